@@ -0,0 +1,55 @@
+package integrationtests_test
+
+import (
+	"encoding/json"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-annotate", func() {
+	const brokerName = "annotate-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan1", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(repeat(3, fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond})...),
+				),
+			),
+		)
+	})
+
+	It("records the provenance of the upgrade, which is shown in later reports", func() {
+		session := cf("upgrade-all-services", brokerName, "-annotate", "--instance-polling-interval", "1ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+
+		// The upgraded instances are up-to-date, so the inventory is used to list them
+		session = cf("upgrade-all-services", brokerName, "-inventory", "-json")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+
+		var receiver struct {
+			Instances []struct {
+				LastUpgrade struct {
+					UpgradedAt      string `json:"upgraded_at"`
+					PreviousVersion string `json:"previous_version"`
+					RunID           string `json:"run_id"`
+				} `json:"last_upgrade"`
+			} `json:"instances"`
+		}
+		Expect(json.Unmarshal(session.Out.Contents(), &receiver)).To(Succeed())
+		Expect(receiver.Instances).To(HaveLen(3))
+		for _, instance := range receiver.Instances {
+			Expect(instance.LastUpgrade.PreviousVersion).To(Equal("1.2.2"))
+			Expect(instance.LastUpgrade.RunID).To(Equal(receiver.Instances[0].LastUpgrade.RunID))
+			Expect(instance.LastUpgrade.RunID).NotTo(BeEmpty())
+			Expect(time.Parse(time.RFC3339, instance.LastUpgrade.UpgradedAt)).To(BeTemporally("~", time.Now(), time.Minute))
+		}
+	})
+})
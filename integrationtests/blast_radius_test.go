@@ -0,0 +1,61 @@
+package integrationtests_test
+
+import (
+	"encoding/json"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-blast-radius", func() {
+	const brokerName = "blast-radius-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", UpgradeAvailable: true, Version: "1.2.2", BoundApps: []string{"app-2", "app-1"}},
+						fakecapi.ServiceInstance{Name: "service-instance-2", UpgradeAvailable: true, Version: "1.2.2"},
+						fakecapi.ServiceInstance{Name: "service-instance-3", UpgradeAvailable: false, Version: "1.2.3", BoundApps: []string{"app-3"}},
+					),
+				),
+			),
+		)
+	})
+
+	It("logs the apps bound to the service instances that would be upgraded", func() {
+		session := cf("upgrade-all-services", brokerName, "-dry-run", "-blast-radius")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(string(session.Out.Contents())).To(ContainSubstring(`instance: "service-instance-1" guid: "5cc87b43-f885-3b94-328f-8a5f953590d3" org: "fake-org" is bound to 2 apps: app-1, app-2`))
+		Expect(string(session.Out.Contents())).To(MatchRegexp(`instance: "service-instance-2" guid: "\S+" org: "fake-org" is bound to 0 apps\n`))
+		Expect(string(session.Out.Contents())).To(ContainSubstring(`org: "fake-org" guid: "1a2f43b5-1594-4247-a888-e8843ebd1b03" has 2 upgradable instances bound to 2 apps`))
+		Expect(string(session.Out.Contents())).NotTo(ContainSubstring("app-3"))
+	})
+
+	It("adds the bound apps to the JSON output", func() {
+		session := cf("upgrade-all-services", brokerName, "-dry-run", "-blast-radius", "-json")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+
+		var output struct {
+			Upgrade []struct {
+				Name     string `json:"name"`
+				Bindings struct {
+					AppCount int `json:"app_count"`
+				} `json:"bindings"`
+			} `json:"upgrade"`
+			BlastRadius []map[string]any `json:"blast_radius"`
+		}
+		Expect(json.Unmarshal(session.Out.Contents(), &output)).To(Succeed())
+		Expect(output.Upgrade).To(HaveLen(2))
+		Expect(output.Upgrade[0].Name).To(Equal("service-instance-1"))
+		Expect(output.Upgrade[0].Bindings.AppCount).To(Equal(2))
+		Expect(output.BlastRadius).To(ConsistOf(HaveKeyWithValue("apps", float64(2))))
+	})
+})
@@ -1,6 +1,8 @@
 package integrationtests_test
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 	"upgrade-all-services-cli-plugin/internal/fakecapi"
@@ -64,6 +66,10 @@ Number of service instances associated with deactivated plans: 2
   Space GUID: "5f870ea3-fa54-4174-ab3f-15f2d9516e07"
   Organization Name: "fake-org"
   Organization GUID: "1a2f43b5-1594-4247-a888-e8843ebd1b03"
+  Replacement Plan Candidates: "service-plan1"
+  Suggested Replacement Plan: "service-plan1" (only candidate)
+  Migration Command: "cf target -o fake-org -s fake-space && cf update-service service-instance-3 -p service-plan1"
+  Migration Entry: "service-offering-1:service-plan-2=service-plan1"
 
   Service Instance Name: "service-instance-4"
   Service Instance GUID: "c53ccd0e-b88e-0d93-712d-609588651af0"
@@ -77,6 +83,7 @@ Number of service instances associated with deactivated plans: 2
   Space GUID: "5f870ea3-fa54-4174-ab3f-15f2d9516e07"
   Organization Name: "fake-org"
   Organization GUID: "1a2f43b5-1594-4247-a888-e8843ebd1b03"
+  Replacement Plan Candidates: "none"
 `)))
 		Expect(string(session.Err.Contents())).To(Equal("upgrade-all-services plugin failed: discovered deactivated plans associated with instances"))
 	})
@@ -104,6 +111,12 @@ Number of service instances associated with deactivated plans: 2
         "guid": "3ccc0ed1-1c06-036b-7bfe-f4d9dff25d02",
         "name": "service-plan-2"
       },
+      "replacement": {
+        "candidates": ["service-plan1"],
+        "plan": "service-plan1",
+        "command": "cf target -o fake-org -s fake-space && cf update-service service-instance-3 -p service-plan1",
+        "migration": "service-offering-1:service-plan-2=service-plan1"
+      },
       "space": {
         "guid": "5f870ea3-fa54-4174-ab3f-15f2d9516e07",
         "name": "fake-space"
@@ -127,6 +140,9 @@ Number of service instances associated with deactivated plans: 2
         "guid": "51f29f1b-d343-6bdd-0192-deb80d4c6d9f",
         "name": "service-plan-3"
       },
+      "replacement": {
+        "candidates": []
+      },
       "space": {
         "guid": "5f870ea3-fa54-4174-ab3f-15f2d9516e07",
         "name": "fake-space"
@@ -136,6 +152,15 @@ Number of service instances associated with deactivated plans: 2
 `))
 	})
 
+	It("suggests the preferred replacement plans from a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "replacements.txt")
+		Expect(os.WriteFile(path, []byte("service-plan-2=service-plan1\n"), 0o600)).To(Succeed())
+
+		session := cf("upgrade-all-services", brokerName, "-check-deactivated-plans", "-replacement-plans-file", path)
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(string(session.Out.Contents())).To(ContainSubstring(`Suggested Replacement Plan: "service-plan1" (preferred)`))
+	})
+
 	It("respects the -ignore-instance-errors flag", func() {
 		session := cf("upgrade-all-services", brokerName, "-check-deactivated-plans", "-ignore-instance-errors")
 		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
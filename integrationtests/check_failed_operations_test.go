@@ -0,0 +1,42 @@
+package integrationtests_test
+
+import (
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-check-failed-operations", func() {
+	const brokerName = "check-failed-operations-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Available: true, Version: "1.2.3"},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", Version: "1.2.3"},
+						fakecapi.ServiceInstance{Name: "service-instance-2", Version: "1.2.2", UpgradeAvailable: true, LastOperationType: "update", LastOperationState: "failed", LastOperationDescription: "broker timed out", LastOperationUpdatedAt: time.Now().Add(-3 * 24 * time.Hour)},
+						fakecapi.ServiceInstance{Name: "service-instance-3", Version: "1.2.3", LastOperationType: "create", LastOperationState: "failed", LastOperationDescription: "quota exceeded"},
+					),
+				),
+			),
+		)
+	})
+
+	It("lists the service instances whose last operation failed", func() {
+		session := cf("upgrade-all-services", brokerName, "-check-failed-operations")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("Number of service instances which failed to create: 1"))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("Number of service instances which failed to update: 1"))
+		Expect(string(session.Out.Contents())).To(ContainSubstring(`Last Operation Message: "broker timed out"`))
+		Expect(string(session.Out.Contents())).To(ContainSubstring(`Last Operation Failed: "3 days ago"`))
+		Expect(string(session.Out.Contents())).NotTo(ContainSubstring(`Service Instance Name: "service-instance-1"`))
+		Expect(string(session.Err.Contents())).To(Equal("upgrade-all-services plugin failed: found 2 service instances whose last operation failed"))
+	})
+})
@@ -0,0 +1,42 @@
+package integrationtests_test
+
+import (
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-check-version-anomalies", func() {
+	const brokerName = "check-version-anomalies-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Available: true, Version: "1.2.3"},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", Version: "1.2.3"},
+						fakecapi.ServiceInstance{Name: "service-instance-2", Version: "1.2.2", UpgradeAvailable: true},
+						fakecapi.ServiceInstance{Name: "service-instance-3", Version: "1.3.0"},
+						fakecapi.ServiceInstance{Name: "service-instance-4", Version: "1.2.1"},
+					),
+				),
+			),
+		)
+	})
+
+	It("lists the service instances with a version that is inconsistent with their plan", func() {
+		session := cf("upgrade-all-services", brokerName, "-check-version-anomalies")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(string(session.Out.Contents())).To(MatchRegexp(`(?s)Number of service instances with a version ahead of their plan: 1.*"service-instance-3"`))
+		Expect(string(session.Out.Contents())).To(MatchRegexp(`(?s)Number of service instances behind their plan without an upgrade available: 1.*"service-instance-4"`))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("Number of service instances missing maintenance_info: 0"))
+		Expect(string(session.Out.Contents())).NotTo(ContainSubstring(`Service Instance Name: "service-instance-2"`))
+		Expect(string(session.Err.Contents())).To(Equal("upgrade-all-services plugin failed: discovered service instances with a version that is inconsistent with their plan"))
+	})
+})
@@ -0,0 +1,52 @@
+package integrationtests_test
+
+import (
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("combined checks", func() {
+	const brokerName = "combined-checks-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Available: true, Version: "1.2.3"},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", UpgradeAvailable: true, Version: "1.2.2"},
+						fakecapi.ServiceInstance{Name: "service-instance-2", UpgradeAvailable: false, Version: "1.2.3"},
+					),
+				),
+			),
+		)
+	})
+
+	It("reports each check and an overall result", func() {
+		session := cf("upgrade-all-services", brokerName, "-check-deactivated-plans", "-version-constraint", ">= 1.2.3")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("Check check-deactivated-plans: passed"))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("Check version-constraint: failed, found 1 service instances outside the version constraint"))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("1 of 2 checks failed"))
+		Expect(string(session.Err.Contents())).To(Equal("upgrade-all-services plugin failed: 1 of 2 checks failed: version-constraint"))
+	})
+
+	It("selects the checks by name", func() {
+		session := cf("upgrade-all-services", brokerName, "-checks", "dry-run,check-deactivated-plans")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("Check dry-run: passed"))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("Check check-deactivated-plans: passed"))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("All 2 checks passed"))
+	})
+
+	It("respects the -ignore-instance-errors flag", func() {
+		session := cf("upgrade-all-services", brokerName, "-check-deactivated-plans", "-version-constraint", ">= 1.2.3", "-ignore-instance-errors")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+	})
+})
@@ -0,0 +1,47 @@
+package integrationtests_test
+
+import (
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-health-check-window", func() {
+	const brokerName = "health-check-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", UpgradeAvailable: true, Version: "1.2.2", BoundApps: []string{"app-1", "app-2"}, CrashedApps: []string{"app-2"}},
+						fakecapi.ServiceInstance{Name: "service-instance-2", UpgradeAvailable: true, Version: "1.2.2", BoundApps: []string{"app-3"}},
+					),
+				),
+			),
+		)
+	})
+
+	It("marks an upgrade as degraded when a bound app crashes", func() {
+		session := cf("upgrade-all-services", brokerName, "-health-check-window", "50ms", "--instance-polling-interval", "10ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(session.Out).To(Say(`upgrade of instance: "service-instance-1" guid: "5cc87b43-f885-3b94-328f-8a5f953590d3" completed, but it is degraded: bound apps have crashed process instances: "app-2" web/0`))
+		Expect(session.Out).To(Say(`successfully upgraded 1 instances`))
+		Expect(session.Out).To(Say(`1 upgraded instances are degraded`))
+	})
+
+	It("stops upgrading with -stop-on-degraded", func() {
+		session := cf("upgrade-all-services", brokerName, "-health-check-window", "50ms", "-stop-on-degraded", "-parallel", "1", "--instance-polling-interval", "10ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(session.Out).To(Say(`not upgrading instance: "service-instance-2" guid: "\S+" as the upgrade was stopped`))
+		Expect(session.Err).To(Say(`the upgrade was stopped because apps bound to an upgraded instance crashed`))
+		Expect(capi.UpdateCount()).To(Equal(1))
+	})
+})
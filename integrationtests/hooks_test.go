@@ -0,0 +1,57 @@
+package integrationtests_test
+
+import (
+	"os"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-pre-hook and -post-hook", func() {
+	const brokerName = "hooks-broker"
+
+	var dir string
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan1", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "backup-ok", UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond},
+						fakecapi.ServiceInstance{Name: "backup-fails", UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond},
+					),
+				),
+			),
+		)
+	})
+
+	It("runs the hooks for each instance", func() {
+		session := cf(
+			"upgrade-all-services", brokerName,
+			"--instance-polling-interval", "1ms",
+			"-pre-hook", `test "$UAS_INSTANCE_NAME" != backup-fails || { echo "backup of $UAS_INSTANCE_NAME failed"; exit 1; }`,
+			"-post-hook", `touch "`+dir+`/$UAS_INSTANCE_NAME-$UAS_CURRENT_VERSION-$UAS_TARGET_VERSION"`,
+		)
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+
+		Expect(session.Out).To(Say(`\S+: pre-hook output for instance: "backup-fails" guid: "\S+":\nbackup of backup-fails failed`))
+		Expect(session.Out).To(Say(`\S+: skipping instance: "backup-fails" guid: "\S+" as the pre-hook failed: exit status 1`))
+		Expect(session.Out).To(Say(`\S+: successfully upgraded 1 instances`))
+		Expect(session.Out).To(Say(`\S+: pre-hook failed for 1 instances`))
+		Expect(capi.UpdateCount()).To(Equal(1))
+
+		entries, err := os.ReadDir(dir)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Name()).To(Equal("backup-ok-1.2.2-1.2.3"))
+	})
+})
@@ -0,0 +1,54 @@
+package integrationtests_test
+
+import (
+	"encoding/csv"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-inventory", func() {
+	const brokerName = "inventory-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Available: true, Version: "1.2.3"},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", UpgradeAvailable: false, Version: "1.2.3"},
+						fakecapi.ServiceInstance{Name: "service-instance-2", UpgradeAvailable: true, Version: "1.2.2"},
+					),
+				),
+			),
+		)
+	})
+
+	It("lists the versions without failing", func() {
+		session := cf("upgrade-all-services", brokerName, "-inventory")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(session.Out).To(Say(`Total number of service instances: 2\n`))
+		Expect(session.Out).To(Say(`Versions of service offering "service-offering-1" plan "service-plan-1" \(plan version "1.2.3"\): 2\n`))
+		Expect(session.Out).To(Say(`  1.2.3\s+#+\s+1\s+50.0%\n`))
+		Expect(session.Out).To(Say(`  1.2.2\s+#+\s+1\s+50.0%\n`))
+	})
+
+	It("writes CSV", func() {
+		session := cf("upgrade-all-services", brokerName, "-inventory", "-output", "csv", "-columns", "name,version,plan_version,status")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+
+		records, err := csv.NewReader(session.Out).ReadAll()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(ConsistOf(
+			[]string{"name", "version", "plan_version", "status"},
+			[]string{"service-instance-1", "1.2.3", "1.2.3", "up_to_date"},
+			[]string{"service-instance-2", "1.2.2", "1.2.3", "upgrade_pending"},
+		))
+	})
+})
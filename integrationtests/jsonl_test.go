@@ -0,0 +1,53 @@
+package integrationtests_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-output jsonl", func() {
+	const brokerName = "jsonl-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan1", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(repeat(3, fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond})...),
+				),
+			),
+		)
+	})
+
+	It("writes events to stdout and the log to stderr", func() {
+		session := cf("upgrade-all-services", brokerName, "-output", "jsonl", "--instance-polling-interval", "1ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(session.Err).To(Say(`\S+: successfully upgraded 3 instances`))
+
+		counts := make(map[string]int)
+		scanner := bufio.NewScanner(bytes.NewReader(session.Out.Contents()))
+		for scanner.Scan() {
+			var line struct {
+				Event string `json:"event"`
+				RunID string `json:"run_id"`
+			}
+			Expect(json.Unmarshal(scanner.Bytes(), &line)).To(Succeed(), scanner.Text())
+			Expect(line.RunID).NotTo(BeEmpty())
+			counts[line.Event]++
+		}
+		Expect(counts).To(HaveKeyWithValue("initial_totals", 1))
+		Expect(counts).To(HaveKeyWithValue("upgrade_starting", 3))
+		Expect(counts).To(HaveKeyWithValue("upgrade_succeeded", 3))
+		Expect(counts).To(HaveKeyWithValue("final_totals", 1))
+	})
+})
@@ -0,0 +1,76 @@
+package integrationtests_test
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-junit-report", func() {
+	const brokerName = "junit-broker"
+
+	type receiver struct {
+		Tests    int `xml:"tests,attr"`
+		Failures int `xml:"failures,attr"`
+		Suites   []struct {
+			Name  string `xml:"name,attr"`
+			Cases []struct {
+				Name    string    `xml:"name,attr"`
+				Failure *struct{} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+
+	readReport := func(path string) receiver {
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		var r receiver
+		Expect(xml.Unmarshal(data, &r)).To(Succeed())
+		return r
+	}
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan1", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond},
+						fakecapi.ServiceInstance{Version: "1.2.3"},
+					),
+				),
+			),
+		)
+	})
+
+	It("writes a test case for each instance checked", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "junit.xml")
+		session := cf("upgrade-all-services", brokerName, "-check-up-to-date", "-junit-report", path)
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+
+		r := readReport(path)
+		Expect(r.Tests).To(Equal(2))
+		Expect(r.Failures).To(Equal(1))
+		Expect(r.Suites).To(HaveLen(1))
+		Expect(r.Suites[0].Name).To(Equal("check-up-to-date"))
+	})
+
+	It("writes a test case for each instance upgraded", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "junit.xml")
+		session := cf("upgrade-all-services", brokerName, "-junit-report", path, "--instance-polling-interval", "1ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+
+		r := readReport(path)
+		Expect(r.Failures).To(Equal(0))
+		Expect(r.Suites).To(HaveLen(1))
+		Expect(r.Suites[0].Name).To(Equal("upgrade"))
+	})
+})
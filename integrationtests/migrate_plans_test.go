@@ -0,0 +1,73 @@
+package integrationtests_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-migrate-plans", func() {
+	const brokerName = "migrate-plans-broker"
+
+	cfFast := func(args ...string) *Session {
+		return cf(append(args, "--instance-polling-interval", "1ms")...)
+	}
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "retired-plan", Version: "1.2.3", Available: false},
+					fakecapi.WithServiceInstances(repeat(20, fakecapi.ServiceInstance{Version: "1.2.3", UpdateTime: time.Millisecond})...),
+				),
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "replacement-plan", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(repeat(5, fakecapi.ServiceInstance{Version: "1.2.3"})...),
+				),
+			),
+		)
+	})
+
+	It("moves the service instances onto the replacement plan", func() {
+		session := cfFast("upgrade-all-services", brokerName, "-migrate-plans", "service-offering-1:retired-plan=replacement-plan")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(session.Out).To(Say(strings.TrimSpace(`
+\S+: discovering service instances for broker: migrate-plans-broker
+\S+: ---
+\S+: total instances: 20
+\S+: migratable instances: 20
+`)))
+		Expect(session.Out).To(Say(`\S+: successfully migrated 20 instances`))
+		Expect(capi.UpdateCount()).To(Equal(20))
+
+		By("finding no more instances on the retired plan")
+		session = cfFast("upgrade-all-services", brokerName, "-migrate-plans", "retired-plan=replacement-plan")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(session.Out).To(Say(`\S+: no instances available to migrate`))
+	})
+
+	It("reads the mappings from a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "mappings")
+		Expect(os.WriteFile(path, []byte("# retired plans\nretired-plan=replacement-plan\n"), 0o600)).To(Succeed())
+
+		session := cfFast("upgrade-all-services", brokerName, "-migrate-plans-file", path)
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(capi.UpdateCount()).To(Equal(20))
+	})
+
+	It("refuses to migrate onto a plan that is not active", func() {
+		session := cfFast("upgrade-all-services", brokerName, "-migrate-plans", "replacement-plan=retired-plan")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(session.Err).To(Say(`invalid plan mapping "replacement-plan=retired-plan": plan "retired-plan" of service offering "service-offering-1" is not active`))
+		Expect(capi.UpdateCount()).To(BeZero())
+	})
+})
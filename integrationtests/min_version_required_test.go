@@ -1,6 +1,8 @@
 package integrationtests_test
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 	"upgrade-all-services-cli-plugin/internal/fakecapi"
@@ -248,6 +250,34 @@ No instances found with version lower than "1.2.0"
 		})
 	})
 
+	Context("minimum version policy", func() {
+		var policyFile string
+
+		BeforeEach(func() {
+			policyFile = filepath.Join(GinkgoT().TempDir(), "policy")
+			Expect(os.WriteFile(policyFile, []byte("# minimum versions\n*=1.2.0\nservice-offering-1:service-plan1=1.2.3\n"), 0o600)).To(Succeed())
+		})
+
+		It("reports the rule that each service instance violates", func() {
+			session := cf("upgrade-all-services", brokerName, "-min-version-policy", policyFile)
+			Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+
+			output := string(session.Out.Contents())
+			Expect(output).To(ContainSubstring("Number of service instances that violate the minimum version policy: 1"))
+			Expect(output).To(ContainSubstring(`Service Instance Name: "service-instance-2"`))
+			Expect(output).To(ContainSubstring(`Minimum Version Rule: "service-offering-1:service-plan1 >= 1.2.3"`))
+			Expect(output).NotTo(ContainSubstring("service-instance-3"))
+			Expect(output).NotTo(ContainSubstring("service-instance-4"))
+			Expect(string(session.Err.Contents())).To(Equal(`upgrade-all-services plugin failed: found 1 service instances that violate the minimum version policy`))
+		})
+
+		It("cannot be combined with -min-version-required", func() {
+			session := cf("upgrade-all-services", brokerName, "-min-version-policy", policyFile, "-min-version-required", "1.2.3")
+			Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+			Expect(string(session.Err.Contents())).To(ContainSubstring("invalid flag combination: --min-version-policy, --min-version-required"))
+		})
+	})
+
 	It("respects the -ignore-instance-errors flag", func() {
 		session := cf("upgrade-all-services", brokerName, "-min-version-required", "1.2.3", "-ignore-instance-errors")
 		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
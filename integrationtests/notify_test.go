@@ -0,0 +1,72 @@
+package integrationtests_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("-notify-url", func() {
+	const brokerName = "notify-broker"
+
+	var (
+		webhook *ghttp.Server
+		lock    sync.Mutex
+		events  []string
+	)
+
+	BeforeEach(func() {
+		events = nil
+		webhook = ghttp.NewServer()
+		webhook.SetAllowUnhandledRequests(true)
+		webhook.RouteToHandler(http.MethodPost, "/hook", func(w http.ResponseWriter, r *http.Request) {
+			var e struct {
+				Event string `json:"event"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&e)
+			lock.Lock()
+			defer lock.Unlock()
+			events = append(events, e.Event)
+		})
+		DeferCleanup(webhook.Close)
+
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan1", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond},
+						fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond, FailTimes: 1},
+					),
+				),
+			),
+		)
+	})
+
+	It("posts events for the start, failures and end of the run", func() {
+		session := cf("upgrade-all-services", brokerName, "-notify-url", webhook.URL()+"/hook", "--instance-polling-interval", "1ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+
+		lock.Lock()
+		defer lock.Unlock()
+		Expect(events).To(Equal([]string{"run_started", "instance_failed", "run_finished"}))
+	})
+
+	It("posts an event when the run is aborted", func() {
+		session := cf("upgrade-all-services", "no-such-broker", "-notify-url", webhook.URL()+"/hook")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+
+		lock.Lock()
+		defer lock.Unlock()
+		Expect(events).To(Equal([]string{"run_aborted"}))
+	})
+})
@@ -0,0 +1,55 @@
+package integrationtests_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-max-pending-age", func() {
+	const brokerName = "max-pending-age-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Available: true, Version: "1.2.3", UpdatedAt: time.Now().Add(-45 * 24 * time.Hour)},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", UpgradeAvailable: true, Version: "1.2.2"},
+						fakecapi.ServiceInstance{Name: "service-instance-2", UpgradeAvailable: false, Version: "1.2.3"},
+					),
+				),
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-2", Available: true, Version: "1.3.0", UpdatedAt: time.Now().Add(-2 * 24 * time.Hour)},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-3", UpgradeAvailable: true, Version: "1.2.3"},
+					),
+				),
+			),
+		)
+	})
+
+	It("lists the service instances with an upgrade pending for too long", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "pending-since.json")
+		session := cf("upgrade-all-services", brokerName, "-max-pending-age", "30d", "-pending-since-file", path)
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("Number of service instances with an upgrade pending for longer than 30 days: 1"))
+		Expect(string(session.Out.Contents())).To(ContainSubstring(`Service Instance Name: "service-instance-1"`))
+		Expect(string(session.Out.Contents())).To(ContainSubstring(`Upgrade Pending For: "45 days"`))
+		Expect(string(session.Err.Contents())).To(Equal("upgrade-all-services plugin failed: found 1 service instances with an upgrade pending for longer than 30 days"))
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		var record map[string]time.Time
+		Expect(json.Unmarshal(data, &record)).To(Succeed())
+		Expect(record).To(HaveLen(2))
+	})
+})
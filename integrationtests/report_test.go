@@ -0,0 +1,106 @@
+package integrationtests_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("upgrade report", func() {
+	const brokerName = "report-broker"
+
+	type receiver struct {
+		Totals struct {
+			ByOutcome map[string]int `json:"by_outcome"`
+		} `json:"totals"`
+		Instances []struct {
+			Outcome       string `json:"outcome"`
+			VersionBefore string `json:"version_before"`
+			VersionAfter  string `json:"version_after"`
+			Attempts      []struct {
+				Error string `json:"error"`
+			} `json:"attempts"`
+		} `json:"instances"`
+	}
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan1", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond},
+						fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond, FailTimes: 1},
+					),
+				),
+			),
+		)
+	})
+
+	It("writes the report to stdout and the log to stderr", func() {
+		session := cf("upgrade-all-services", brokerName, "-json", "-attempts", "2", "--instance-polling-interval", "1ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(session.Err).To(Say(`\S+: successfully upgraded 2 instances`))
+
+		var r receiver
+		Expect(json.Unmarshal(session.Out.Contents(), &r)).To(Succeed())
+		Expect(r.Totals.ByOutcome).To(HaveKeyWithValue("succeeded", 2))
+		Expect(r.Instances).To(HaveLen(2))
+		attempts := 0
+		for _, instance := range r.Instances {
+			Expect(instance.Outcome).To(Equal("succeeded"))
+			Expect(instance.VersionBefore).To(Equal("1.2.2"))
+			Expect(instance.VersionAfter).To(Equal("1.2.3"))
+			attempts += len(instance.Attempts)
+		}
+		Expect(attempts).To(Equal(3))
+	})
+
+	It("writes the report to a file", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "report.json")
+		session := cf("upgrade-all-services", brokerName, "-report-file", path, "--instance-polling-interval", "1ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(session.Out).To(Say(`\S+: failed to upgrade 1 instances`))
+
+		data, err := os.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		var r receiver
+		Expect(json.Unmarshal(data, &r)).To(Succeed())
+		Expect(r.Totals.ByOutcome).To(HaveKeyWithValue("succeeded", 1))
+		Expect(r.Totals.ByOutcome).To(HaveKeyWithValue("failed", 1))
+	})
+	It("reports instances which are not at the target version after upgrading", func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: "stuck-broker"},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "stuck-offering"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "stuck-plan", Version: "1.2.3", Available: true},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond, KeepVersion: true},
+					),
+				),
+			),
+		)
+
+		session := cf("upgrade-all-services", "stuck-broker", "-json", "--instance-polling-interval", "1ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(session.Err).To(Say(`completed, but it is not at the target version: version is "1.2.2" rather than the target version "1.2.3"`))
+
+		var r receiver
+		Expect(json.Unmarshal(session.Out.Contents(), &r)).To(Succeed())
+		Expect(r.Totals.ByOutcome).To(HaveKeyWithValue("not_at_target_version", 1))
+		Expect(r.Instances).To(HaveLen(1))
+		Expect(r.Instances[0].Outcome).To(Equal("not_at_target_version"))
+		Expect(r.Instances[0].VersionAfter).To(Equal("1.2.2"))
+	})
+})
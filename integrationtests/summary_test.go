@@ -0,0 +1,54 @@
+package integrationtests_test
+
+import (
+	"encoding/json"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-summary", func() {
+	const brokerName = "summary-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Available: true, Version: "1.2.3"},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.2", UpdateTime: time.Millisecond},
+						fakecapi.ServiceInstance{UpgradeAvailable: true, Version: "1.2.1", UpdateTime: time.Millisecond},
+					),
+				),
+			),
+		)
+	})
+
+	It("summarizes a check", func() {
+		session := cf("upgrade-all-services", brokerName, "-check-up-to-date", "-summary")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(session.Out).To(Say(`Summary of service instances with an upgrade available: 2\n`))
+		Expect(session.Out).To(Say(`  By service plan:\n    service-offering-1/service-plan-1\s+2\s+100.0%\n`))
+		Expect(session.Out).To(Say(`  By version:\n    1.2.1\s+1\s+50.0%\n    1.2.2\s+1\s+50.0%\n`))
+	})
+
+	It("summarizes an upgrade in the JSON report", func() {
+		session := cf("upgrade-all-services", brokerName, "-json", "-summary", "--instance-polling-interval", "1ms")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(session.Err).To(Say(`Summary of upgraded service instances: 2\n`))
+
+		var receiver struct {
+			Summary map[string]struct {
+				Total int `json:"total"`
+			} `json:"summary"`
+		}
+		Expect(json.Unmarshal(session.Out.Contents(), &receiver)).To(Succeed())
+		Expect(receiver.Summary).To(HaveKeyWithValue("succeeded", HaveField("Total", 2)))
+	})
+})
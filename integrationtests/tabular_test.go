@@ -0,0 +1,51 @@
+package integrationtests_test
+
+import (
+	"encoding/csv"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gbytes"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-output table and csv", func() {
+	const brokerName = "tabular-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Available: true, Version: "1.2.3"},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", UpgradeAvailable: false, Version: "1.2.3"},
+						fakecapi.ServiceInstance{Name: "service-instance-2", UpgradeAvailable: true, Version: "1.2.2"},
+					),
+				),
+			),
+		)
+	})
+
+	It("writes a CSV row for each instance that is not up to date", func() {
+		session := cf("upgrade-all-services", brokerName, "-check-up-to-date", "-output", "csv", "-columns", "status,name,offering,plan,version")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+
+		records, err := csv.NewReader(session.Out).ReadAll()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(Equal([][]string{
+			{"status", "name", "offering", "plan", "version"},
+			{"upgrade_pending", "service-instance-2", "service-offering-1", "service-plan-1", "1.2.2"},
+		}))
+	})
+
+	It("writes a table row for each instance in a dry run", func() {
+		session := cf("upgrade-all-services", brokerName, "-dry-run", "-output", "table", "-columns", "status,name,version")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(session.Out).To(Say(`status\s+name\s+version\n`))
+		Expect(session.Out).To(Say(`upgrade\s+service-instance-2\s+1.2.2\n`))
+	})
+})
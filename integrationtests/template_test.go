@@ -0,0 +1,50 @@
+package integrationtests_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-template", func() {
+	const brokerName = "template-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Available: true, Version: "1.2.3"},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", UpgradeAvailable: false, Version: "1.2.3"},
+						fakecapi.ServiceInstance{Name: "service-instance-2", UpgradeAvailable: true, Version: "1.2.2"},
+					),
+				),
+			),
+		)
+	})
+
+	It("renders the template", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "report.tmpl")
+		Expect(os.WriteFile(path, []byte(`{{.BrokerName}}: {{.Totals.Upgradeable}} of {{.Totals.All}} to upgrade: {{join ", " (pluck "name" .Upgradeable)}}`), 0o600)).To(Succeed())
+
+		session := cf("upgrade-all-services", brokerName, "-dry-run", "-template", path)
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(string(session.Out.Contents())).To(Equal("template-broker: 1 of 2 to upgrade: service-instance-2"))
+	})
+
+	It("fails before doing any work when the template is invalid", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "report.tmpl")
+		Expect(os.WriteFile(path, []byte(`{{range .All}}`), 0o600)).To(Succeed())
+
+		session := cf("upgrade-all-services", brokerName, "-dry-run", "-template", path)
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(session.Err.Contents()).To(ContainSubstring("error parsing template file"))
+	})
+})
@@ -0,0 +1,49 @@
+package integrationtests_test
+
+import (
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("thresholds", func() {
+	const brokerName = "thresholds-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Available: true, Version: "1.2.3", UpdatedAt: time.Now().Add(-90 * 24 * time.Hour)},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", UpgradeAvailable: true, Version: "1.2.2"},
+						fakecapi.ServiceInstance{Name: "service-instance-2", UpgradeAvailable: false, Version: "1.2.3"},
+					),
+				),
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-2", Available: true, Version: "1.3.0", UpdatedAt: time.Now().Add(-24 * time.Hour)},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-3", UpgradeAvailable: true, Version: "1.2.3"},
+						fakecapi.ServiceInstance{Name: "service-instance-4", UpgradeAvailable: false, Version: "1.3.0"},
+					),
+				),
+			),
+		)
+	})
+
+	It("fails when the violations are above the threshold", func() {
+		session := cf("upgrade-all-services", brokerName, "-check-up-to-date", "-fail-if-more-than", "1")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+		Expect(string(session.Err.Contents())).To(Equal("upgrade-all-services plugin failed: discovered service instances associated with deactivated plans or with an upgrade available: 2 of 4 service instances (50.0%) count towards the thresholds"))
+	})
+
+	It("passes when the violations within the grace period do not count", func() {
+		session := cf("upgrade-all-services", brokerName, "-check-up-to-date", "-fail-if-more-than", "1", "-grace-period-days", "7")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(string(session.Out.Contents())).To(ContainSubstring("Number of service instances with an upgrade available: 2"))
+	})
+})
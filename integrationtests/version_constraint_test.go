@@ -0,0 +1,90 @@
+package integrationtests_test
+
+import (
+	"strings"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/fakecapi"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("-version-constraint", func() {
+	const brokerName = "version-constraint-broker"
+
+	BeforeEach(func() {
+		capi.AddBroker(
+			fakecapi.ServiceBroker{Name: brokerName},
+			fakecapi.WithServiceOffering(
+				fakecapi.ServiceOffering{Name: "service-offering-1"},
+				fakecapi.WithServicePlan(
+					fakecapi.ServicePlan{Name: "service-plan-1", Version: "1.6.0"},
+					fakecapi.WithServiceInstances(
+						fakecapi.ServiceInstance{Name: "service-instance-1", Version: "1.6.0"},
+						fakecapi.ServiceInstance{Name: "service-instance-2", Version: "1.5.3"},
+						fakecapi.ServiceInstance{Name: "service-instance-3", Version: "1.3.0"},
+					),
+				),
+			),
+		)
+	})
+
+	It("detects versions outside the constraint", func() {
+		session := cf("upgrade-all-services", brokerName, "-version-constraint", ">= 1.4.0, != 1.5.3")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+
+		output := string(session.Out.Contents())
+		Expect(output).To(ContainSubstring(`Number of service instances outside the version constraint ">= 1.4.0, != 1.5.3": 2`))
+		Expect(output).To(ContainSubstring(`Service Instance Name: "service-instance-2"`))
+		Expect(output).To(ContainSubstring(`Service Instance Name: "service-instance-3"`))
+		Expect(output).NotTo(ContainSubstring("service-instance-1"))
+		Expect(string(session.Err.Contents())).To(Equal(`upgrade-all-services plugin failed: found 2 service instances outside the version constraint`))
+	})
+
+	It("has a exit code of zero when all instances satisfy the constraint", func() {
+		session := cf("upgrade-all-services", brokerName, "-version-constraint", "~> 1.3")
+		Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+		Expect(strings.TrimSpace(string(session.Out.Contents()))).To(Equal(strings.TrimSpace(`
+Discovering service instances for broker: version-constraint-broker
+Total number of service instances: 3
+No instances found outside the version constraint "~> 1.3"
+`)))
+	})
+
+	When("a service instance has no version", func() {
+		const legacyBrokerName = "legacy-version-broker"
+
+		BeforeEach(func() {
+			capi.AddBroker(
+				fakecapi.ServiceBroker{Name: legacyBrokerName},
+				fakecapi.WithServiceOffering(
+					fakecapi.ServiceOffering{Name: "service-offering-1"},
+					fakecapi.WithServicePlan(
+						fakecapi.ServicePlan{Name: "service-plan-1", Version: "1.6.0"},
+						fakecapi.WithServiceInstances(
+							fakecapi.ServiceInstance{Name: "service-instance-1", Version: "1.6.0"},
+							fakecapi.ServiceInstance{Name: "legacy-instance"},
+						),
+					),
+				),
+			)
+		})
+
+		It("lists it with an unknown version, and succeeds", func() {
+			session := cf("upgrade-all-services", legacyBrokerName, "-version-constraint", "~> 1.6")
+			Eventually(session).WithTimeout(time.Minute).Should(Exit(0))
+
+			output := string(session.Out.Contents())
+			Expect(output).To(ContainSubstring(`No instances found outside the version constraint "~> 1.6"`))
+			Expect(output).To(ContainSubstring("Number of service instances with an unknown version: 1"))
+			Expect(output).To(ContainSubstring(`Service Instance Name: "legacy-instance"`))
+		})
+
+		It("fails with -fail-on-unknown-version", func() {
+			session := cf("upgrade-all-services", legacyBrokerName, "-version-constraint", "~> 1.6", "-fail-on-unknown-version")
+			Eventually(session).WithTimeout(time.Minute).Should(Exit(1))
+			Expect(string(session.Err.Contents())).To(Equal(`upgrade-all-services plugin failed: found 1 service instances with an unknown version`))
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package ccapi
+
+import "fmt"
+
+// ProcessInstance is the state of one instance of a process of an app, for example "RUNNING" or "CRASHED"
+type ProcessInstance struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	State string `json:"state"`
+}
+
+// GetAppProcessInstances gets the state of each instance of each process of an app
+func (c CCAPI) GetAppProcessInstances(appGUID string) ([]ProcessInstance, error) {
+	var processes struct {
+		Resources []struct {
+			Type string `json:"type"`
+		} `json:"resources"`
+	}
+	if err := c.requester.Get(fmt.Sprintf("v3/apps/%s/processes?per_page=5000", appGUID), &processes); err != nil {
+		return nil, fmt.Errorf("error getting app processes: %w", err)
+	}
+
+	var result []ProcessInstance
+	for _, p := range processes.Resources {
+		var stats struct {
+			Resources []ProcessInstance `json:"resources"`
+		}
+		if err := c.requester.Get(fmt.Sprintf("v3/apps/%s/processes/%s/stats", appGUID, p.Type), &stats); err != nil {
+			return nil, fmt.Errorf("error getting app process stats: %w", err)
+		}
+		result = append(result, stats.Resources...)
+	}
+
+	return result, nil
+}
+
+// HasCrashed is true when the process instance has crashed
+func (p ProcessInstance) HasCrashed() bool {
+	return p.State == "CRASHED"
+}
@@ -0,0 +1,89 @@
+package ccapi_test
+
+import (
+	"net/http"
+	"time"
+
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/requester"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("GetAppProcessInstances", func() {
+	var (
+		fakeServer  *ghttp.Server
+		req         requester.Requester
+		ccapiClient ccapi.CCAPI
+	)
+
+	BeforeEach(func() {
+		fakeServer = ghttp.NewServer()
+		DeferCleanup(fakeServer.Close)
+		req = requester.NewRequester(fakeServer.URL(), "fake-token", false)
+		ccapiClient = ccapi.NewCCAPI(req, time.Millisecond)
+	})
+
+	When("the app has processes", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "fake-token"),
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid/processes", "per_page=5000"),
+					ghttp.RespondWith(http.StatusOK, `{"resources": [{"guid": "process-guid-1", "type": "web"}, {"guid": "process-guid-2", "type": "worker"}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid/processes/web/stats"),
+					ghttp.RespondWith(http.StatusOK, `{"resources": [{"type": "web", "index": 0, "state": "RUNNING"}, {"type": "web", "index": 1, "state": "CRASHED"}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps/app-guid/processes/worker/stats"),
+					ghttp.RespondWith(http.StatusOK, `{"resources": [{"type": "worker", "index": 0, "state": "STARTING"}]}`),
+				),
+			)
+		})
+
+		It("returns the state of each process instance", func() {
+			instances, err := ccapiClient.GetAppProcessInstances("app-guid")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
+			Expect(instances).To(Equal([]ccapi.ProcessInstance{
+				{Type: "web", Index: 0, State: "RUNNING"},
+				{Type: "web", Index: 1, State: "CRASHED"},
+				{Type: "worker", Index: 0, State: "STARTING"},
+			}))
+			Expect(instances[0].HasCrashed()).To(BeFalse())
+			Expect(instances[1].HasCrashed()).To(BeTrue())
+		})
+	})
+
+	When("getting the processes fails", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, nil))
+		})
+
+		It("returns an error", func() {
+			_, err := ccapiClient.GetAppProcessInstances("app-guid")
+
+			Expect(err).To(MatchError("error getting app processes: http response: 500"))
+		})
+	})
+
+	When("getting the stats fails", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.RespondWith(http.StatusOK, `{"resources": [{"type": "web"}]}`),
+				ghttp.RespondWith(http.StatusNotFound, nil),
+			)
+		})
+
+		It("returns an error", func() {
+			_, err := ccapiClient.GetAppProcessInstances("app-guid")
+
+			Expect(err).To(MatchError("error getting app process stats: http response: 404"))
+		})
+	})
+})
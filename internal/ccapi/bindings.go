@@ -0,0 +1,175 @@
+package ccapi
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// bindingsBatchSize is the number of service instance GUIDs in each request, which keeps the URL to a sensible length
+const bindingsBatchSize = 50
+
+// BoundApp is an app that uses a service instance, either through a service credential binding, or through a route
+// binding to a route that maps to the app
+type BoundApp struct {
+	GUID string
+	Name string
+}
+
+// Bindings are what would be affected by an operation on a service instance
+type Bindings struct {
+	Apps          []BoundApp
+	ServiceKeys   int
+	RouteBindings int
+}
+
+// pagination says how many pages a list has. Each page is read, so that no bindings are missed when there are more
+// than fit on one page.
+type pagination struct {
+	TotalPages int `json:"total_pages"`
+}
+
+// pageParameter selects a page of a list after the first
+func pageParameter(page int) string {
+	if page == 1 {
+		return ""
+	}
+	return fmt.Sprintf("&page=%d", page)
+}
+
+// GetServiceInstanceBindings gets the service credential bindings and the route bindings of the service instances,
+// and the apps that they are bound to, by service instance GUID. The apps of each service instance are in name order.
+func (c CCAPI) GetServiceInstanceBindings(instanceGUIDs []string) (map[string]Bindings, error) {
+	type binding struct {
+		Type                string `json:"type"`
+		AppGUID             string `jsonry:"relationships.app.data.guid"`
+		ServiceInstanceGUID string `jsonry:"relationships.service_instance.data.guid"`
+	}
+
+	type routeBinding struct {
+		RouteGUID           string `jsonry:"relationships.route.data.guid"`
+		ServiceInstanceGUID string `jsonry:"relationships.service_instance.data.guid"`
+	}
+
+	type destination struct {
+		AppGUID string `jsonry:"app.guid"`
+	}
+
+	type includedRoute struct {
+		GUID         string        `json:"guid"`
+		Destinations []destination `json:"destinations"`
+	}
+
+	type app struct {
+		GUID string `json:"guid"`
+		Name string `json:"name"`
+	}
+
+	result := make(map[string]Bindings, len(instanceGUIDs))
+	appNames := make(map[string]string)
+	appGUIDs := make(map[string][]string)
+
+	for batch := range slices.Chunk(instanceGUIDs, bindingsBatchSize) {
+		guids := strings.Join(batch, ",")
+
+		for page := 1; ; page++ {
+			var credentialBindings struct {
+				Pagination pagination `json:"pagination"`
+				Bindings   []binding  `json:"resources"`
+				Included   struct {
+					Apps []app `json:"apps"`
+				} `json:"included"`
+			}
+			if err := c.requester.Get(fmt.Sprintf("v3/service_credential_bindings?per_page=5000&include=app&service_instance_guids=%s%s", guids, pageParameter(page)), &credentialBindings); err != nil {
+				return nil, fmt.Errorf("error getting service credential bindings: %w", err)
+			}
+
+			for _, a := range credentialBindings.Included.Apps {
+				appNames[a.GUID] = a.Name
+			}
+			for _, b := range credentialBindings.Bindings {
+				if b.Type == "key" {
+					bindings := result[b.ServiceInstanceGUID]
+					bindings.ServiceKeys++
+					result[b.ServiceInstanceGUID] = bindings
+					continue
+				}
+				appGUIDs[b.ServiceInstanceGUID] = append(appGUIDs[b.ServiceInstanceGUID], b.AppGUID)
+			}
+
+			if page >= credentialBindings.Pagination.TotalPages {
+				break
+			}
+		}
+
+		for page := 1; ; page++ {
+			var routeBindings struct {
+				Pagination pagination     `json:"pagination"`
+				Bindings   []routeBinding `json:"resources"`
+				Included   struct {
+					Routes []includedRoute `json:"routes"`
+				} `json:"included"`
+			}
+			if err := c.requester.Get(fmt.Sprintf("v3/service_route_bindings?per_page=5000&include=route&service_instance_guids=%s%s", guids, pageParameter(page)), &routeBindings); err != nil {
+				return nil, fmt.Errorf("error getting service route bindings: %w", err)
+			}
+
+			routeApps := make(map[string][]string)
+			for _, r := range routeBindings.Included.Routes {
+				for _, d := range r.Destinations {
+					routeApps[r.GUID] = append(routeApps[r.GUID], d.AppGUID)
+				}
+			}
+			for _, b := range routeBindings.Bindings {
+				bindings := result[b.ServiceInstanceGUID]
+				bindings.RouteBindings++
+				result[b.ServiceInstanceGUID] = bindings
+				appGUIDs[b.ServiceInstanceGUID] = append(appGUIDs[b.ServiceInstanceGUID], routeApps[b.RouteGUID]...)
+			}
+
+			if page >= routeBindings.Pagination.TotalPages {
+				break
+			}
+		}
+	}
+
+	// Apps behind a route are not included with the route bindings, so their names are fetched separately
+	var unnamed []string
+	for _, guids := range appGUIDs {
+		for _, guid := range guids {
+			if _, ok := appNames[guid]; !ok && !slices.Contains(unnamed, guid) {
+				unnamed = append(unnamed, guid)
+			}
+		}
+	}
+	for batch := range slices.Chunk(unnamed, bindingsBatchSize) {
+		for page := 1; ; page++ {
+			var apps struct {
+				Pagination pagination `json:"pagination"`
+				Apps       []app      `json:"resources"`
+			}
+			if err := c.requester.Get(fmt.Sprintf("v3/apps?per_page=5000&guids=%s%s", strings.Join(batch, ","), pageParameter(page)), &apps); err != nil {
+				return nil, fmt.Errorf("error getting apps: %w", err)
+			}
+			for _, a := range apps.Apps {
+				appNames[a.GUID] = a.Name
+			}
+
+			if page >= apps.Pagination.TotalPages {
+				break
+			}
+		}
+	}
+
+	for instanceGUID, guids := range appGUIDs {
+		slices.Sort(guids)
+		bindings := result[instanceGUID]
+		for _, guid := range slices.Compact(guids) {
+			bindings.Apps = append(bindings.Apps, BoundApp{GUID: guid, Name: appNames[guid]})
+		}
+		slices.SortFunc(bindings.Apps, func(a, b BoundApp) int { return strings.Compare(a.Name, b.Name) })
+		result[instanceGUID] = bindings
+	}
+
+	return result, nil
+}
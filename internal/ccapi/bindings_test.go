@@ -0,0 +1,228 @@
+package ccapi_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/requester"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("GetServiceInstanceBindings", func() {
+	var (
+		fakeServer  *ghttp.Server
+		req         requester.Requester
+		ccapiClient ccapi.CCAPI
+	)
+
+	BeforeEach(func() {
+		fakeServer = ghttp.NewServer()
+		DeferCleanup(fakeServer.Close)
+		req = requester.NewRequester(fakeServer.URL(), "fake-token", false)
+		ccapiClient = ccapi.NewCCAPI(req, time.Millisecond)
+	})
+
+	When("the service instances have bindings", func() {
+		BeforeEach(func() {
+			const credentialBindings = `
+{
+    "resources": [
+        {
+            "type": "app",
+            "relationships": {
+                "app": {"data": {"guid": "app-guid-2"}},
+                "service_instance": {"data": {"guid": "instance-guid-1"}}
+            }
+        },
+        {
+            "type": "app",
+            "relationships": {
+                "app": {"data": {"guid": "app-guid-1"}},
+                "service_instance": {"data": {"guid": "instance-guid-1"}}
+            }
+        },
+        {
+            "type": "key",
+            "relationships": {
+                "service_instance": {"data": {"guid": "instance-guid-2"}}
+            }
+        }
+    ],
+    "included": {
+        "apps": [
+            {"guid": "app-guid-1", "name": "app-b"},
+            {"guid": "app-guid-2", "name": "app-a"}
+        ]
+    }
+}`
+			const routeBindings = `
+{
+    "resources": [
+        {
+            "relationships": {
+                "route": {"data": {"guid": "route-guid-1"}},
+                "service_instance": {"data": {"guid": "instance-guid-2"}}
+            }
+        }
+    ],
+    "included": {
+        "routes": [
+            {
+                "guid": "route-guid-1",
+                "destinations": [
+                    {"app": {"guid": "app-guid-1"}},
+                    {"app": {"guid": "app-guid-3"}}
+                ]
+            }
+        ]
+    }
+}`
+			const apps = `{"resources": [{"guid": "app-guid-3", "name": "app-c"}]}`
+
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "fake-token"),
+					ghttp.VerifyRequest("GET", "/v3/service_credential_bindings", "per_page=5000&include=app&service_instance_guids=instance-guid-1,instance-guid-2"),
+					ghttp.RespondWith(http.StatusOK, credentialBindings),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/service_route_bindings", "per_page=5000&include=route&service_instance_guids=instance-guid-1,instance-guid-2"),
+					ghttp.RespondWith(http.StatusOK, routeBindings),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps", "per_page=5000&guids=app-guid-3"),
+					ghttp.RespondWith(http.StatusOK, apps),
+				),
+			)
+		})
+
+		It("returns the bound apps of each service instance", func() {
+			bindings, err := ccapiClient.GetServiceInstanceBindings([]string{"instance-guid-1", "instance-guid-2"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
+			Expect(bindings).To(Equal(map[string]ccapi.Bindings{
+				"instance-guid-1": {
+					Apps: []ccapi.BoundApp{{GUID: "app-guid-2", Name: "app-a"}, {GUID: "app-guid-1", Name: "app-b"}},
+				},
+				"instance-guid-2": {
+					Apps:          []ccapi.BoundApp{{GUID: "app-guid-1", Name: "app-b"}, {GUID: "app-guid-3", Name: "app-c"}},
+					ServiceKeys:   1,
+					RouteBindings: 1,
+				},
+			}))
+		})
+	})
+
+	When("the bindings are on more than one page", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/service_credential_bindings", "per_page=5000&include=app&service_instance_guids=instance-guid-1"),
+					ghttp.RespondWith(http.StatusOK, `{
+    "pagination": {"total_pages": 2},
+    "resources": [{"type": "app", "relationships": {"app": {"data": {"guid": "app-guid-1"}}, "service_instance": {"data": {"guid": "instance-guid-1"}}}}],
+    "included": {"apps": [{"guid": "app-guid-1", "name": "app-a"}]}
+}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/service_credential_bindings", "per_page=5000&include=app&service_instance_guids=instance-guid-1&page=2"),
+					ghttp.RespondWith(http.StatusOK, `{
+    "pagination": {"total_pages": 2},
+    "resources": [{"type": "app", "relationships": {"app": {"data": {"guid": "app-guid-2"}}, "service_instance": {"data": {"guid": "instance-guid-1"}}}}],
+    "included": {"apps": [{"guid": "app-guid-2", "name": "app-b"}]}
+}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/service_route_bindings", "per_page=5000&include=route&service_instance_guids=instance-guid-1"),
+					ghttp.RespondWith(http.StatusOK, `{
+    "pagination": {"total_pages": 2},
+    "resources": [{"relationships": {"route": {"data": {"guid": "route-guid-1"}}, "service_instance": {"data": {"guid": "instance-guid-1"}}}}],
+    "included": {"routes": [{"guid": "route-guid-1", "destinations": [{"app": {"guid": "app-guid-3"}}]}]}
+}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/service_route_bindings", "per_page=5000&include=route&service_instance_guids=instance-guid-1&page=2"),
+					ghttp.RespondWith(http.StatusOK, `{
+    "pagination": {"total_pages": 2},
+    "resources": [{"relationships": {"route": {"data": {"guid": "route-guid-2"}}, "service_instance": {"data": {"guid": "instance-guid-1"}}}}],
+    "included": {"routes": [{"guid": "route-guid-2", "destinations": [{"app": {"guid": "app-guid-4"}}]}]}
+}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps", "per_page=5000&guids=app-guid-3,app-guid-4"),
+					ghttp.RespondWith(http.StatusOK, `{"pagination": {"total_pages": 2}, "resources": [{"guid": "app-guid-3", "name": "app-c"}]}`),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/apps", "per_page=5000&guids=app-guid-3,app-guid-4&page=2"),
+					ghttp.RespondWith(http.StatusOK, `{"pagination": {"total_pages": 2}, "resources": [{"guid": "app-guid-4", "name": "app-d"}]}`),
+				),
+			)
+		})
+
+		It("reads every page", func() {
+			bindings, err := ccapiClient.GetServiceInstanceBindings([]string{"instance-guid-1"})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(6))
+			Expect(bindings).To(Equal(map[string]ccapi.Bindings{
+				"instance-guid-1": {
+					Apps: []ccapi.BoundApp{
+						{GUID: "app-guid-1", Name: "app-a"},
+						{GUID: "app-guid-2", Name: "app-b"},
+						{GUID: "app-guid-3", Name: "app-c"},
+						{GUID: "app-guid-4", Name: "app-d"},
+					},
+					RouteBindings: 2,
+				},
+			}))
+		})
+	})
+
+	When("there are many service instances", func() {
+		BeforeEach(func() {
+			fakeServer.RouteToHandler("GET", "/v3/service_credential_bindings", ghttp.RespondWith(http.StatusOK, `{"resources": []}`))
+			fakeServer.RouteToHandler("GET", "/v3/service_route_bindings", ghttp.RespondWith(http.StatusOK, `{"resources": []}`))
+		})
+
+		It("requests them in batches", func() {
+			var guids []string
+			for i := range 120 {
+				guids = append(guids, fmt.Sprintf("instance-guid-%d", i))
+			}
+
+			bindings, err := ccapiClient.GetServiceInstanceBindings(guids)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bindings).To(BeEmpty())
+
+			requests := fakeServer.ReceivedRequests()
+			Expect(requests).To(HaveLen(6))
+			Expect(strings.Count(requests[0].URL.Query().Get("service_instance_guids"), ",")).To(Equal(49))
+			Expect(strings.Count(requests[4].URL.Query().Get("service_instance_guids"), ",")).To(Equal(19))
+		})
+	})
+
+	When("the request fails", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "fake-token"),
+					ghttp.RespondWith(http.StatusInternalServerError, nil),
+				),
+			)
+		})
+
+		It("returns an error", func() {
+			_, err := ccapiClient.GetServiceInstanceBindings([]string{"instance-guid-1"})
+
+			Expect(err).To(MatchError("error getting service credential bindings: http response: 500"))
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package ccapi
+
+import "fmt"
+
+// provenanceAnnotationPrefix namespaces the annotations that this plugin writes to service instances. Cloud Controller
+// reserves the cloudfoundry.org domain for its own metadata, so the prefix must not be in that domain.
+const provenanceAnnotationPrefix = "upgrade-all-services-cli-plugin/"
+
+const (
+	upgradedByAnnotation      = provenanceAnnotationPrefix + "upgraded-by"
+	upgradedAtAnnotation      = provenanceAnnotationPrefix + "upgraded-at"
+	previousVersionAnnotation = provenanceAnnotationPrefix + "previous-version"
+	runIDAnnotation           = provenanceAnnotationPrefix + "run-id"
+)
+
+// Provenance records who upgraded a service instance, when, from which version, and in which run of the plugin
+type Provenance struct {
+	UpgradedBy      string
+	UpgradedAt      string
+	PreviousVersion string
+	RunID           string
+}
+
+// Provenance reads back the provenance annotations from a service instance. The boolean is false when the
+// service instance has never been annotated by this plugin.
+func (i ServiceInstance) Provenance() (Provenance, bool) {
+	p := Provenance{
+		UpgradedBy:      i.Annotations[upgradedByAnnotation],
+		UpgradedAt:      i.Annotations[upgradedAtAnnotation],
+		PreviousVersion: i.Annotations[previousVersionAnnotation],
+		RunID:           i.Annotations[runIDAnnotation],
+	}
+	return p, p != Provenance{}
+}
+
+// AnnotateServiceInstance writes the provenance annotations to a service instance. Updating only
+// the metadata does not involve the service broker, so there is no operation to wait for.
+func (c CCAPI) AnnotateServiceInstance(guid string, p Provenance) error {
+	body := struct {
+		Annotations map[string]string `jsonry:"metadata.annotations"`
+	}{
+		Annotations: map[string]string{
+			upgradedByAnnotation:      p.UpgradedBy,
+			upgradedAtAnnotation:      p.UpgradedAt,
+			previousVersionAnnotation: p.PreviousVersion,
+			runIDAnnotation:           p.RunID,
+		},
+	}
+
+	if err := c.requester.Patch(fmt.Sprintf("v3/service_instances/%s", guid), body); err != nil {
+		return fmt.Errorf("annotation request error: %s", err)
+	}
+
+	return nil
+}
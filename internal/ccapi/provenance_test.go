@@ -0,0 +1,116 @@
+package ccapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/requester"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Provenance", func() {
+	Describe("AnnotateServiceInstance", func() {
+		var (
+			fakeServer  *ghttp.Server
+			ccapiClient ccapi.CCAPI
+		)
+
+		BeforeEach(func() {
+			fakeServer = ghttp.NewServer()
+			DeferCleanup(fakeServer.Close)
+			ccapiClient = ccapi.NewCCAPI(requester.NewRequester(fakeServer.URL(), "fake-token", false), time.Millisecond)
+		})
+
+		It("patches the annotations", func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "fake-token"),
+					ghttp.VerifyRequest("PATCH", "/v3/service_instances/test-guid"),
+					ghttp.VerifyJSON(`{
+						"metadata": {
+							"annotations": {
+								"upgrade-all-services-cli-plugin/upgraded-by": "admin",
+								"upgrade-all-services-cli-plugin/upgraded-at": "2024-05-01T10:11:12Z",
+								"upgrade-all-services-cli-plugin/previous-version": "1.2.3",
+								"upgrade-all-services-cli-plugin/run-id": "fake-run-id"
+							}
+						}
+					}`),
+					ghttp.RespondWith(http.StatusOK, `{}`, nil),
+				),
+			)
+
+			err := ccapiClient.AnnotateServiceInstance("test-guid", ccapi.Provenance{
+				UpgradedBy:      "admin",
+				UpgradedAt:      "2024-05-01T10:11:12Z",
+				PreviousVersion: "1.2.3",
+				RunID:           "fake-run-id",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("uses annotation keys that Cloud Controller accepts", func() {
+			var body struct {
+				Metadata struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"metadata"`
+			}
+			fakeServer.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				defer GinkgoRecover()
+				Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+			})
+
+			Expect(ccapiClient.AnnotateServiceInstance("test-guid", ccapi.Provenance{UpgradedBy: "admin"})).To(Succeed())
+			Expect(body.Metadata.Annotations).To(HaveLen(4))
+			for key := range body.Metadata.Annotations {
+				prefix, name, found := strings.Cut(key, "/")
+				Expect(found).To(BeTrue(), key)
+				Expect(prefix).To(MatchRegexp(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`), "prefix must be a DNS subdomain")
+				Expect(len(prefix)).To(BeNumerically("<=", 253))
+				Expect(prefix).NotTo(Equal("cloudfoundry.org"), "the cloudfoundry.org domain is reserved")
+				Expect(prefix).NotTo(HaveSuffix(".cloudfoundry.org"), "the cloudfoundry.org domain is reserved")
+				Expect(name).To(MatchRegexp(`^[A-Za-z0-9]([-_.A-Za-z0-9]*[A-Za-z0-9])?$`))
+				Expect(len(name)).To(BeNumerically("<=", 63))
+			}
+		})
+
+		It("returns an error when the request fails", func() {
+			fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, ``, nil))
+
+			err := ccapiClient.AnnotateServiceInstance("test-guid", ccapi.Provenance{})
+			Expect(err).To(MatchError("annotation request error: http_error: 404 Not Found response_body: "))
+		})
+	})
+
+	Describe("reading back", func() {
+		It("reads the annotations", func() {
+			p, ok := ccapi.ServiceInstance{Annotations: map[string]string{
+				"upgrade-all-services-cli-plugin/upgraded-by":      "admin",
+				"upgrade-all-services-cli-plugin/upgraded-at":      "2024-05-01T10:11:12Z",
+				"upgrade-all-services-cli-plugin/previous-version": "1.2.3",
+				"upgrade-all-services-cli-plugin/run-id":           "fake-run-id",
+				"some-other-annotation":                            "ignored",
+			}}.Provenance()
+
+			Expect(ok).To(BeTrue())
+			Expect(p).To(Equal(ccapi.Provenance{
+				UpgradedBy:      "admin",
+				UpgradedAt:      "2024-05-01T10:11:12Z",
+				PreviousVersion: "1.2.3",
+				RunID:           "fake-run-id",
+			}))
+		})
+
+		It("reports when there are no annotations", func() {
+			_, ok := ccapi.ServiceInstance{Annotations: map[string]string{"some-other-annotation": "ignored"}}.Provenance()
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
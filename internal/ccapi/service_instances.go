@@ -3,19 +3,22 @@ package ccapi
 import (
 	"fmt"
 	"strings"
+	"time"
 )
 
 type ServiceInstance struct {
 	// These elements are retrieved directly from the service instance object
-	GUID                     string `json:"guid"`
-	Name                     string `json:"name"`
-	UpgradeAvailable         bool   `json:"upgrade_available"`
-	ServicePlanGUID          string `jsonry:"relationships.service_plan.data.guid"`
-	SpaceGUID                string `jsonry:"relationships.space.data.guid"`
-	LastOperationType        string `jsonry:"last_operation.type"`
-	LastOperationState       string `jsonry:"last_operation.state"`
-	LastOperationDescription string `jsonry:"last_operation.description"`
-	MaintenanceInfoVersion   string `jsonry:"maintenance_info.version"`
+	GUID                     string            `json:"guid"`
+	Name                     string            `json:"name"`
+	UpgradeAvailable         bool              `json:"upgrade_available"`
+	ServicePlanGUID          string            `jsonry:"relationships.service_plan.data.guid"`
+	SpaceGUID                string            `jsonry:"relationships.space.data.guid"`
+	LastOperationType        string            `jsonry:"last_operation.type"`
+	LastOperationState       string            `jsonry:"last_operation.state"`
+	LastOperationDescription string            `jsonry:"last_operation.description"`
+	LastOperationUpdatedAt   time.Time         `jsonry:"last_operation.updated_at"`
+	MaintenanceInfoVersion   string            `jsonry:"maintenance_info.version"`
+	Annotations              map[string]string `jsonry:"metadata.annotations"`
 
 	// These elements are retrieved from other resources returned by the API
 	ServicePlanName     string `json:"-"`
@@ -25,8 +28,9 @@ type ServiceInstance struct {
 	OrganizationGUID    string `json:"-"`
 	OrganizationName    string `json:"-"`
 
-	ServicePlanMaintenanceInfoVersion string `json:"-"`
-	ServicePlanDeactivated            bool   `json:"-"`
+	ServicePlanMaintenanceInfoVersion string    `json:"-"`
+	ServicePlanDeactivated            bool      `json:"-"`
+	ServicePlanUpdatedAt              time.Time `json:"-"`
 }
 
 type includedSpace struct {
@@ -68,6 +72,7 @@ func (c CCAPI) GetServiceInstancesForServicePlans(plans []ServicePlan) ([]Servic
 		receiver.Instances[i].ServiceOfferingName = plan.ServiceOfferingName
 		receiver.Instances[i].ServicePlanMaintenanceInfoVersion = plan.MaintenanceInfoVersion
 		receiver.Instances[i].ServicePlanDeactivated = !plan.Available
+		receiver.Instances[i].ServicePlanUpdatedAt = plan.UpdatedAt
 
 		spaceName, orgGUID, orgName := spaceGUIDLookup(receiver.Instances[i].SpaceGUID)
 		receiver.Instances[i].SpaceName = spaceName
@@ -78,10 +83,24 @@ func (c CCAPI) GetServiceInstancesForServicePlans(plans []ServicePlan) ([]Servic
 	return receiver.Instances, nil
 }
 
+// GetServiceInstance gets the current state of a service instance. Only the elements retrieved directly
+// from the service instance object are set.
+func (c CCAPI) GetServiceInstance(guid string) (ServiceInstance, error) {
+	var instance ServiceInstance
+	if err := c.requester.Get(fmt.Sprintf("v3/service_instances/%s", guid), &instance); err != nil {
+		return ServiceInstance{}, fmt.Errorf("error getting service instance: %w", err)
+	}
+	return instance, nil
+}
+
 func HasInstanceCreateFailedStatus(i ServiceInstance) bool {
 	return i.LastOperationType == "create" && i.LastOperationState == "failed"
 }
 
+func HasFailedLastOperation(i ServiceInstance) bool {
+	return i.LastOperationState == "failed"
+}
+
 func computePlanGUIDLookup(plans []ServicePlan) func(guid string) ServicePlan {
 	plansLookup := make(map[string]ServicePlan, len(plans))
 	for _, plan := range plans {
@@ -50,6 +50,7 @@ var _ = Describe("GetServiceInstances", func() {
 				MaintenanceInfoVersion: "1.5.1",
 				ServiceOfferingGUID:    "707cff6a-fc54-471a-9594-442c306fb1d0",
 				ServiceOfferingName:    "fake-service-offering-name-1",
+				UpdatedAt:              time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
 			}
 
 			servicePlanTwo := ccapi.ServicePlan{
@@ -82,6 +83,7 @@ var _ = Describe("GetServiceInstances", func() {
 					LastOperationType:                 "create",
 					LastOperationState:                "succeeded",
 					LastOperationDescription:          "Instance provisioning completed",
+					LastOperationUpdatedAt:            time.Date(2023, time.November, 16, 23, 27, 41, 0, time.UTC),
 					MaintenanceInfoVersion:            "2.10.14-build.3",
 					ServicePlanGUID:                   "72abfc2f-5473-4fda-b895-a59d47b8f001",
 					ServicePlanName:                   "db-small",
@@ -92,6 +94,8 @@ var _ = Describe("GetServiceInstances", func() {
 					OrganizationGUID:                  "69086541-1b9d-449d-b8a4-79029b25e74f",
 					OrganizationName:                  "pivotal",
 					ServicePlanMaintenanceInfoVersion: "1.5.1",
+					ServicePlanUpdatedAt:              time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+					Annotations:                       map[string]string{},
 				},
 				ccapi.ServiceInstance{
 					GUID:                              "3358305d-7402-48b3-80a7-e0148a38675b",
@@ -100,6 +104,7 @@ var _ = Describe("GetServiceInstances", func() {
 					LastOperationType:                 "create",
 					LastOperationState:                "succeeded",
 					LastOperationDescription:          "",
+					LastOperationUpdatedAt:            time.Date(2023, time.November, 17, 11, 20, 24, 0, time.UTC),
 					MaintenanceInfoVersion:            "",
 					ServicePlanGUID:                   "e55b84e8-b953-4a14-98b2-67bec998a632",
 					ServicePlanName:                   "postgres-db-f1-micro",
@@ -110,6 +115,7 @@ var _ = Describe("GetServiceInstances", func() {
 					OrganizationGUID:                  "69086541-1b9d-449d-b8a4-79029b25e74f",
 					OrganizationName:                  "pivotal",
 					ServicePlanMaintenanceInfoVersion: "",
+					Annotations:                       map[string]string{},
 				},
 				ccapi.ServiceInstance{
 					GUID:                              "5b528bf8-ac0f-4fed-85d0-0fb5f8588968",
@@ -118,6 +124,7 @@ var _ = Describe("GetServiceInstances", func() {
 					LastOperationType:                 "update",
 					LastOperationState:                "succeeded",
 					LastOperationDescription:          "update succeeded",
+					LastOperationUpdatedAt:            time.Date(2023, time.November, 17, 13, 56, 14, 0, time.UTC),
 					MaintenanceInfoVersion:            "1.3.9",
 					ServicePlanGUID:                   "510da794-1e71-4192-bd39-d974de20b7a4",
 					ServicePlanName:                   "small",
@@ -128,6 +135,7 @@ var _ = Describe("GetServiceInstances", func() {
 					OrganizationGUID:                  "529d3532-87a9-11ee-8a24-d354d25d7923",
 					OrganizationName:                  "vmware",
 					ServicePlanMaintenanceInfoVersion: "",
+					Annotations:                       map[string]string{},
 				},
 			))
 
@@ -160,6 +168,57 @@ var _ = Describe("GetServiceInstances", func() {
 	})
 })
 
+var _ = Describe("GetServiceInstance", func() {
+	var (
+		fakeServer  *ghttp.Server
+		req         requester.Requester
+		ccapiClient ccapi.CCAPI
+	)
+
+	BeforeEach(func() {
+		fakeServer = ghttp.NewServer()
+		DeferCleanup(fakeServer.Close)
+		req = requester.NewRequester(fakeServer.URL(), "fake-token", false)
+		ccapiClient = ccapi.NewCCAPI(req, time.Millisecond)
+	})
+
+	It("returns the service instance", func() {
+		fakeServer.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "fake-token"),
+				ghttp.VerifyRequest("GET", "/v3/service_instances/test-guid"),
+				ghttp.RespondWith(http.StatusOK, `{
+					"guid": "test-guid",
+					"name": "test-name",
+					"upgrade_available": true,
+					"maintenance_info": {"version": "1.2.3"},
+					"last_operation": {"type": "update", "state": "succeeded"}
+				}`),
+			),
+		)
+
+		instance, err := ccapiClient.GetServiceInstance("test-guid")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(instance).To(Equal(ccapi.ServiceInstance{
+			GUID:                   "test-guid",
+			Name:                   "test-name",
+			UpgradeAvailable:       true,
+			MaintenanceInfoVersion: "1.2.3",
+			LastOperationType:      "update",
+			LastOperationState:     "succeeded",
+		}))
+	})
+
+	It("returns an error when the request fails", func() {
+		fakeServer.AppendHandlers(ghttp.RespondWith(http.StatusInternalServerError, nil))
+
+		_, err := ccapiClient.GetServiceInstance("test-guid")
+
+		Expect(err).To(MatchError("error getting service instance: http response: 500"))
+	})
+})
+
 func fakeResponse() string {
 	return `
 {
@@ -2,6 +2,7 @@ package ccapi
 
 import (
 	"fmt"
+	"time"
 )
 
 type ServicePlan struct {
@@ -11,16 +12,18 @@ type ServicePlan struct {
 	MaintenanceInfoVersion string
 	ServiceOfferingGUID    string
 	ServiceOfferingName    string
+	UpdatedAt              time.Time
 }
 
 func (c CCAPI) GetServicePlans(brokerName string) ([]ServicePlan, error) {
 
 	type plan struct {
-		GUID                        string `json:"guid"`
-		Available                   bool   `json:"available"`
-		Name                        string `json:"name"`
-		MaintenanceInfoVersion      string `jsonry:"maintenance_info.version"`
-		IncludedServiceOfferingGUID string `jsonry:"relationships.service_offering.data.guid"`
+		GUID                        string    `json:"guid"`
+		Available                   bool      `json:"available"`
+		Name                        string    `json:"name"`
+		MaintenanceInfoVersion      string    `jsonry:"maintenance_info.version"`
+		IncludedServiceOfferingGUID string    `jsonry:"relationships.service_offering.data.guid"`
+		UpdatedAt                   time.Time `json:"updated_at"`
 	}
 
 	type serviceOffering struct {
@@ -55,6 +58,7 @@ func (c CCAPI) GetServicePlans(brokerName string) ([]ServicePlan, error) {
 			Available:              p.Available,
 			Name:                   p.Name,
 			MaintenanceInfoVersion: p.MaintenanceInfoVersion,
+			UpdatedAt:              p.UpdatedAt,
 		}
 
 		offering := serviceOfferingLookup[p.IncludedServiceOfferingGUID]
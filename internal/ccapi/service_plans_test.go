@@ -40,6 +40,7 @@ var _ = Describe("GetServicePlans", func() {
             },
             "name": "test-name-1",
             "available": true,
+            "updated_at": "2024-03-01T12:00:00Z",
             "relationships": {
                 "service_offering": {
                     "data": {
@@ -125,6 +126,7 @@ var _ = Describe("GetServicePlans", func() {
 					MaintenanceInfoVersion: "test-mi-version",
 					ServiceOfferingGUID:    "test-offering-guid-1",
 					ServiceOfferingName:    "test-offering-name-1",
+					UpdatedAt:              time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
 				},
 				ccapi.ServicePlan{
 					GUID:                   "test-guid-2",
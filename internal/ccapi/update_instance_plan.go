@@ -0,0 +1,19 @@
+package ccapi
+
+import "fmt"
+
+// UpdateServiceInstancePlan moves a service instance onto a different service plan and waits for the operation to complete
+func (c CCAPI) UpdateServiceInstancePlan(guid, planGUID string) error {
+	body := struct {
+		ServicePlanGUID string `jsonry:"relationships.service_plan.data.guid"`
+	}{
+		ServicePlanGUID: planGUID,
+	}
+
+	err := c.requester.Patch(fmt.Sprintf("v3/service_instances/%s", guid), body)
+	if err != nil {
+		return fmt.Errorf("plan update request error: %s", err)
+	}
+
+	return c.pollServiceInstanceUpdate(guid)
+}
@@ -0,0 +1,148 @@
+package ccapi_test
+
+import (
+	"net/http"
+	"time"
+
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/requester"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("UpdateServiceInstancePlan", func() {
+	const instanceUpdatingResponse = `
+{
+  "guid": "test-guid",
+  "last_operation": {
+    "type": "update",
+    "state": "in progress",
+    "description": "Update in progress"
+  },
+  "relationships": {
+    "service_plan": {
+      "data": {
+        "guid": "test-plan-guid"
+      }
+    }
+  }
+}
+`
+	const instanceSuccessResponse = `
+{
+  "guid": "test-guid",
+  "last_operation": {
+    "type": "update",
+    "state": "succeeded",
+    "description": "Instance update completed"
+  },
+  "relationships": {
+    "service_plan": {
+      "data": {
+        "guid": "test-plan-guid"
+      }
+    }
+  }
+}
+`
+	const instanceFailedResponse = `
+{
+  "guid": "test-guid",
+  "last_operation": {
+    "type": "update",
+    "state": "failed",
+    "description": "Plan change not supported"
+  },
+  "relationships": {
+    "service_plan": {
+      "data": {
+        "guid": "old-plan-guid"
+      }
+    }
+  }
+}
+`
+
+	var (
+		fakeServer  *ghttp.Server
+		req         requester.Requester
+		ccapiClient ccapi.CCAPI
+	)
+
+	BeforeEach(func() {
+		fakeServer = ghttp.NewServer()
+		DeferCleanup(fakeServer.Close)
+		req = requester.NewRequester(fakeServer.URL(), "fake-token", false)
+		ccapiClient = ccapi.NewCCAPI(req, time.Millisecond)
+	})
+
+	When("the plan update succeeds", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "fake-token"),
+					ghttp.VerifyRequest("PATCH", "/v3/service_instances/test-guid"),
+					ghttp.VerifyBody([]byte(`{"relationships":{"service_plan":{"data":{"guid":"test-plan-guid"}}}}`)),
+					ghttp.RespondWith(http.StatusAccepted, ``, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "fake-token"),
+					ghttp.VerifyRequest("GET", "/v3/service_instances/test-guid"),
+					ghttp.RespondWith(http.StatusOK, instanceUpdatingResponse, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("Authorization", "fake-token"),
+					ghttp.VerifyRequest("GET", "/v3/service_instances/test-guid"),
+					ghttp.RespondWith(http.StatusOK, instanceSuccessResponse, nil),
+				),
+			)
+		})
+
+		It("patches the plan and polls until complete", func() {
+			err := ccapiClient.UpdateServiceInstancePlan("test-guid", "test-plan-guid")
+			Expect(err).NotTo(HaveOccurred())
+
+			requests := fakeServer.ReceivedRequests()
+			Expect(requests).To(HaveLen(3))
+		})
+	})
+
+	When("the plan update request fails", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PATCH", "/v3/service_instances/test-guid"),
+					ghttp.RespondWith(http.StatusUnprocessableEntity, `{"errors":[{"code":60032,"title":"CF-MaintenanceInfoConflict","detail":"boom"}]}`, nil),
+				),
+			)
+		})
+
+		It("returns the error", func() {
+			err := ccapiClient.UpdateServiceInstancePlan("test-guid", "test-plan-guid")
+			Expect(err).To(MatchError("plan update request error: http_error: 422 Unprocessable Entity capi_error_code: 60032 capi_error_title: CF-MaintenanceInfoConflict capi_error_detail: boom"))
+			Expect(fakeServer.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+
+	When("the plan update operation fails", func() {
+		BeforeEach(func() {
+			fakeServer.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("PATCH", "/v3/service_instances/test-guid"),
+					ghttp.RespondWith(http.StatusAccepted, ``, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/service_instances/test-guid"),
+					ghttp.RespondWith(http.StatusOK, instanceFailedResponse, nil),
+				),
+			)
+		})
+
+		It("returns the error from the last operation", func() {
+			err := ccapiClient.UpdateServiceInstancePlan("test-guid", "test-plan-guid")
+			Expect(err).To(MatchError("Plan change not supported"))
+		})
+	})
+})
@@ -15,14 +15,31 @@ const (
 	CheckUpToDateAction
 	CheckDeactivatedPlansAction
 	MinVersionCheckAction
+	MigratePlansAction
+	InventoryAction
+	VersionConstraintAction
+	CombinedChecksAction
+	PendingAgeAction
+	CheckFailedOperationsAction
+	CheckVersionAnomaliesAction
 )
 
-func determineAction(checkDeactivatedPlans, checkUpToDate, dryRun bool, minVersionRequired string) (Action, error) {
+// determineAction works out the action from the flags. The dry run and the checks can be combined, in which case
+// the action is CombinedChecksAction, and the checks are returned in a fixed order. Any other combination is invalid.
+func determineAction(checkDeactivatedPlans, checkUpToDate, checkFailedOperations, checkVersionAnomalies, dryRun, inventory bool, minVersionRequired, minVersionPolicy, versionConstraint, maxPendingAge, migratePlans, migratePlansFile string) (Action, []Action, error) {
 	spec := map[string]bool{
 		checkDeactivatedPlansFlag: checkDeactivatedPlans,
 		checkUpToDateFlag:         checkUpToDate,
+		checkFailedOperationsFlag: checkFailedOperations,
+		checkVersionAnomaliesFlag: checkVersionAnomalies,
 		dryRunFlag:                dryRun,
+		inventoryFlag:             inventory,
 		minVersionRequiredFlag:    minVersionRequired != "",
+		minVersionPolicyFlag:      minVersionPolicy != "",
+		versionConstraintFlag:     versionConstraint != "",
+		maxPendingAgeFlag:         maxPendingAge != "",
+		migratePlansFlag:          migratePlans != "",
+		migratePlansFileFlag:      migratePlansFile != "",
 	}
 
 	var flagsSpecified []string
@@ -31,22 +48,56 @@ func determineAction(checkDeactivatedPlans, checkUpToDate, dryRun bool, minVersi
 			flagsSpecified = append(flagsSpecified, fmt.Sprintf("--%s", k))
 		}
 	}
+	sort.Strings(flagsSpecified) // Because maps order is random, ensures identical error message each time
 
-	if len(flagsSpecified) > 1 {
-		sort.Strings(flagsSpecified) // Because maps order is random, ensures identical error message each time
-		return InvalidAction, fmt.Errorf("invalid flag combination: %s", strings.Join(flagsSpecified, ", "))
+	var checks []Action
+	for _, c := range []struct {
+		specified bool
+		action    Action
+	}{
+		{specified: dryRun, action: DryRunAction},
+		{specified: checkUpToDate, action: CheckUpToDateAction},
+		{specified: checkDeactivatedPlans, action: CheckDeactivatedPlansAction},
+		{specified: checkFailedOperations, action: CheckFailedOperationsAction},
+		{specified: checkVersionAnomalies, action: CheckVersionAnomaliesAction},
+		{specified: minVersionRequired != "" || minVersionPolicy != "", action: MinVersionCheckAction},
+		{specified: versionConstraint != "", action: VersionConstraintAction},
+		{specified: maxPendingAge != "", action: PendingAgeAction},
+	} {
+		if c.specified {
+			checks = append(checks, c.action)
+		}
 	}
 
 	switch {
-	case checkDeactivatedPlans:
-		return CheckDeactivatedPlansAction, nil
-	case checkUpToDate:
-		return CheckUpToDateAction, nil
-	case dryRun:
-		return DryRunAction, nil
-	case minVersionRequired != "":
-		return MinVersionCheckAction, nil
+	case minVersionRequired != "" && minVersionPolicy != "":
+		return InvalidAction, nil, fmt.Errorf("invalid flag combination: --%s, --%s", minVersionPolicyFlag, minVersionRequiredFlag)
+	case len(flagsSpecified) > 1 && len(checks) != len(flagsSpecified):
+		return InvalidAction, nil, fmt.Errorf("invalid flag combination: %s", strings.Join(flagsSpecified, ", "))
+	case len(checks) > 1:
+		return CombinedChecksAction, checks, nil
+	case len(checks) == 1:
+		return checks[0], nil, nil
+	case migratePlans != "", migratePlansFile != "":
+		return MigratePlansAction, nil, nil
+	case inventory:
+		return InventoryAction, nil, nil
 	default:
-		return UpgradeAction, nil
+		return UpgradeAction, nil, nil
+	}
+}
+
+// includes is true when the action is one of the targets, or when it combines checks that include one of them
+func includes(action Action, checks []Action, targets ...Action) bool {
+	for _, target := range targets {
+		if action == target {
+			return true
+		}
+		for _, check := range checks {
+			if check == target {
+				return true
+			}
+		}
 	}
+	return false
 }
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// checkNames are the names of the checks that can be selected with the --checks flag. Each check is named
+// after the flag that selects it on its own.
+var checkNames = []string{
+	dryRunFlag,
+	checkUpToDateFlag,
+	checkDeactivatedPlansFlag,
+	checkFailedOperationsFlag,
+	checkVersionAnomaliesFlag,
+	minVersionRequiredFlag,
+	minVersionPolicyFlag,
+	versionConstraintFlag,
+	maxPendingAgeFlag,
+}
+
+// selectChecks turns on the switches for the checks named in the --checks option. A check that needs a value,
+// such as the version constraint, is already selected by its own flag, so naming it only confirms that the
+// value has been given.
+func selectChecks(value string, switches map[string]*bool, values map[string]string) error {
+	if value == "" {
+		return nil
+	}
+
+	var selected []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		s, isSwitch := switches[name]
+		v, isValue := values[name]
+		switch {
+		case !isSwitch && !isValue:
+			return fmt.Errorf("unknown check %q for the --%s flag, must be one of: %s", name, checksFlag, strings.Join(checkNames, ", "))
+		case slices.Contains(selected, name):
+			return fmt.Errorf("duplicate check %q for the --%s flag", name, checksFlag)
+		case isSwitch:
+			*s = true
+		case v == "":
+			return fmt.Errorf("the %q check needs a value from the --%s flag", name, name)
+		}
+		selected = append(selected, name)
+	}
+
+	return nil
+}
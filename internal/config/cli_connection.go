@@ -11,4 +11,5 @@ type CLIConnection interface {
 	ApiVersion() (string, error)
 	ApiEndpoint() (string, error)
 	IsSSLDisabled() (bool, error)
+	Username() (string, error)
 }
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+)
+
+// Column is a field of a service instance that can be shown by the table and CSV output formats
+type Column string
+
+const (
+	StatusColumn       Column = "status"
+	NameColumn         Column = "name"
+	GUIDColumn         Column = "guid"
+	VersionColumn      Column = "version"
+	PlanColumn         Column = "plan"
+	PlanGUIDColumn     Column = "plan_guid"
+	PlanVersionColumn  Column = "plan_version"
+	OfferingColumn     Column = "offering"
+	OfferingGUIDColumn Column = "offering_guid"
+	SpaceColumn        Column = "space"
+	SpaceGUIDColumn    Column = "space_guid"
+	OrgColumn          Column = "org"
+	OrgGUIDColumn      Column = "org_guid"
+
+	// These are only set by the deactivated plans check
+	ReplacementCandidatesColumn Column = "replacement_candidates"
+	ReplacementPlanColumn       Column = "replacement_plan"
+)
+
+// AllColumns lists every column in the order they are documented
+var AllColumns = []Column{
+	StatusColumn, NameColumn, GUIDColumn, VersionColumn, PlanColumn, PlanGUIDColumn, PlanVersionColumn,
+	OfferingColumn, OfferingGUIDColumn, SpaceColumn, SpaceGUIDColumn, OrgColumn, OrgGUIDColumn,
+	ReplacementCandidatesColumn, ReplacementPlanColumn,
+}
+
+// ParseColumn returns the column with the name, or an error if there is no such column
+func ParseColumn(name string) (Column, error) {
+	column := Column(strings.TrimSpace(name))
+	if !slices.Contains(AllColumns, column) {
+		return "", fmt.Errorf("invalid column %q, must be one of: %s", column, joinColumns(AllColumns))
+	}
+	return column, nil
+}
+
+// Value returns the value of the column for the service instance. The status and replacement columns depend
+// on why the service instance is being listed, so they are empty here.
+func (c Column) Value(instance ccapi.ServiceInstance) string {
+	switch c {
+	case NameColumn:
+		return instance.Name
+	case GUIDColumn:
+		return instance.GUID
+	case VersionColumn:
+		return instance.MaintenanceInfoVersion
+	case PlanColumn:
+		return instance.ServicePlanName
+	case PlanGUIDColumn:
+		return instance.ServicePlanGUID
+	case PlanVersionColumn:
+		return instance.ServicePlanMaintenanceInfoVersion
+	case OfferingColumn:
+		return instance.ServiceOfferingName
+	case OfferingGUIDColumn:
+		return instance.ServiceOfferingGUID
+	case SpaceColumn:
+		return instance.SpaceName
+	case SpaceGUIDColumn:
+		return instance.SpaceGUID
+	case OrgColumn:
+		return instance.OrganizationName
+	case OrgGUIDColumn:
+		return instance.OrganizationGUID
+	default:
+		return ""
+	}
+}
+
+func parseColumns(value string, output OutputFormat) ([]Column, error) {
+	switch {
+	case !output.IsTabular() && value != "":
+		return nil, fmt.Errorf("the --%s flag can only be used with the --%s %s or --%s %s options", columnsFlag, outputFlag, TableOutput, outputFlag, CSVOutput)
+	case !output.IsTabular():
+		return nil, nil
+	case value == "":
+		value = defaultColumns
+	}
+
+	var columns []Column
+	for _, name := range strings.Split(value, ",") {
+		column, err := ParseColumn(name)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("invalid column %q for the --%s flag, must be one of: %s", strings.TrimSpace(name), columnsFlag, joinColumns(AllColumns))
+		case slices.Contains(columns, column):
+			return nil, fmt.Errorf("duplicate column %q for the --%s flag", column, columnsFlag)
+		default:
+			columns = append(columns, column)
+		}
+	}
+
+	return columns, nil
+}
+
+func joinColumns(columns []Column) string {
+	names := make([]string, 0, len(columns))
+	for _, c := range columns {
+		names = append(names, string(c))
+	}
+	return strings.Join(names, ", ")
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
 
 	"github.com/hashicorp/go-version"
 )
@@ -16,19 +17,46 @@ import (
 // which just looks more complicated than it has to. So we use an `int` for pragmatic reasons.
 type Config struct {
 	Action                  Action
+	Checks                  []Action
 	BrokerName              string
 	APIToken                string
 	APIEndpoint             string
 	SkipSSLValidation       bool
 	HTTPLogging             bool
 	JSONOutput              bool
+	ReportFile              string
+	Output                  OutputFormat
+	Columns                 []Column
+	TemplateFile            string
+	Summary                 bool
+	JUnitReportFile         string
 	MinVersion              *version.Version
+	MinVersionPolicy        []versionchecker.Rule
+	VersionConstraint       version.Constraints
+	FailOnUnknownVersion    bool
+	VersionComparison       versionchecker.Comparison
+	Thresholds              Thresholds
+	MaxPendingAge           time.Duration
+	PendingSinceFile        string
+	PlanMappings            []PlanMapping
+	ReplacementPlans        []PlanMapping
 	ParallelUpgrades        int
+	BlastRadius             bool
 	Limit                   int
 	Attempts                int
 	RetryInterval           time.Duration
 	IgnoreInstanceErrors    bool
 	InstancePollingInterval time.Duration
+	Annotate                bool
+	Username                string
+	RunID                   string
+	PreHook                 string
+	PostHook                string
+	HookTimeout             time.Duration
+	HealthCheckWindow       time.Duration
+	StopOnDegraded          bool
+	NotifyURL               string
+	NotifyTimeout           time.Duration
 }
 
 // ParseConfig combines and validates data from the command line and CLIConnection object
@@ -38,22 +66,69 @@ func ParseConfig(conn CLIConnection, args []string) (Config, error) {
 		dryRun                bool
 		checkUpToDate         bool
 		minVersionRequired    string
+		minVersionPolicy      string
+		versionConstraint     string
+		versionComparison     string
+		maxPendingAge         string
 		checkDeactivatedPlans bool
+		checkFailedOperations bool
+		replacementPlansFile  string
+		checkVersionAnomalies bool
+		inventory             bool
+		checks                string
+		failIfMoreThan        int
+		failIfPercentAbove    float64
+		gracePeriodDays       int
+		migratePlans          string
+		migratePlansFile      string
+		output                string
+		columns               string
 	)
 
 	flagSet := flag.NewFlagSet("upgrade-all-services", flag.ContinueOnError)
 	flagSet.IntVar(&cfg.ParallelUpgrades, parallelFlag, parallelDefault, parallelDescription)
 	flagSet.BoolVar(&cfg.HTTPLogging, httpLoggingFlag, httpLoggingDefault, httpLoggingDescription)
 	flagSet.BoolVar(&cfg.JSONOutput, jsonOutputFlag, jsonOutputDefault, jsonOutputDescription)
+	flagSet.StringVar(&cfg.ReportFile, reportFileFlag, reportFileDefault, reportFileDescription)
+	flagSet.StringVar(&output, outputFlag, outputDefault, outputDescription)
+	flagSet.StringVar(&columns, columnsFlag, columnsDefault, columnsDescription)
+	flagSet.StringVar(&cfg.TemplateFile, templateFlag, templateDefault, templateDescription)
+	flagSet.BoolVar(&cfg.Summary, summaryFlag, summaryDefault, summaryDescription)
+	flagSet.StringVar(&cfg.JUnitReportFile, junitReportFlag, junitReportDefault, junitReportDescription)
 	flagSet.BoolVar(&dryRun, dryRunFlag, dryRunDefault, dryRunDescription)
 	flagSet.BoolVar(&checkUpToDate, checkUpToDateFlag, checkUpToDateDefault, checkUpToDateDescription)
 	flagSet.StringVar(&minVersionRequired, minVersionRequiredFlag, minVersionRequiredDefault, minVersionRequiredDescription)
+	flagSet.StringVar(&minVersionPolicy, minVersionPolicyFlag, minVersionPolicyDefault, minVersionPolicyDescription)
+	flagSet.StringVar(&versionConstraint, versionConstraintFlag, versionConstraintDefault, versionConstraintDescription)
+	flagSet.BoolVar(&cfg.FailOnUnknownVersion, failOnUnknownVersionFlag, failOnUnknownVersionDefault, failOnUnknownVersionDescription)
+	flagSet.StringVar(&versionComparison, versionComparisonFlag, versionComparisonDefault, versionComparisonDescription)
+	flagSet.StringVar(&maxPendingAge, maxPendingAgeFlag, maxPendingAgeDefault, maxPendingAgeDescription)
+	flagSet.StringVar(&cfg.PendingSinceFile, pendingSinceFileFlag, pendingSinceFileDefault, pendingSinceFileDescription)
 	flagSet.BoolVar(&checkDeactivatedPlans, checkDeactivatedPlansFlag, checkDeactivatedPlansDefault, checkDeactivatedPlansDescription)
+	flagSet.StringVar(&replacementPlansFile, replacementPlansFileFlag, replacementPlansFileDefault, replacementPlansFileDescription)
+	flagSet.StringVar(&checks, checksFlag, checksDefault, checksDescription)
+	flagSet.IntVar(&failIfMoreThan, failIfMoreThanFlag, failIfMoreThanDefault, failIfMoreThanDescription)
+	flagSet.Float64Var(&failIfPercentAbove, failIfPercentAboveFlag, failIfPercentAboveDefault, failIfPercentAboveDescription)
+	flagSet.IntVar(&gracePeriodDays, gracePeriodDaysFlag, gracePeriodDaysDefault, gracePeriodDaysDescription)
+	flagSet.BoolVar(&checkFailedOperations, checkFailedOperationsFlag, checkFailedOperationsDefault, checkFailedOperationsDescription)
+	flagSet.BoolVar(&checkVersionAnomalies, checkVersionAnomaliesFlag, checkVersionAnomaliesDefault, checkVersionAnomaliesDescription)
+	flagSet.BoolVar(&inventory, inventoryFlag, inventoryDefault, inventoryDescription)
+	flagSet.StringVar(&migratePlans, migratePlansFlag, migratePlansDefault, migratePlansDescription)
+	flagSet.StringVar(&migratePlansFile, migratePlansFileFlag, migratePlansFileDefault, migratePlansFileDescription)
+	flagSet.BoolVar(&cfg.BlastRadius, blastRadiusFlag, blastRadiusDefault, blastRadiusDescription)
 	flagSet.IntVar(&cfg.Limit, limitFlag, limitDefault, limitDescription)
 	flagSet.IntVar(&cfg.Attempts, attemptsFlag, attemptsDefault, attemptsDescription)
 	flagSet.DurationVar(&cfg.RetryInterval, retryIntervalFlag, retryIntervalDefault, retryIntervalDescription)
 	flagSet.BoolVar(&cfg.IgnoreInstanceErrors, ignoreInstanceErrorsFlag, ignoreInstanceErrorsDefault, ignoreInstanceErrorsDescription)
 	flagSet.DurationVar(&cfg.InstancePollingInterval, instancePollingIntervalFlag, instancePollingIntervalDefault, instancePollingIntervalDescription)
+	flagSet.BoolVar(&cfg.Annotate, annotateFlag, annotateDefault, annotateDescription)
+	flagSet.StringVar(&cfg.PreHook, preHookFlag, preHookDefault, preHookDescription)
+	flagSet.StringVar(&cfg.PostHook, postHookFlag, postHookDefault, postHookDescription)
+	flagSet.DurationVar(&cfg.HookTimeout, hookTimeoutFlag, hookTimeoutDefault, hookTimeoutDescription)
+	flagSet.DurationVar(&cfg.HealthCheckWindow, healthCheckWindowFlag, healthCheckWindowDefault, healthCheckWindowDescription)
+	flagSet.BoolVar(&cfg.StopOnDegraded, stopOnDegradedFlag, stopOnDegradedDefault, stopOnDegradedDescription)
+	flagSet.StringVar(&cfg.NotifyURL, notifyURLFlag, notifyURLDefault, notifyURLDescription)
+	flagSet.DurationVar(&cfg.NotifyTimeout, notifyTimeoutFlag, notifyTimeoutDefault, notifyTimeoutDescription)
 
 	// This ranges over a chain of functions, each of which performs a single action and may return an error.
 	// The chain breaks at the first error received. It arguably reads better than repetitive error handling logic.
@@ -62,8 +137,14 @@ func ParseConfig(conn CLIConnection, args []string) (Config, error) {
 			cfg.BrokerName, err = parseCommandLine(flagSet, args)
 			return
 		},
+		func() error {
+			return selectChecks(checks,
+				map[string]*bool{dryRunFlag: &dryRun, checkUpToDateFlag: &checkUpToDate, checkDeactivatedPlansFlag: &checkDeactivatedPlans, checkFailedOperationsFlag: &checkFailedOperations, checkVersionAnomaliesFlag: &checkVersionAnomalies},
+				map[string]string{minVersionRequiredFlag: minVersionRequired, minVersionPolicyFlag: minVersionPolicy, versionConstraintFlag: versionConstraint, maxPendingAgeFlag: maxPendingAge},
+			)
+		},
 		func() (err error) {
-			cfg.Action, err = determineAction(checkDeactivatedPlans, checkUpToDate, dryRun, minVersionRequired)
+			cfg.Action, cfg.Checks, err = determineAction(checkDeactivatedPlans, checkUpToDate, checkFailedOperations, checkVersionAnomalies, dryRun, inventory, minVersionRequired, minVersionPolicy, versionConstraint, maxPendingAge, migratePlans, migratePlansFile)
 			return
 		},
 		func() error { return validateLoginStatus(conn) },
@@ -71,17 +152,71 @@ func ParseConfig(conn CLIConnection, args []string) (Config, error) {
 		func() error { return read("access token", conn.AccessToken, &cfg.APIToken) },
 		func() error { return read("API endpoint", conn.ApiEndpoint, &cfg.APIEndpoint) },
 		func() error { return read("skip SSL validation", conn.IsSSLDisabled, &cfg.SkipSSLValidation) },
+		func() error { return readUsername(conn, cfg.Annotate, &cfg.Username) },
 		func() error { return validateParallelUpgrades(cfg.ParallelUpgrades) },
 		func() error { return validateBrokerName(cfg.BrokerName) },
 		func() (err error) {
 			cfg.MinVersion, err = validateMinVersionRequired(minVersionRequired)
 			return
 		},
+		func() (err error) {
+			cfg.MinVersionPolicy, err = parseMinVersionPolicy(minVersionPolicy)
+			return
+		},
+		func() (err error) {
+			cfg.VersionConstraint, err = validateVersionConstraint(versionConstraint)
+			return
+		},
+		func() (err error) {
+			cfg.MaxPendingAge, err = parseMaxPendingAge(maxPendingAge)
+			return
+		},
+		func() error { return validatePendingSinceFile(cfg.PendingSinceFile, cfg.Action, cfg.Checks) },
+		func() (err error) {
+			cfg.PlanMappings, err = parsePlanMappings(migratePlans, migratePlansFile)
+			return
+		},
+		func() (err error) {
+			cfg.ReplacementPlans, err = parseReplacementPlans(replacementPlansFile, cfg.Action, cfg.Checks)
+			return
+		},
+		func() error { return validateFailOnUnknownVersion(cfg.FailOnUnknownVersion, cfg.Action, cfg.Checks) },
+		func() (err error) {
+			cfg.VersionComparison, err = parseVersionComparison(versionComparison, cfg.Action, cfg.Checks)
+			return
+		},
+		func() (err error) {
+			cfg.Thresholds, err = parseThresholds(failIfMoreThan, failIfPercentAbove, gracePeriodDays, cfg.Action, cfg.Checks)
+			return
+		},
 		func() error { return validateJSONFlag(cfg.JSONOutput, cfg.Action) },
+		func() error { return validateReportFile(cfg.ReportFile, cfg.JSONOutput, cfg.Action) },
+		func() error { return validateJUnitReport(cfg.JUnitReportFile, cfg.Action) },
+		func() (err error) {
+			cfg.Output, err = parseOutputFormat(output, cfg.JSONOutput, cfg.Action)
+			return
+		},
+		func() (err error) {
+			cfg.Columns, err = parseColumns(columns, cfg.Output)
+			return
+		},
+		func() error { return validateTemplate(cfg.TemplateFile, cfg.JSONOutput, cfg.Output, cfg.Action) },
+		func() error { return validateSummary(cfg.Summary, cfg.TemplateFile, cfg.Output, cfg.Action) },
+		func() error { return validateBlastRadius(cfg.BlastRadius, cfg.TemplateFile, cfg.Output, cfg.Action) },
 		func() error { return validateLimit(cfg.Limit) },
 		func() error { return validateAttempts(cfg.Attempts) },
 		func() error { return validateRetryInterval(cfg.RetryInterval) },
 		func() error { return validateInstancePollingInterval(cfg.InstancePollingInterval) },
+		func() error { return validateAnnotateFlag(cfg.Annotate, cfg.Action) },
+		func() error { return validateHookFlags(cfg.PreHook, cfg.PostHook, cfg.Action) },
+		func() error { return validateHookTimeout(cfg.HookTimeout) },
+		func() error { return validateHealthCheck(cfg.HealthCheckWindow, cfg.StopOnDegraded, cfg.Action) },
+		func() error { return validateNotifyURL(cfg.NotifyURL, cfg.Action) },
+		func() error { return validateNotifyTimeout(cfg.NotifyTimeout) },
+		func() (err error) {
+			cfg.RunID, err = newRunID()
+			return
+		},
 	} {
 		if err := s(); err != nil {
 			return Config{}, err
@@ -110,6 +245,14 @@ func parseCommandLine(flagSet *flag.FlagSet, args []string) (string, error) {
 	return args[0], nil
 }
 
+// readUsername reads the name of the user, which is only needed when annotating service instances
+func readUsername(conn CLIConnection, annotate bool, set *string) error {
+	if !annotate {
+		return nil
+	}
+	return read("username", conn.Username, set)
+}
+
 // read calls a function (typically on the object that implements CLIConnection) and assuming
 // no error it stores it in the specified location. This arguably reads better than repetitive logic.
 func read[T any](desc string, get func() (T, error), set *T) error {
@@ -2,9 +2,13 @@ package config_test
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 	"upgrade-all-services-cli-plugin/internal/config"
 	"upgrade-all-services-cli-plugin/internal/config/configfakes"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
 
 	"github.com/hashicorp/go-version"
 	. "github.com/onsi/ginkgo/v2"
@@ -41,13 +45,73 @@ var _ = Describe("Config", func() {
 			Expect(cfgErr).To(MatchError(fmt.Sprintf("invalid flag combination: %s", message)))
 			Expect(cfg.Action).To(Equal(config.InvalidAction))
 		},
-		Entry(nil, []string{"--check-deactivated-plans", "--check-up-to-date"}, "--check-deactivated-plans, --check-up-to-date"),
-		Entry(nil, []string{"--check-deactivated-plans", "--dry-run"}, "--check-deactivated-plans, --dry-run"),
-		Entry(nil, []string{"--check-deactivated-plans", "--min-version-required", "1.2.3"}, "--check-deactivated-plans, --min-version-required"),
-		Entry(nil, []string{"--check-up-to-date", "--dry-run"}, "--check-up-to-date, --dry-run"),
-		Entry(nil, []string{"--check-up-to-date", "--min-version-required", "1.2.3"}, "--check-up-to-date, --min-version-required"),
-		Entry(nil, []string{"--dry-run", "--min-version-required", "1.2.3"}, "--dry-run, --min-version-required"),
-		Entry(nil, []string{"--check-deactivated-plans", "--check-up-to-date", "--dry-run", "--min-version-required", "1.2.3"}, "--check-deactivated-plans, --check-up-to-date, --dry-run, --min-version-required"),
+		Entry(nil, []string{"--dry-run", "--migrate-plans", "a=b"}, "--dry-run, --migrate-plans"),
+		Entry(nil, []string{"--migrate-plans", "a=b", "--migrate-plans-file", "/path/to/file"}, "--migrate-plans, --migrate-plans-file"),
+		Entry(nil, []string{"--check-up-to-date", "--inventory"}, "--check-up-to-date, --inventory"),
+		Entry(nil, []string{"--check-up-to-date", "--min-version-required", "1.2.3", "--migrate-plans", "a=b"}, "--check-up-to-date, --migrate-plans, --min-version-required"),
+		Entry(nil, []string{"--min-version-required", "1.2.3", "--min-version-policy", "/path/to/file"}, "--min-version-policy, --min-version-required"),
+		Entry(nil, []string{"--check-up-to-date", "--min-version-required", "1.2.3", "--min-version-policy", "/path/to/file"}, "--min-version-policy, --min-version-required"),
+	)
+
+	DescribeTable("combined checks",
+		func(flags []string, checks []config.Action) {
+			fakeArgs = append(fakeArgs, flags...)
+
+			// JustBeforeEach() pattern doesn't work with table tests
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.Action).To(Equal(config.CombinedChecksAction))
+			Expect(cfg.Checks).To(Equal(checks))
+		},
+		Entry(nil, []string{"--check-deactivated-plans", "--check-up-to-date"}, []config.Action{config.CheckUpToDateAction, config.CheckDeactivatedPlansAction}),
+		Entry(nil, []string{"--check-deactivated-plans", "--dry-run"}, []config.Action{config.DryRunAction, config.CheckDeactivatedPlansAction}),
+		Entry(nil, []string{"--min-version-required", "1.2.3", "--check-up-to-date"}, []config.Action{config.CheckUpToDateAction, config.MinVersionCheckAction}),
+		Entry(nil, []string{"--min-version-required", "1.2.3", "--version-constraint", "~> 1.6"}, []config.Action{config.MinVersionCheckAction, config.VersionConstraintAction}),
+		Entry(nil, []string{"--check-deactivated-plans", "--check-up-to-date", "--dry-run", "--min-version-required", "1.2.3"}, []config.Action{config.DryRunAction, config.CheckUpToDateAction, config.CheckDeactivatedPlansAction, config.MinVersionCheckAction}),
+		Entry(nil, []string{"--checks", "check-up-to-date,check-deactivated-plans"}, []config.Action{config.CheckUpToDateAction, config.CheckDeactivatedPlansAction}),
+		Entry(nil, []string{"--check-failed-operations", "--check-deactivated-plans"}, []config.Action{config.CheckDeactivatedPlansAction, config.CheckFailedOperationsAction}),
+		Entry(nil, []string{"--checks", "dry-run, version-constraint", "--version-constraint", "~> 1.6"}, []config.Action{config.DryRunAction, config.VersionConstraintAction}),
+		Entry(nil, []string{"--checks", "check-up-to-date,min-version-required", "--min-version-required", "1.2.3"}, []config.Action{config.CheckUpToDateAction, config.MinVersionCheckAction}),
+	)
+
+	Describe("--checks", func() {
+		It("selects a single check on its own", func() {
+			fakeArgs = append(fakeArgs, "--checks", "check-deactivated-plans")
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.Action).To(Equal(config.CheckDeactivatedPlansAction))
+			Expect(cfg.Checks).To(BeEmpty())
+		})
+
+		DescribeTable("invalid checks",
+			func(value, message string) {
+				fakeArgs = append(fakeArgs, "--checks", value)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("unknown", "check-up-to-date,bogus", `unknown check "bogus" for the --checks flag, must be one of: dry-run, check-up-to-date, check-deactivated-plans, check-failed-operations, check-version-anomalies, min-version-required, min-version-policy, version-constraint, max-pending-age`),
+			Entry("duplicate", "dry-run,dry-run", `duplicate check "dry-run" for the --checks flag`),
+			Entry("missing value", "dry-run,version-constraint", `the "version-constraint" check needs a value from the --version-constraint flag`),
+		)
+	})
+
+	DescribeTable("options that cannot be used when combining checks",
+		func(flags []string, message string) {
+			fakeArgs = append(fakeArgs, "--check-up-to-date", "--check-deactivated-plans")
+			fakeArgs = append(fakeArgs, flags...)
+
+			// JustBeforeEach() pattern doesn't work with table tests
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+			Expect(cfgErr).To(MatchError(message))
+		},
+		Entry(nil, []string{"--output", "table"}, "the --output table option cannot be used when combining checks"),
+		Entry(nil, []string{"--template", "/path/to/template"}, "the --template flag cannot be used when combining checks"),
+		Entry(nil, []string{"--summary"}, "the --summary flag cannot be used when combining checks"),
+		Entry(nil, []string{"--fail-on-unknown-version"}, "the --fail-on-unknown-version flag can only be used with the --min-version-required, --min-version-policy, or --version-constraint flags"),
 	)
 
 	Describe("flag combinations with --parallel", func() {
@@ -64,7 +128,7 @@ var _ = Describe("Config", func() {
 	})
 
 	Describe("flag combinations with --json", func() {
-		for _, flags := range [][]string{{"--min-version-required", "1.2.3"}, {"--check-deactivated-plans"}, {"--check-up-to-date"}, {"--dry-run"}} {
+		for _, flags := range [][]string{{}, {"--min-version-required", "1.2.3"}, {"--version-constraint", "~> 1.6"}, {"--check-deactivated-plans"}, {"--check-failed-operations"}, {"--check-version-anomalies"}, {"--check-up-to-date"}, {"--dry-run"}, {"--inventory"}} {
 			When(fmt.Sprintf("specified with flags: %q", strings.Join(flags, " ")), func() {
 				BeforeEach(func() {
 					fakeArgs = append(fakeArgs, "--json")
@@ -78,13 +142,13 @@ var _ = Describe("Config", func() {
 			})
 		}
 
-		When("specified with no other flags", func() {
+		When("specified with plan migration", func() {
 			BeforeEach(func() {
-				fakeArgs = append(fakeArgs, "--json")
+				fakeArgs = append(fakeArgs, "--json", "--migrate-plans", "small=medium")
 			})
 
 			It("fails", func() {
-				Expect(cfgErr).To(MatchError(`the --json flag can only be used with the --min-version-required, --check-deactivated-plans, --check-up-to-date, or --dry-run flags`))
+				Expect(cfgErr).To(MatchError(`the --json flag can only be used when upgrading, or with the --min-version-required, --version-constraint, --max-pending-age, --check-deactivated-plans, --check-failed-operations, --check-version-anomalies, --check-up-to-date, --dry-run, or --inventory flags`))
 			})
 		})
 	})
@@ -362,9 +426,14 @@ var _ = Describe("Config", func() {
 		Entry("none", nil, config.UpgradeAction),
 		Entry("", []string{"--parallel", "10"}, config.UpgradeAction),
 		Entry(nil, []string{"--check-deactivated-plans"}, config.CheckDeactivatedPlansAction),
+		Entry(nil, []string{"--check-failed-operations"}, config.CheckFailedOperationsAction),
+		Entry(nil, []string{"--check-version-anomalies"}, config.CheckVersionAnomaliesAction),
 		Entry(nil, []string{"--check-up-to-date"}, config.CheckUpToDateAction),
 		Entry(nil, []string{"--dry-run"}, config.DryRunAction),
 		Entry(nil, []string{"--min-version-required", "1.2.3"}, config.MinVersionCheckAction),
+		Entry(nil, []string{"--migrate-plans", "a=b"}, config.MigratePlansAction),
+		Entry(nil, []string{"--inventory"}, config.InventoryAction),
+		Entry(nil, []string{"--version-constraint", "~> 1.6"}, config.VersionConstraintAction),
 	)
 
 	Describe("min-version-required", func() {
@@ -405,6 +474,759 @@ var _ = Describe("Config", func() {
 		})
 	})
 
+	Describe("-version-constraint", func() {
+		When("not specified", func() {
+			It("is not set", func() {
+				Expect(cfg.VersionConstraint).To(BeNil())
+			})
+		})
+
+		When("specified with an invalid constraint", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-version-constraint", "about 1.6")
+			})
+
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError(ContainSubstring("error parsing version-constraint option: Malformed constraint: about 1.6")))
+			})
+		})
+
+		When("specified with a constraint", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-version-constraint", ">= 1.4.0, < 2.0.0, != 1.5.3")
+			})
+
+			It("parses the constraint", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.VersionConstraint.String()).To(Equal(">= 1.4.0, < 2.0.0, != 1.5.3"))
+			})
+		})
+	})
+
+	Describe("-fail-on-unknown-version", func() {
+		When("specified with a version check", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-min-version-required", "1.2.3", "-fail-on-unknown-version")
+			})
+
+			It("is set", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.FailOnUnknownVersion).To(BeTrue())
+			})
+		})
+
+		When("specified without a version check", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-check-up-to-date", "-fail-on-unknown-version")
+			})
+
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError("the --fail-on-unknown-version flag can only be used with the --min-version-required, --min-version-policy, or --version-constraint flags"))
+			})
+		})
+	})
+
+	Describe("-version-comparison", func() {
+		When("not specified", func() {
+			It("defaults to semver", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.VersionComparison).To(Equal(versionchecker.SemverComparison))
+			})
+		})
+
+		DescribeTable(
+			"valid values",
+			func(flags []string, expected versionchecker.Comparison) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.VersionComparison).To(Equal(expected))
+			},
+			Entry("ignore-suffix", []string{"-min-version-required", "1.2.3", "-version-comparison", "ignore-suffix"}, versionchecker.IgnoreSuffixComparison),
+			Entry("post-release", []string{"-version-constraint", "~> 1.2", "-version-comparison", "post-release"}, versionchecker.PostReleaseComparison),
+			Entry("inventory", []string{"-inventory", "-version-comparison", "post-release"}, versionchecker.PostReleaseComparison),
+			Entry("version anomalies", []string{"-check-version-anomalies", "-version-comparison", "ignore-suffix"}, versionchecker.IgnoreSuffixComparison),
+			Entry("semver when upgrading", []string{"-version-comparison", "semver"}, versionchecker.SemverComparison),
+		)
+
+		DescribeTable(
+			"invalid values",
+			func(flags []string, expected string) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+				Expect(cfgErr).To(MatchError(expected))
+			},
+			Entry("unknown", []string{"-inventory", "-version-comparison", "loose"}, `invalid --version-comparison option "loose", must be one of: semver, ignore-suffix, post-release`),
+			Entry("when upgrading", []string{"-version-comparison", "post-release"}, "the --version-comparison flag can only be used with the --min-version-required, --min-version-policy, --version-constraint, --check-version-anomalies, or --inventory flags"),
+		)
+	})
+
+	Describe("thresholds", func() {
+		When("not specified", func() {
+			It("fails a check for any violation", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.Thresholds).To(BeZero())
+			})
+		})
+
+		It("reads the thresholds", func() {
+			fakeArgs = append(fakeArgs, "-check-up-to-date", "-fail-if-more-than", "10", "-fail-if-percent-above", "2.5", "-grace-period-days", "14")
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.Thresholds).To(Equal(config.Thresholds{MaxInstances: 10, MaxPercent: 2.5, GracePeriod: 14 * 24 * time.Hour}))
+		})
+
+		DescribeTable(
+			"invalid values",
+			func(flags []string, expected string) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+				Expect(cfgErr).To(MatchError(expected))
+			},
+			Entry("negative count", []string{"-check-up-to-date", "-fail-if-more-than", "-1"}, "the --fail-if-more-than option must be 0 or greater"),
+			Entry("percent above 100", []string{"-check-up-to-date", "-fail-if-percent-above", "101"}, "the --fail-if-percent-above option must be in the range of 0 to 100"),
+			Entry("negative grace period", []string{"-check-up-to-date", "-grace-period-days", "-3"}, "the --grace-period-days option must be 0 or greater"),
+			Entry("grace period for a check without upgrades", []string{"-check-deactivated-plans", "-grace-period-days", "3"}, "the --grace-period-days flag can only be used with the --check-up-to-date or --max-pending-age flags"),
+			Entry("not a check", []string{"-dry-run", "-fail-if-more-than", "3"}, "the --fail-if-more-than, --fail-if-percent-above, and --grace-period-days flags can only be used with the --check-up-to-date, --check-deactivated-plans, --check-failed-operations, --check-version-anomalies, --min-version-required, --min-version-policy, --version-constraint, or --max-pending-age flags"),
+		)
+	})
+
+	Describe("-max-pending-age", func() {
+		DescribeTable(
+			"valid values",
+			func(value string, expected time.Duration) {
+				fakeArgs = append(fakeArgs, "-max-pending-age", value)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.Action).To(Equal(config.PendingAgeAction))
+				Expect(cfg.MaxPendingAge).To(Equal(expected))
+			},
+			Entry("days", "30d", 30*24*time.Hour),
+			Entry("duration", "36h", 36*time.Hour),
+		)
+
+		DescribeTable(
+			"invalid values",
+			func(flags []string, expected string) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+				Expect(cfgErr).To(MatchError(expected))
+			},
+			Entry("not an age", []string{"-max-pending-age", "a month"}, `error parsing max-pending-age option "a month", must be a number of days such as '30d', or a duration such as '36h'`),
+			Entry("zero", []string{"-max-pending-age", "0d"}, "the --max-pending-age option must be greater than 0"),
+			Entry("pending since file without the check", []string{"-check-deactivated-plans", "-pending-since-file", "/path/to/file"}, "the --pending-since-file flag can only be used with the --max-pending-age or --check-up-to-date flags"),
+		)
+
+		It("accepts a pending since file", func() {
+			fakeArgs = append(fakeArgs, "-max-pending-age", "30d", "-pending-since-file", "/path/to/file")
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.PendingSinceFile).To(Equal("/path/to/file"))
+		})
+
+		It("accepts a pending since file for the grace period of the up-to-date check", func() {
+			fakeArgs = append(fakeArgs, "-check-up-to-date", "-grace-period-days", "7", "-pending-since-file", "/path/to/file")
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.PendingSinceFile).To(Equal("/path/to/file"))
+		})
+	})
+
+	Describe("-replacement-plans-file", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "replacements.txt")
+			Expect(os.WriteFile(path, []byte("# preferred replacements\nsmall=medium\nmysql:large=xlarge\n"), 0o600)).To(Succeed())
+		})
+
+		It("reads the replacement plans", func() {
+			fakeArgs = append(fakeArgs, "-check-deactivated-plans", "-replacement-plans-file", path)
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.ReplacementPlans).To(Equal([]config.PlanMapping{
+				{FromPlanName: "small", ToPlanName: "medium"},
+				{ServiceOfferingName: "mysql", FromPlanName: "large", ToPlanName: "xlarge"},
+			}))
+		})
+
+		It("can be used when combining checks", func() {
+			fakeArgs = append(fakeArgs, "-checks", "check-up-to-date,check-deactivated-plans", "-replacement-plans-file", path)
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.ReplacementPlans).To(HaveLen(2))
+		})
+
+		DescribeTable(
+			"invalid values",
+			func(flags []string, expected string) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+				Expect(cfgErr).To(MatchError(ContainSubstring(expected)))
+			},
+			Entry("without the check", []string{"-check-up-to-date", "-replacement-plans-file", "/path/to/file"}, "the --replacement-plans-file flag can only be used with the --check-deactivated-plans flag"),
+			Entry("missing file", []string{"-check-deactivated-plans", "-replacement-plans-file", "/path/to/missing"}, "error reading replacement-plans-file option:"),
+		)
+	})
+
+	Describe("-migrate-plans", func() {
+		When("not specified", func() {
+			It("is not set", func() {
+				Expect(cfg.PlanMappings).To(BeNil())
+			})
+		})
+
+		When("specified with mappings", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-migrate-plans", "small=medium, postgres:large=xlarge")
+			})
+
+			It("parses the mappings", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.PlanMappings).To(Equal([]config.PlanMapping{
+					{FromPlanName: "small", ToPlanName: "medium"},
+					{ServiceOfferingName: "postgres", FromPlanName: "large", ToPlanName: "xlarge"},
+				}))
+			})
+		})
+
+		DescribeTable("invalid mappings",
+			func(value, message string) {
+				fakeArgs = append(fakeArgs, "-migrate-plans", value)
+
+				// JustBeforeEach() pattern doesn't work with table tests
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("missing target", "small", `invalid plan mapping "small", expected format: [offering:]old-plan=new-plan`),
+			Entry("empty target", "small=", `invalid plan mapping "small=", expected format: [offering:]old-plan=new-plan`),
+			Entry("empty offering", ":small=medium", `invalid plan mapping ":small=medium", expected format: [offering:]old-plan=new-plan`),
+			Entry("mapped to itself", "small=small", `invalid plan mapping "small=small", plan is mapped to itself`),
+			Entry("mapped twice", "small=medium,small=large", `plan "small" is mapped more than once`),
+		)
+	})
+
+	Describe("-migrate-plans-file", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "mappings")
+			fakeArgs = append(fakeArgs, "-migrate-plans-file", path)
+		})
+
+		When("the file contains mappings", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(path, []byte("# retired plans\nsmall=medium\n\npostgres:large=xlarge\n"), 0o600)).To(Succeed())
+			})
+
+			It("parses the mappings", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.Action).To(Equal(config.MigratePlansAction))
+				Expect(cfg.PlanMappings).To(Equal([]config.PlanMapping{
+					{FromPlanName: "small", ToPlanName: "medium"},
+					{ServiceOfferingName: "postgres", FromPlanName: "large", ToPlanName: "xlarge"},
+				}))
+			})
+		})
+
+		When("the file contains no mappings", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(path, []byte("# nothing to see\n"), 0o600)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError(fmt.Sprintf("no plan mappings found in file: %s", path)))
+			})
+		})
+
+		When("the file does not exist", func() {
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError(ContainSubstring("error reading migrate-plans-file option:")))
+			})
+		})
+	})
+
+	Describe("-min-version-policy", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "policy")
+			fakeArgs = append(fakeArgs, "-min-version-policy", path)
+		})
+
+		When("the file contains rules", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(path, []byte("# minimum versions\n*=1.0.0\n\npostgres=1.2.3\npostgres:large=>= 1.6, < 2\n"), 0o600)).To(Succeed())
+			})
+
+			It("parses the rules", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.Action).To(Equal(config.MinVersionCheckAction))
+				Expect(cfg.MinVersion).To(BeNil())
+				Expect(cfg.MinVersionPolicy).To(HaveLen(3))
+				Expect(cfg.MinVersionPolicy[0].String()).To(Equal("* >= 1.0.0"))
+				Expect(cfg.MinVersionPolicy[1].String()).To(Equal("postgres >= 1.2.3"))
+				Expect(cfg.MinVersionPolicy[2].String()).To(Equal("postgres:large >= 1.6, < 2"))
+			})
+		})
+
+		When("the file contains more than one rule for a plan", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(path, []byte("postgres:large=1.2.3\npostgres:large=1.3.0\n"), 0o600)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError(`more than one minimum version rule for "postgres:large"`))
+			})
+		})
+
+		When("the file contains an invalid rule", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(path, []byte("postgres=latest\n"), 0o600)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError(`invalid rule "postgres=latest", "latest" is neither a version nor a constraint`))
+			})
+		})
+
+		When("the file contains no rules", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(path, []byte("# nothing to see\n"), 0o600)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError(fmt.Sprintf("no minimum version rules found in file: %s", path)))
+			})
+		})
+
+		When("the file does not exist", func() {
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError(ContainSubstring("error reading min-version-policy option:")))
+			})
+		})
+	})
+
+	Describe("-annotate", func() {
+		When("not specified", func() {
+			It("does not read the username", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.Annotate).To(BeFalse())
+				Expect(fakeCLIConnection.UsernameCallCount()).To(BeZero())
+			})
+		})
+
+		When("specified", func() {
+			BeforeEach(func() {
+				fakeCLIConnection.UsernameReturns("fake-user", nil)
+				fakeArgs = append(fakeArgs, "-annotate")
+			})
+
+			It("reads the username", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.Annotate).To(BeTrue())
+				Expect(cfg.Username).To(Equal("fake-user"))
+			})
+		})
+
+		When("error getting the username", func() {
+			BeforeEach(func() {
+				fakeCLIConnection.UsernameReturns("", fmt.Errorf("boom"))
+				fakeArgs = append(fakeArgs, "-annotate")
+			})
+
+			It("returns the error", func() {
+				Expect(cfgErr).To(MatchError("error reading username: boom"))
+			})
+		})
+
+		When("specified with a check", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-annotate", "-dry-run")
+			})
+
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError("the --annotate flag can only be used when upgrading service instances"))
+			})
+		})
+	})
+
+	Describe("-blast-radius", func() {
+		It("defaults to false", func() {
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.BlastRadius).To(BeFalse())
+		})
+
+		When("specified when upgrading", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-blast-radius")
+			})
+
+			It("succeeds", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.BlastRadius).To(BeTrue())
+			})
+		})
+
+		When("specified with --dry-run", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-blast-radius", "-dry-run", "-json")
+			})
+
+			It("succeeds", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.BlastRadius).To(BeTrue())
+			})
+		})
+
+		When("specified with a check", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-blast-radius", "-check-up-to-date")
+			})
+
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError("the --blast-radius flag can only be used when upgrading, or with the --dry-run flag"))
+			})
+		})
+
+		When("specified with --dry-run and a tabular output", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-blast-radius", "-dry-run", "-output", "csv")
+			})
+
+			It("returns an error", func() {
+				Expect(cfgErr).To(MatchError("the --blast-radius flag cannot be used with the --output csv option"))
+			})
+		})
+	})
+
+	Describe("-output", func() {
+		It("defaults to text", func() {
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.Output).To(Equal(config.TextOutput))
+		})
+
+		DescribeTable("valid",
+			func(flags []string, output config.OutputFormat) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.Output).To(Equal(output))
+			},
+			Entry("text", []string{"-output", "text", "-dry-run"}, config.TextOutput),
+			Entry("jsonl when upgrading", []string{"-output", "jsonl"}, config.JSONLinesOutput),
+			Entry("jsonl when migrating plans", []string{"-output", "jsonl", "-migrate-plans", "small=medium"}, config.JSONLinesOutput),
+			Entry("table with dry run", []string{"-output", "table", "-dry-run"}, config.TableOutput),
+			Entry("csv with check-up-to-date", []string{"-output", "csv", "-check-up-to-date"}, config.CSVOutput),
+			Entry("csv with check-deactivated-plans", []string{"-output", "csv", "-check-deactivated-plans"}, config.CSVOutput),
+			Entry("table with min-version-required", []string{"-output", "table", "-min-version-required", "1.2.3"}, config.TableOutput),
+			Entry("csv with inventory", []string{"-output", "csv", "-inventory"}, config.CSVOutput),
+		)
+
+		DescribeTable("invalid",
+			func(flags []string, message string) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("unknown", []string{"-output", "xml"}, `invalid --output option "xml", must be one of: text, jsonl, table, csv`),
+			Entry("jsonl with a check", []string{"-output", "jsonl", "-check-up-to-date"}, "the --output jsonl option can only be used when upgrading service instances or migrating plans"),
+			Entry("jsonl with JSON", []string{"-output", "jsonl", "-json"}, "the --output jsonl option cannot be used with the --json flag"),
+			Entry("table when upgrading", []string{"-output", "table"}, "the --output table option can only be used with the --dry-run, --check-up-to-date, --check-deactivated-plans, --check-failed-operations, --check-version-anomalies, --min-version-required, --version-constraint, --max-pending-age, or --inventory flags"),
+			Entry("csv with JSON", []string{"-output", "csv", "-dry-run", "-json"}, "the --output csv option cannot be used with the --json flag"),
+		)
+	})
+
+	Describe("-columns", func() {
+		It("is not set for text output", func() {
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.Columns).To(BeEmpty())
+		})
+
+		It("has default columns for table output", func() {
+			fakeArgs = append(fakeArgs, "-output", "table", "-dry-run")
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.Columns).To(Equal([]config.Column{
+				config.StatusColumn, config.OrgColumn, config.SpaceColumn, config.NameColumn,
+				config.OfferingColumn, config.PlanColumn, config.VersionColumn, config.PlanVersionColumn,
+			}))
+		})
+
+		It("can be selected", func() {
+			fakeArgs = append(fakeArgs, "-output", "csv", "-check-up-to-date", "-columns", "guid, org_guid,space_guid")
+			cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.Columns).To(Equal([]config.Column{config.GUIDColumn, config.OrgGUIDColumn, config.SpaceGUIDColumn}))
+		})
+
+		DescribeTable("invalid",
+			func(flags []string, message string) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("without table or csv output", []string{"-dry-run", "-columns", "guid"}, "the --columns flag can only be used with the --output table or --output csv options"),
+			Entry("unknown", []string{"-dry-run", "-output", "csv", "-columns", "guid,color"}, `invalid column "color" for the --columns flag, must be one of: status, name, guid, version, plan, plan_guid, plan_version, offering, offering_guid, space, space_guid, org, org_guid, replacement_candidates, replacement_plan`),
+			Entry("duplicate", []string{"-dry-run", "-output", "csv", "-columns", "guid,guid"}, `duplicate column "guid" for the --columns flag`),
+		)
+	})
+
+	Describe("-template", func() {
+		DescribeTable("valid",
+			func(flags []string) {
+				fakeArgs = append(fakeArgs, "-template", "/path/to/report.tmpl")
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.TemplateFile).To(Equal("/path/to/report.tmpl"))
+			},
+			Entry("dry-run", []string{"-dry-run"}),
+			Entry("check-up-to-date", []string{"-check-up-to-date"}),
+			Entry("check-deactivated-plans", []string{"-check-deactivated-plans"}),
+			Entry("min-version-required", []string{"-min-version-required", "1.2.3"}),
+		)
+
+		DescribeTable("invalid",
+			func(flags []string, message string) {
+				fakeArgs = append(fakeArgs, "-template", "/path/to/report.tmpl")
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("when upgrading", []string{}, "the --template flag can only be used with the --dry-run, --check-up-to-date, --check-deactivated-plans, --min-version-required, --version-constraint, or --inventory flags"),
+			Entry("with JSON", []string{"-dry-run", "-json"}, "the --template flag cannot be used with the --json flag"),
+			Entry("with CSV", []string{"-dry-run", "-output", "csv"}, "the --template flag cannot be used with the --output csv option"),
+		)
+	})
+
+	Describe("-summary", func() {
+		DescribeTable("valid",
+			func(flags []string) {
+				fakeArgs = append(fakeArgs, "-summary")
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.Summary).To(BeTrue())
+			},
+			Entry("upgrade", []string{}),
+			Entry("upgrade with JSON", []string{"-json"}),
+			Entry("dry-run", []string{"-dry-run"}),
+			Entry("check-up-to-date", []string{"-check-up-to-date"}),
+			Entry("check-deactivated-plans", []string{"-check-deactivated-plans", "-json"}),
+			Entry("min-version-required", []string{"-min-version-required", "1.2.3"}),
+		)
+
+		DescribeTable("invalid",
+			func(flags []string, message string) {
+				fakeArgs = append(fakeArgs, "-summary")
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("with inventory", []string{"-inventory"}, "the --summary flag can only be used when upgrading, or with the --dry-run, --check-up-to-date, --check-deactivated-plans, --check-failed-operations, --check-version-anomalies, --min-version-required, --version-constraint, or --max-pending-age flags"),
+			Entry("when migrating plans", []string{"-migrate-plans", "small=medium"}, "the --summary flag can only be used when upgrading, or with the --dry-run, --check-up-to-date, --check-deactivated-plans, --check-failed-operations, --check-version-anomalies, --min-version-required, --version-constraint, or --max-pending-age flags"),
+			Entry("with a template", []string{"-dry-run", "-template", "/path/to/report.tmpl"}, "the --summary flag cannot be used with the --template flag"),
+			Entry("with CSV", []string{"-dry-run", "-output", "csv"}, "the --summary flag cannot be used with the --output csv option"),
+		)
+	})
+
+	Describe("-junit-report", func() {
+		DescribeTable("valid",
+			func(flags []string) {
+				fakeArgs = append(fakeArgs, "-junit-report", "/path/to/junit.xml")
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.JUnitReportFile).To(Equal("/path/to/junit.xml"))
+			},
+			Entry("upgrade", []string{}),
+			Entry("check-up-to-date", []string{"-check-up-to-date"}),
+			Entry("check-deactivated-plans", []string{"-check-deactivated-plans"}),
+			Entry("min-version-required", []string{"-min-version-required", "1.2.3"}),
+		)
+
+		When("specified with dry run", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-junit-report", "/path/to/junit.xml", "-dry-run")
+			})
+
+			It("fails", func() {
+				Expect(cfgErr).To(MatchError("the --junit-report flag can only be used when upgrading, or with the --check-up-to-date, --check-deactivated-plans, --check-failed-operations, --check-version-anomalies, --min-version-required, --version-constraint, or --max-pending-age flags"))
+			})
+		})
+	})
+
+	Describe("-report-file", func() {
+		When("specified", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-report-file", "/path/to/report.json")
+			})
+
+			It("reads the path", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.ReportFile).To(Equal("/path/to/report.json"))
+			})
+		})
+
+		DescribeTable("invalid combinations",
+			func(flags []string, message string) {
+				fakeArgs = append(fakeArgs, "-report-file", "/path/to/report.json")
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("with a check", []string{"-check-up-to-date"}, "the --report-file flag can only be used when upgrading service instances"),
+			Entry("with JSON output", []string{"-json"}, "the --report-file flag cannot be used with the --json flag"),
+		)
+	})
+
+	Describe("-pre-hook and -post-hook", func() {
+		When("not specified", func() {
+			It("has the default timeout", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.PreHook).To(BeEmpty())
+				Expect(cfg.PostHook).To(BeEmpty())
+				Expect(cfg.HookTimeout).To(Equal(5 * time.Minute))
+			})
+		})
+
+		When("specified", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-pre-hook", "./backup.sh", "-post-hook", "./smoke-test.sh", "-hook-timeout", "90s")
+			})
+
+			It("reads the commands and timeout", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.PreHook).To(Equal("./backup.sh"))
+				Expect(cfg.PostHook).To(Equal("./smoke-test.sh"))
+				Expect(cfg.HookTimeout).To(Equal(90 * time.Second))
+			})
+		})
+
+		DescribeTable("specified with a check",
+			func(flag string) {
+				fakeArgs = append(fakeArgs, flag, "true", "-check-up-to-date")
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).To(MatchError(fmt.Sprintf("the -%s flag can only be used when upgrading service instances", flag)))
+			},
+			Entry("pre-hook", "-pre-hook"),
+			Entry("post-hook", "-post-hook"),
+		)
+
+		DescribeTable("invalid timeout",
+			func(timeout, message string) {
+				fakeArgs = append(fakeArgs, "-hook-timeout", timeout)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("zero", "0s", "hook timeout must be greater than 0"),
+			Entry("too long", "2h", "hook timeout must be less than or equal to 1h0m0s"),
+		)
+	})
+
+	Describe("-health-check-window", func() {
+		When("not specified", func() {
+			It("does not check the health of bound apps", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.HealthCheckWindow).To(BeZero())
+				Expect(cfg.StopOnDegraded).To(BeFalse())
+			})
+		})
+
+		When("specified", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-health-check-window", "2m", "-stop-on-degraded")
+			})
+
+			It("reads the window and whether to stop", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.HealthCheckWindow).To(Equal(2 * time.Minute))
+				Expect(cfg.StopOnDegraded).To(BeTrue())
+			})
+		})
+
+		DescribeTable("invalid",
+			func(flags []string, message string) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("negative", []string{"-health-check-window", "-1s"}, "health check window must be greater or equal to 0"),
+			Entry("too long", []string{"-health-check-window", "2h"}, "health check window must be less than or equal to 1h0m0s"),
+			Entry("with a check", []string{"-health-check-window", "1m", "-dry-run"}, "the --health-check-window flag can only be used when upgrading service instances"),
+			Entry("stop without a window", []string{"-stop-on-degraded"}, "the --stop-on-degraded flag can only be used with the --health-check-window flag"),
+		)
+	})
+
+	Describe("-notify-url", func() {
+		When("not specified", func() {
+			It("has the default timeout", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.NotifyURL).To(BeEmpty())
+				Expect(cfg.NotifyTimeout).To(Equal(10 * time.Second))
+			})
+		})
+
+		When("specified", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-notify-url", "https://chat.example.com/hooks/abc", "-notify-timeout", "3s")
+			})
+
+			It("reads the URL and timeout", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+				Expect(cfg.NotifyURL).To(Equal("https://chat.example.com/hooks/abc"))
+				Expect(cfg.NotifyTimeout).To(Equal(3 * time.Second))
+			})
+		})
+
+		When("specified with plan migration", func() {
+			BeforeEach(func() {
+				fakeArgs = append(fakeArgs, "-notify-url", "http://localhost:8080", "-migrate-plans", "small=medium")
+			})
+
+			It("is accepted", func() {
+				Expect(cfgErr).NotTo(HaveOccurred())
+			})
+		})
+
+		DescribeTable("invalid",
+			func(flags []string, message string) {
+				fakeArgs = append(fakeArgs, flags...)
+				cfg, cfgErr = config.ParseConfig(fakeCLIConnection, fakeArgs)
+				Expect(cfgErr).To(MatchError(message))
+			},
+			Entry("with a check", []string{"-notify-url", "https://example.com", "-dry-run"}, "the --notify-url flag can only be used when upgrading service instances or migrating plans"),
+			Entry("not a URL", []string{"-notify-url", "example.com"}, "the --notify-url flag must be an http or https URL"),
+			Entry("wrong scheme", []string{"-notify-url", "ftp://example.com"}, "the --notify-url flag must be an http or https URL"),
+			Entry("zero timeout", []string{"-notify-timeout", "0s"}, "notify timeout must be greater than 0"),
+			Entry("timeout too long", []string{"-notify-timeout", "2m"}, "notify timeout must be less than or equal to 1m0s"),
+		)
+	})
+
+	Describe("run ID", func() {
+		It("generates a different run ID each time", func() {
+			Expect(cfgErr).NotTo(HaveOccurred())
+			Expect(cfg.RunID).To(MatchRegexp(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`))
+
+			other, err := config.ParseConfig(fakeCLIConnection, fakeArgs)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(other.RunID).NotTo(Equal(cfg.RunID))
+		})
+	})
+
 	Describe("broker name", func() {
 		When("valid", func() {
 			BeforeEach(func() {
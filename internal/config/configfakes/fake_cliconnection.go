@@ -67,6 +67,18 @@ type FakeCLIConnection struct {
 		result1 bool
 		result2 error
 	}
+	UsernameStub        func() (string, error)
+	usernameMutex       sync.RWMutex
+	usernameArgsForCall []struct {
+	}
+	usernameReturns struct {
+		result1 string
+		result2 error
+	}
+	usernameReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -351,19 +363,65 @@ func (fake *FakeCLIConnection) IsSSLDisabledReturnsOnCall(i int, result1 bool, r
 	}{result1, result2}
 }
 
+func (fake *FakeCLIConnection) Username() (string, error) {
+	fake.usernameMutex.Lock()
+	ret, specificReturn := fake.usernameReturnsOnCall[len(fake.usernameArgsForCall)]
+	fake.usernameArgsForCall = append(fake.usernameArgsForCall, struct {
+	}{})
+	stub := fake.UsernameStub
+	fakeReturns := fake.usernameReturns
+	fake.recordInvocation("Username", []interface{}{})
+	fake.usernameMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCLIConnection) UsernameCallCount() int {
+	fake.usernameMutex.RLock()
+	defer fake.usernameMutex.RUnlock()
+	return len(fake.usernameArgsForCall)
+}
+
+func (fake *FakeCLIConnection) UsernameCalls(stub func() (string, error)) {
+	fake.usernameMutex.Lock()
+	defer fake.usernameMutex.Unlock()
+	fake.UsernameStub = stub
+}
+
+func (fake *FakeCLIConnection) UsernameReturns(result1 string, result2 error) {
+	fake.usernameMutex.Lock()
+	defer fake.usernameMutex.Unlock()
+	fake.UsernameStub = nil
+	fake.usernameReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCLIConnection) UsernameReturnsOnCall(i int, result1 string, result2 error) {
+	fake.usernameMutex.Lock()
+	defer fake.usernameMutex.Unlock()
+	fake.UsernameStub = nil
+	if fake.usernameReturnsOnCall == nil {
+		fake.usernameReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.usernameReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeCLIConnection) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.accessTokenMutex.RLock()
-	defer fake.accessTokenMutex.RUnlock()
-	fake.apiEndpointMutex.RLock()
-	defer fake.apiEndpointMutex.RUnlock()
-	fake.apiVersionMutex.RLock()
-	defer fake.apiVersionMutex.RUnlock()
-	fake.isLoggedInMutex.RLock()
-	defer fake.isLoggedInMutex.RUnlock()
-	fake.isSSLDisabledMutex.RLock()
-	defer fake.isSSLDisabledMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -28,13 +28,108 @@ const (
 	minVersionRequiredFlag        = "min-version-required"
 	minVersionRequiredDescription = "--min-version-required <major.minor.patch>. Checks and fails if any service instance has a version lower than the specified"
 
+	minVersionPolicyDefault     = ""
+	minVersionPolicyFlag        = "min-version-policy"
+	minVersionPolicyDescription = "--min-version-policy <path>. Like --min-version-required, but reads minimum version rules for service offerings and plans from a file with one rule per line, in the format: offering[:plan]=version-or-constraint. Use '*' as the offering for a rule that applies to all service instances. Lines starting with '#' are ignored"
+
+	versionConstraintDefault     = ""
+	versionConstraintFlag        = "version-constraint"
+	versionConstraintDescription = "--version-constraint <constraint>. Checks and fails if the version of any service instance does not satisfy the constraint, for example \">= 1.4.0, < 2.0.0\", \"~> 1.6\" or \"!= 1.5.3\""
+
+	failOnUnknownVersionDefault     = false
+	failOnUnknownVersionFlag        = "fail-on-unknown-version"
+	failOnUnknownVersionDescription = "with --min-version-required, --min-version-policy or --version-constraint, fail when a service instance has an empty version or one that is not a semantic version. By default these are listed, but do not fail the check"
+
+	versionComparisonDefault     = "semver"
+	versionComparisonFlag        = "version-comparison"
+	versionComparisonDescription = "--version-comparison <semver|ignore-suffix|post-release>. How a suffix such as '-build.3' in '2.10.14-build.3' is compared by --min-version-required, --min-version-policy, --version-constraint, --check-version-anomalies, and --inventory. With 'semver' the version is a pre-release that comes before '2.10.14', with 'ignore-suffix' it is equal to '2.10.14', and with 'post-release' the number at the end of the suffix is a build that comes after '2.10.14'. Default is 'semver'"
+
+	maxPendingAgeDefault     = ""
+	maxPendingAgeFlag        = "max-pending-age"
+	maxPendingAgeDescription = "--max-pending-age <age>. Checks and fails if any service instance has had an upgrade available for longer than the age, for example '30d' or '36h'. The upgrade is pending from when the plan was last updated, or from when the service instance was first seen with an upgrade available in the --pending-since-file, whichever is earlier"
+
+	pendingSinceFileDefault     = ""
+	pendingSinceFileFlag        = "pending-since-file"
+	pendingSinceFileDescription = "--pending-since-file <path>. With --max-pending-age or --check-up-to-date, records in the file when each service instance was first seen with an upgrade available, so that the age and the grace period are known across runs. The file is created if it does not exist"
+
 	checkDeactivatedPlansDefault     = false
 	checkDeactivatedPlansFlag        = "check-deactivated-plans"
-	checkDeactivatedPlansDescription = "checks whether any of the plans have been deactivated. If any deactivated plans are found, the command will fail"
+	checkDeactivatedPlansDescription = "checks whether any of the plans have been deactivated. If any deactivated plans are found, the command will fail. Active plans of the same service offering are suggested as replacements"
+
+	replacementPlansFileDefault     = ""
+	replacementPlansFileFlag        = "replacement-plans-file"
+	replacementPlansFileDescription = "--replacement-plans-file <path>. With --check-deactivated-plans, reads the preferred replacement for each deactivated plan from a file in the same format as --migrate-plans-file"
+
+	failIfMoreThanDefault     = 0
+	failIfMoreThanFlag        = "fail-if-more-than"
+	failIfMoreThanDescription = "--fail-if-more-than <count>. A check fails when more than this number of service instances violate it. When combined with --fail-if-percent-above, exceeding either threshold fails the check. Default is 0, which is no limit unless neither threshold is set, in which case any violation fails the check"
+
+	failIfPercentAboveDefault     = 0.0
+	failIfPercentAboveFlag        = "fail-if-percent-above"
+	failIfPercentAboveDescription = "--fail-if-percent-above <percent>. A check fails when more than this percentage of the service instances violate it. When combined with --fail-if-more-than, exceeding either threshold fails the check. Default is 0, which is no limit unless neither threshold is set, in which case any violation fails the check"
+
+	gracePeriodDaysDefault     = 0
+	gracePeriodDaysFlag        = "grace-period-days"
+	gracePeriodDaysDescription = "--grace-period-days <days>. With --check-up-to-date or --max-pending-age, a service instance with an upgrade available only counts towards failing the check once the upgrade has been available for more than this number of days, so that there has been time to upgrade it. The upgrade is available from when the plan was last updated, or from when the service instance was first seen with an upgrade available in the --pending-since-file, whichever is earlier. Default is 0"
+
+	checksDefault     = ""
+	checksFlag        = "checks"
+	checksDescription = "--checks <check,...>. Runs the named checks against a single discovery of the service instances, with a section for each check in the output. Available checks: dry-run, check-up-to-date, check-deactivated-plans, check-failed-operations, check-version-anomalies, min-version-required, min-version-policy, version-constraint, max-pending-age. A check that needs a value also needs its own flag, for example --version-constraint"
+
+	checkFailedOperationsDefault     = false
+	checkFailedOperationsFlag        = "check-failed-operations"
+	checkFailedOperationsDescription = "checks and fails if the last operation of any service instance failed, listing them by the type of operation, with the message from the broker and how long ago the operation happened"
+
+	checkVersionAnomaliesDefault     = false
+	checkVersionAnomaliesFlag        = "check-version-anomalies"
+	checkVersionAnomaliesDescription = "checks and fails if the version of any service instance is ahead of its plan, differs from its plan without an upgrade available, or is missing from only one of them, which points to a bug in the broker or Cloud Controller"
+
+	inventoryDefault     = false
+	inventoryFlag        = "inventory"
+	inventoryDescription = "list every service instance with its version and the version of its plan, and a histogram of the versions for each service offering and plan. Never fails because of the state of a service instance"
+
+	migratePlansDefault     = ""
+	migratePlansFlag        = "migrate-plans"
+	migratePlansDescription = "--migrate-plans <[offering:]old-plan=new-plan,...>. Moves service instances from deactivated plans onto replacement plans of the same service offering"
+
+	migratePlansFileDefault     = ""
+	migratePlansFileFlag        = "migrate-plans-file"
+	migratePlansFileDescription = "--migrate-plans-file <path>. Like --migrate-plans, but reads the plan mappings from a file with one mapping per line. Lines starting with '#' are ignored"
 
 	jsonOutputDefault     = false
 	jsonOutputFlag        = "json"
-	jsonOutputDescription = "output as JSON"
+	jsonOutputDescription = "output as JSON. When upgrading, a JSON report of the outcome for each service instance is written to stdout, and the log is written to stderr"
+
+	junitReportDefault     = ""
+	junitReportFlag        = "junit-report"
+	junitReportDescription = "--junit-report <path>. Write a JUnit XML report with a test case for each service instance to the file. Can be used when upgrading, or with the --check-up-to-date, --check-deactivated-plans, --check-failed-operations, --check-version-anomalies, --min-version-required, --version-constraint, or --max-pending-age flags"
+
+	outputDefault     = string(TextOutput)
+	outputFlag        = "output"
+	outputDescription = "--output <text|jsonl|table|csv>. When upgrading or migrating plans, 'jsonl' writes each event to stdout as a line of JSON, and the text log to stderr. With --dry-run or a check, 'table' and 'csv' write a row for each service instance. Default is 'text'"
+
+	columnsDefault     = ""
+	columnsFlag        = "columns"
+	columnsDescription = "--columns <column,...>. The columns written by --output table or csv. Available columns: status, name, guid, version, plan, plan_guid, plan_version, offering, offering_guid, space, space_guid, org, org_guid, replacement_candidates, replacement_plan. Default is '" + defaultColumns + "'"
+
+	// defaultColumns are used by the table and CSV output formats when --columns is not specified
+	defaultColumns = "status,org,space,name,offering,plan,version,plan_version"
+
+	summaryDefault     = false
+	summaryFlag        = "summary"
+	summaryDescription = "add a breakdown of the service instances by org, space, service offering, plan and version to the text or JSON output. Can be used when upgrading, or with the --dry-run, --check-up-to-date, --check-deactivated-plans, --check-failed-operations, --check-version-anomalies, --min-version-required, --version-constraint, or --max-pending-age flags"
+
+	templateDefault     = ""
+	templateFlag        = "template"
+	templateDescription = "--template <path>. With --dry-run or a check, render the service instances through a Go text/template file instead of the usual output"
+
+	reportFileDefault     = ""
+	reportFileFlag        = "report-file"
+	reportFileDescription = "--report-file <path>. When upgrading, write a JSON report of the outcome for each service instance to the file"
+
+	blastRadiusDefault     = false
+	blastRadiusFlag        = "blast-radius"
+	blastRadiusDescription = "when upgrading, or with --dry-run, look up the apps bound to each upgradable service instance through service credential bindings and route bindings, and log them with the number of instances and bound apps in each org. The bound apps are also included in the --json output and the --report-file"
 
 	limitDefault     = 0
 	limitFlag        = "limit"
@@ -52,7 +147,42 @@ const (
 
 	ignoreInstanceErrorsDefault     = false
 	ignoreInstanceErrorsFlag        = "ignore-instance-errors"
-	ignoreInstanceErrorsDescription = "exit with code 0 even when the -min-version-required, -check-deactivated-plans, or -check-up-to-date detect outdated service instances"
+	ignoreInstanceErrorsDescription = "exit with code 0 even when the -min-version-required, -version-constraint, -check-deactivated-plans, or -check-up-to-date detect outdated service instances"
+
+	annotateDefault     = false
+	annotateFlag        = "annotate"
+	annotateDescription = "after a successful upgrade, record who upgraded the service instance, when, from which version, and the run ID as annotations on the service instance"
+
+	preHookDefault     = ""
+	preHookFlag        = "pre-hook"
+	preHookDescription = "--pre-hook <command>. Shell command to run before upgrading each service instance, with details of the instance in UAS_* environment variables. If the command fails, the instance is skipped"
+
+	postHookDefault     = ""
+	postHookFlag        = "post-hook"
+	postHookDescription = "--post-hook <command>. Shell command to run after successfully upgrading each service instance, with details of the instance in UAS_* environment variables. A failure is reported separately from upgrade failures"
+
+	hookTimeoutDefault     = 5 * time.Minute
+	hookTimeoutFlag        = "hook-timeout"
+	hookTimeoutDescription = "time to wait for a pre-hook or post-hook command to complete, e.g. '30s', '10m'. Maximum 1h, default 5m."
+	hookTimeoutMaximum     = time.Hour
+
+	healthCheckWindowDefault     = time.Duration(0)
+	healthCheckWindowFlag        = "health-check-window"
+	healthCheckWindowDescription = "after upgrading each service instance, check the processes of the apps bound to it for crashes for this long, e.g. '2m'. Maximum 1h, default 0 which disables the check."
+	healthCheckWindowMaximum     = time.Hour
+
+	stopOnDegradedDefault     = false
+	stopOnDegradedFlag        = "stop-on-degraded"
+	stopOnDegradedDescription = "with --health-check-window, stop upgrading further service instances once an upgrade is degraded by crashes of bound apps"
+
+	notifyURLDefault     = ""
+	notifyURLFlag        = "notify-url"
+	notifyURLDescription = "--notify-url <url>. POST JSON events to the URL when an upgrade or plan migration starts, when a service instance fails, and when the run is aborted or finishes"
+
+	notifyTimeoutDefault     = 10 * time.Second
+	notifyTimeoutFlag        = "notify-timeout"
+	notifyTimeoutDescription = "time to wait for the --notify-url to respond, e.g. '5s'. Failed notifications are retried. Maximum 1m, default 10s."
+	notifyTimeoutMaximum     = time.Minute
 
 	instancePollingIntervalDefault     = 10 * time.Second
 	instancePollingIntervalFlag        = "instance-polling-interval"
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
+)
+
+// parseMinVersionPolicy reads the minimum version rules from a file with one rule per line
+func parseMinVersionPolicy(path string) ([]versionchecker.Rule, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s option: %w", minVersionPolicyFlag, err)
+	}
+
+	var result []versionchecker.Rule
+	seen := make(map[string]struct{})
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r, err := versionchecker.ParseRule(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := seen[r.Selector()]; ok {
+			return nil, fmt.Errorf("more than one minimum version rule for %q", r.Selector())
+		}
+		seen[r.Selector()] = struct{}{}
+
+		result = append(result, r)
+	}
+
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no minimum version rules found in file: %s", path)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package config
+
+import "fmt"
+
+// OutputFormat determines how the progress of an upgrade or plan migration, or the results of a check, are written
+type OutputFormat string
+
+const (
+	TextOutput      OutputFormat = "text"
+	JSONLinesOutput OutputFormat = "jsonl"
+	TableOutput     OutputFormat = "table"
+	CSVOutput       OutputFormat = "csv"
+)
+
+// IsTabular is true for the formats that write one row per service instance
+func (o OutputFormat) IsTabular() bool {
+	return o == TableOutput || o == CSVOutput
+}
+
+func parseOutputFormat(value string, jsonOutput bool, action Action) (OutputFormat, error) {
+	switch OutputFormat(value) {
+	case TextOutput:
+		return TextOutput, nil
+	case JSONLinesOutput:
+		switch {
+		case action != UpgradeAction && action != MigratePlansAction:
+			return "", fmt.Errorf("the --%s %s option can only be used when upgrading service instances or migrating plans", outputFlag, value)
+		case jsonOutput:
+			return "", fmt.Errorf("the --%s %s option cannot be used with the --%s flag", outputFlag, value, jsonOutputFlag)
+		default:
+			return JSONLinesOutput, nil
+		}
+	case TableOutput, CSVOutput:
+		switch {
+		case action == CombinedChecksAction:
+			return "", fmt.Errorf("the --%s %s option cannot be used when combining checks", outputFlag, value)
+		case action != DryRunAction && action != CheckUpToDateAction && action != CheckDeactivatedPlansAction && action != CheckFailedOperationsAction && action != CheckVersionAnomaliesAction && action != MinVersionCheckAction && action != VersionConstraintAction && action != PendingAgeAction && action != InventoryAction:
+			return "", fmt.Errorf("the --%s %s option can only be used with the --%s, --%s, --%s, --%s, --%s, --%s, --%s, --%s, or --%s flags", outputFlag, value, dryRunFlag, checkUpToDateFlag, checkDeactivatedPlansFlag, checkFailedOperationsFlag, checkVersionAnomaliesFlag, minVersionRequiredFlag, versionConstraintFlag, maxPendingAgeFlag, inventoryFlag)
+		case jsonOutput:
+			return "", fmt.Errorf("the --%s %s option cannot be used with the --%s flag", outputFlag, value, jsonOutputFlag)
+		default:
+			return OutputFormat(value), nil
+		}
+	default:
+		return "", fmt.Errorf("invalid --%s option %q, must be one of: %s, %s, %s, %s", outputFlag, value, TextOutput, JSONLinesOutput, TableOutput, CSVOutput)
+	}
+}
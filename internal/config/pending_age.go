@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseMaxPendingAge reads an age, which is either a number of days such as "30d", or a duration such as "36h"
+func parseMaxPendingAge(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	var (
+		age time.Duration
+		err error
+	)
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		age = time.Duration(n) * 24 * time.Hour
+	} else {
+		age, err = time.ParseDuration(value)
+	}
+
+	switch {
+	case err != nil:
+		return 0, fmt.Errorf("error parsing %s option %q, must be a number of days such as '30d', or a duration such as '36h'", maxPendingAgeFlag, value)
+	case age <= 0:
+		return 0, fmt.Errorf("the --%s option must be greater than 0", maxPendingAgeFlag)
+	default:
+		return age, nil
+	}
+}
+
+func validatePendingSinceFile(path string, action Action, checks []Action) error {
+	if path == "" || includes(action, checks, PendingAgeAction, CheckUpToDateAction) {
+		return nil
+	}
+	return fmt.Errorf("the --%s flag can only be used with the --%s or --%s flags", pendingSinceFileFlag, maxPendingAgeFlag, checkUpToDateFlag)
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PlanMapping describes the move of service instances from one service plan to another.
+// The service offering name is optional, and is only needed when a plan name is used by
+// more than one service offering of the broker.
+type PlanMapping struct {
+	ServiceOfferingName string
+	FromPlanName        string
+	ToPlanName          string
+}
+
+func (m PlanMapping) String() string {
+	if m.ServiceOfferingName == "" {
+		return fmt.Sprintf("%s=%s", m.FromPlanName, m.ToPlanName)
+	}
+	return fmt.Sprintf("%s:%s=%s", m.ServiceOfferingName, m.FromPlanName, m.ToPlanName)
+}
+
+// parsePlanMappings reads plan mappings from either the command line value or a file.
+// Command line mappings are comma separated, whereas a file has one mapping per line.
+func parsePlanMappings(mappings, path string) ([]PlanMapping, error) {
+	switch {
+	case mappings != "":
+		return parsePlanMappingEntries(strings.Split(mappings, ","))
+	case path != "":
+		entries, err := readPlanMappingsFile(path, migratePlansFileFlag)
+		if err != nil {
+			return nil, err
+		}
+		return parsePlanMappingEntries(entries)
+	default:
+		return nil, nil
+	}
+}
+
+// parseReplacementPlans reads the preferred replacements for deactivated plans, which are plan mappings
+// in the same format as the --migrate-plans-file, so that the same file can be used for both
+func parseReplacementPlans(path string, action Action, checks []Action) ([]PlanMapping, error) {
+	switch {
+	case path == "":
+		return nil, nil
+	case !includes(action, checks, CheckDeactivatedPlansAction):
+		return nil, fmt.Errorf("the --%s flag can only be used with the --%s flag", replacementPlansFileFlag, checkDeactivatedPlansFlag)
+	}
+
+	entries, err := readPlanMappingsFile(path, replacementPlansFileFlag)
+	if err != nil {
+		return nil, err
+	}
+	return parsePlanMappingEntries(entries)
+}
+
+// readPlanMappingsFile reads a file with one plan mapping per line. Lines starting with '#' are ignored.
+func readPlanMappingsFile(path, flag string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s option: %w", flag, err)
+	}
+
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			entries = append(entries, line)
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no plan mappings found in file: %s", path)
+	}
+	return entries, nil
+}
+
+func parsePlanMappingEntries(entries []string) ([]PlanMapping, error) {
+	var result []PlanMapping
+	seen := make(map[string]struct{})
+	for _, entry := range entries {
+		m, err := parsePlanMapping(strings.TrimSpace(entry))
+		if err != nil {
+			return nil, err
+		}
+
+		key := m.ServiceOfferingName + ":" + m.FromPlanName
+		if _, ok := seen[key]; ok {
+			return nil, fmt.Errorf("plan %q is mapped more than once", m.FromPlanName)
+		}
+		seen[key] = struct{}{}
+
+		result = append(result, m)
+	}
+
+	return result, nil
+}
+
+func parsePlanMapping(entry string) (PlanMapping, error) {
+	plans, target, ok := strings.Cut(entry, "=")
+	if !ok {
+		return PlanMapping{}, fmt.Errorf("invalid plan mapping %q, expected format: [offering:]old-plan=new-plan", entry)
+	}
+
+	var m PlanMapping
+	if offering, plan, ok := strings.Cut(plans, ":"); ok {
+		m.ServiceOfferingName = strings.TrimSpace(offering)
+		m.FromPlanName = strings.TrimSpace(plan)
+	} else {
+		m.FromPlanName = strings.TrimSpace(plans)
+	}
+	m.ToPlanName = strings.TrimSpace(target)
+
+	switch {
+	case m.FromPlanName == "", m.ToPlanName == "", strings.Contains(plans, ":") && m.ServiceOfferingName == "":
+		return PlanMapping{}, fmt.Errorf("invalid plan mapping %q, expected format: [offering:]old-plan=new-plan", entry)
+	case m.FromPlanName == m.ToPlanName:
+		return PlanMapping{}, fmt.Errorf("invalid plan mapping %q, plan is mapped to itself", entry)
+	default:
+		return m, nil
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// newRunID generates a random identifier in UUID format, so that the changes made by a run of the
+// plugin can be correlated, for example in annotations, reports and notifications
+func newRunID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating run ID: %w", err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Thresholds decide whether the service instances that violate a check are enough for the check to fail.
+// The check fails when the service instances that count are more than MaxInstances, or more than MaxPercent
+// of the total. A threshold of 0 is not set, and when neither is set, the check fails for any service instance
+// that counts. The grace period only applies to the checks of upgrades that are available, which
+// are --check-up-to-date and --max-pending-age: a service instance whose upgrade became available within the
+// grace period does not count.
+type Thresholds struct {
+	MaxInstances int
+	MaxPercent   float64
+	GracePeriod  time.Duration
+}
+
+func parseThresholds(maxInstances int, maxPercent float64, gracePeriodDays int, action Action, checks []Action) (Thresholds, error) {
+	thresholds := Thresholds{
+		MaxInstances: maxInstances,
+		MaxPercent:   maxPercent,
+		GracePeriod:  time.Duration(gracePeriodDays) * 24 * time.Hour,
+	}
+
+	switch {
+	case maxInstances < 0:
+		return Thresholds{}, fmt.Errorf("the --%s option must be 0 or greater", failIfMoreThanFlag)
+	case maxPercent < 0 || maxPercent > 100:
+		return Thresholds{}, fmt.Errorf("the --%s option must be in the range of 0 to 100", failIfPercentAboveFlag)
+	case gracePeriodDays < 0:
+		return Thresholds{}, fmt.Errorf("the --%s option must be 0 or greater", gracePeriodDaysFlag)
+	case gracePeriodDays > 0 && !includes(action, checks, CheckUpToDateAction, PendingAgeAction):
+		return Thresholds{}, fmt.Errorf("the --%s flag can only be used with the --%s or --%s flags", gracePeriodDaysFlag, checkUpToDateFlag, maxPendingAgeFlag)
+	case thresholds == Thresholds{}, includes(action, checks, CheckUpToDateAction, CheckDeactivatedPlansAction, CheckFailedOperationsAction, CheckVersionAnomaliesAction, MinVersionCheckAction, VersionConstraintAction, PendingAgeAction):
+		return thresholds, nil
+	default:
+		return Thresholds{}, errors.New("the --fail-if-more-than, --fail-if-percent-above, and --grace-period-days flags can only be used with the --check-up-to-date, --check-deactivated-plans, --check-failed-operations, --check-version-anomalies, --min-version-required, --min-version-policy, --version-constraint, or --max-pending-age flags")
+	}
+}
@@ -15,14 +15,45 @@ func UsageOptions() map[string]string {
 		httpLoggingFlag:             httpLoggingDescription,
 		dryRunFlag:                  dryRunDescription,
 		minVersionRequiredFlag:      minVersionRequiredDescription,
+		minVersionPolicyFlag:        minVersionPolicyDescription,
+		versionConstraintFlag:       versionConstraintDescription,
+		failOnUnknownVersionFlag:    failOnUnknownVersionDescription,
+		versionComparisonFlag:       versionComparisonDescription,
 		checkUpToDateFlag:           checkUpToDateDescription,
+		maxPendingAgeFlag:           maxPendingAgeDescription,
+		pendingSinceFileFlag:        pendingSinceFileDescription,
 		checkDeactivatedPlansFlag:   checkDeactivatedPlansDescription,
+		replacementPlansFileFlag:    replacementPlansFileDescription,
+		failIfMoreThanFlag:          failIfMoreThanDescription,
+		failIfPercentAboveFlag:      failIfPercentAboveDescription,
+		gracePeriodDaysFlag:         gracePeriodDaysDescription,
+		checksFlag:                  checksDescription,
+		checkFailedOperationsFlag:   checkFailedOperationsDescription,
+		checkVersionAnomaliesFlag:   checkVersionAnomaliesDescription,
+		inventoryFlag:               inventoryDescription,
+		migratePlansFlag:            migratePlansDescription,
+		migratePlansFileFlag:        migratePlansFileDescription,
+		blastRadiusFlag:             blastRadiusDescription,
 		limitFlag:                   limitDescription,
 		jsonOutputFlag:              jsonOutputDescription,
+		reportFileFlag:              reportFileDescription,
+		outputFlag:                  outputDescription,
+		columnsFlag:                 columnsDescription,
+		templateFlag:                templateDescription,
+		summaryFlag:                 summaryDescription,
+		junitReportFlag:             junitReportDescription,
 		attemptsFlag:                attemptsDescription,
 		retryIntervalFlag:           retryIntervalDescription,
 		instancePollingIntervalFlag: instancePollingIntervalDescription,
 		ignoreInstanceErrorsFlag:    ignoreInstanceErrorsDescription,
+		annotateFlag:                annotateDescription,
+		preHookFlag:                 preHookDescription,
+		postHookFlag:                postHookDescription,
+		hookTimeoutFlag:             hookTimeoutDescription,
+		healthCheckWindowFlag:       healthCheckWindowDescription,
+		stopOnDegradedFlag:          stopOnDegradedDescription,
+		notifyURLFlag:               notifyURLDescription,
+		notifyTimeoutFlag:           notifyTimeoutDescription,
 	}
 }
 
@@ -3,8 +3,10 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
 	"time"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
 
 	"github.com/hashicorp/go-version"
 )
@@ -83,16 +85,207 @@ func validateMinVersionRequired(ver string) (*version.Version, error) {
 	return v, nil
 }
 
+func validateVersionConstraint(constraint string) (version.Constraints, error) {
+	if constraint == "" {
+		return nil, nil
+	}
+
+	c, err := version.NewConstraint(constraint)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing version-constraint option: %w", err)
+	}
+	return c, nil
+}
+
+func validateFailOnUnknownVersion(value bool, action Action, checks []Action) error {
+	switch {
+	case !value, includes(action, checks, MinVersionCheckAction, VersionConstraintAction):
+		return nil
+	default:
+		return fmt.Errorf("the --%s flag can only be used with the --%s, --%s, or --%s flags", failOnUnknownVersionFlag, minVersionRequiredFlag, minVersionPolicyFlag, versionConstraintFlag)
+	}
+}
+
+func parseVersionComparison(value string, action Action, checks []Action) (versionchecker.Comparison, error) {
+	comparison, err := versionchecker.ParseComparison(value)
+	switch {
+	case err != nil:
+		return "", fmt.Errorf("invalid --%s option %q, must be one of: %s, %s, %s", versionComparisonFlag, value, versionchecker.SemverComparison, versionchecker.IgnoreSuffixComparison, versionchecker.PostReleaseComparison)
+	case comparison == versionchecker.SemverComparison:
+		return comparison, nil
+	case !includes(action, checks, MinVersionCheckAction, VersionConstraintAction, CheckVersionAnomaliesAction, InventoryAction):
+		return "", fmt.Errorf("the --%s flag can only be used with the --%s, --%s, --%s, --%s, or --%s flags", versionComparisonFlag, minVersionRequiredFlag, minVersionPolicyFlag, versionConstraintFlag, checkVersionAnomaliesFlag, inventoryFlag)
+	default:
+		return comparison, nil
+	}
+}
+
 func validateJSONFlag(value bool, action Action) error {
 	if !value {
 		return nil
 	}
 
 	switch action {
-	case MinVersionCheckAction, CheckDeactivatedPlansAction, CheckUpToDateAction, DryRunAction:
+	case UpgradeAction, MinVersionCheckAction, VersionConstraintAction, PendingAgeAction, CheckDeactivatedPlansAction, CheckFailedOperationsAction, CheckVersionAnomaliesAction, CheckUpToDateAction, DryRunAction, InventoryAction, CombinedChecksAction:
 		return nil
 	default:
-		return fmt.Errorf("the --%s flag can only be used with the --%s, --%s, --%s, or --%s flags", jsonOutputFlag, minVersionRequiredFlag, checkDeactivatedPlansFlag, checkUpToDateFlag, dryRunFlag)
+		return fmt.Errorf("the --%s flag can only be used when upgrading, or with the --%s, --%s, --%s, --%s, --%s, --%s, --%s, --%s, or --%s flags", jsonOutputFlag, minVersionRequiredFlag, versionConstraintFlag, maxPendingAgeFlag, checkDeactivatedPlansFlag, checkFailedOperationsFlag, checkVersionAnomaliesFlag, checkUpToDateFlag, dryRunFlag, inventoryFlag)
+	}
+}
+
+func validateReportFile(path string, jsonOutput bool, action Action) error {
+	switch {
+	case path == "":
+		return nil
+	case action != UpgradeAction:
+		return fmt.Errorf("the --%s flag can only be used when upgrading service instances", reportFileFlag)
+	case jsonOutput:
+		return fmt.Errorf("the --%s flag cannot be used with the --%s flag", reportFileFlag, jsonOutputFlag)
+	default:
+		return nil
+	}
+}
+
+func validateAnnotateFlag(value bool, action Action) error {
+	if !value {
+		return nil
+	}
+
+	if action != UpgradeAction {
+		return fmt.Errorf("the --%s flag can only be used when upgrading service instances", annotateFlag)
+	}
+	return nil
+}
+
+func validateJUnitReport(path string, action Action) error {
+	if path == "" {
+		return nil
+	}
+
+	switch action {
+	case UpgradeAction, CheckUpToDateAction, CheckDeactivatedPlansAction, CheckFailedOperationsAction, CheckVersionAnomaliesAction, MinVersionCheckAction, VersionConstraintAction, PendingAgeAction, CombinedChecksAction:
+		return nil
+	default:
+		return fmt.Errorf("the --%s flag can only be used when upgrading, or with the --%s, --%s, --%s, --%s, --%s, --%s, or --%s flags", junitReportFlag, checkUpToDateFlag, checkDeactivatedPlansFlag, checkFailedOperationsFlag, checkVersionAnomaliesFlag, minVersionRequiredFlag, versionConstraintFlag, maxPendingAgeFlag)
+	}
+}
+
+func validateTemplate(path string, jsonOutput bool, output OutputFormat, action Action) error {
+	switch {
+	case path == "":
+		return nil
+	case action == CombinedChecksAction:
+		return fmt.Errorf("the --%s flag cannot be used when combining checks", templateFlag)
+	case action != DryRunAction && action != CheckUpToDateAction && action != CheckDeactivatedPlansAction && action != MinVersionCheckAction && action != VersionConstraintAction && action != InventoryAction:
+		return fmt.Errorf("the --%s flag can only be used with the --%s, --%s, --%s, --%s, --%s, or --%s flags", templateFlag, dryRunFlag, checkUpToDateFlag, checkDeactivatedPlansFlag, minVersionRequiredFlag, versionConstraintFlag, inventoryFlag)
+	case jsonOutput:
+		return fmt.Errorf("the --%s flag cannot be used with the --%s flag", templateFlag, jsonOutputFlag)
+	case output != TextOutput:
+		return fmt.Errorf("the --%s flag cannot be used with the --%s %s option", templateFlag, outputFlag, output)
+	default:
+		return nil
+	}
+}
+
+func validateSummary(summary bool, templateFile string, output OutputFormat, action Action) error {
+	switch {
+	case !summary:
+		return nil
+	case action == CombinedChecksAction:
+		return fmt.Errorf("the --%s flag cannot be used when combining checks", summaryFlag)
+	case action == MigratePlansAction, action == InventoryAction:
+		return fmt.Errorf("the --%s flag can only be used when upgrading, or with the --%s, --%s, --%s, --%s, --%s, --%s, --%s, or --%s flags", summaryFlag, dryRunFlag, checkUpToDateFlag, checkDeactivatedPlansFlag, checkFailedOperationsFlag, checkVersionAnomaliesFlag, minVersionRequiredFlag, versionConstraintFlag, maxPendingAgeFlag)
+	case templateFile != "":
+		return fmt.Errorf("the --%s flag cannot be used with the --%s flag", summaryFlag, templateFlag)
+	case output.IsTabular():
+		return fmt.Errorf("the --%s flag cannot be used with the --%s %s option", summaryFlag, outputFlag, output)
+	default:
+		return nil
+	}
+}
+
+func validateBlastRadius(value bool, templateFile string, output OutputFormat, action Action) error {
+	switch {
+	case !value:
+		return nil
+	case action != UpgradeAction && action != DryRunAction:
+		return fmt.Errorf("the --%s flag can only be used when upgrading, or with the --%s flag", blastRadiusFlag, dryRunFlag)
+	case action == UpgradeAction:
+		return nil
+	case templateFile != "":
+		return fmt.Errorf("the --%s flag cannot be used with the --%s flag", blastRadiusFlag, templateFlag)
+	case output.IsTabular():
+		return fmt.Errorf("the --%s flag cannot be used with the --%s %s option", blastRadiusFlag, outputFlag, output)
+	default:
+		return nil
+	}
+}
+
+func validateHookFlags(preHook, postHook string, action Action) error {
+	if action == UpgradeAction {
+		return nil
+	}
+
+	switch {
+	case preHook != "":
+		return fmt.Errorf("the --%s flag can only be used when upgrading service instances", preHookFlag)
+	case postHook != "":
+		return fmt.Errorf("the --%s flag can only be used when upgrading service instances", postHookFlag)
+	default:
+		return nil
+	}
+}
+
+func validateHookTimeout(timeout time.Duration) error {
+	switch {
+	case timeout > hookTimeoutMaximum:
+		return fmt.Errorf("hook timeout must be less than or equal to %s", hookTimeoutMaximum)
+	case timeout <= 0:
+		return errors.New("hook timeout must be greater than 0")
+	default:
+		return nil
+	}
+}
+
+func validateHealthCheck(window time.Duration, stopOnDegraded bool, action Action) error {
+	switch {
+	case window < 0:
+		return errors.New("health check window must be greater or equal to 0")
+	case window > healthCheckWindowMaximum:
+		return fmt.Errorf("health check window must be less than or equal to %s", healthCheckWindowMaximum)
+	case window > 0 && action != UpgradeAction:
+		return fmt.Errorf("the --%s flag can only be used when upgrading service instances", healthCheckWindowFlag)
+	case stopOnDegraded && window == 0:
+		return fmt.Errorf("the --%s flag can only be used with the --%s flag", stopOnDegradedFlag, healthCheckWindowFlag)
+	default:
+		return nil
+	}
+}
+
+func validateNotifyURL(value string, action Action) error {
+	if value == "" {
+		return nil
+	}
+
+	if action != UpgradeAction && action != MigratePlansAction {
+		return fmt.Errorf("the --%s flag can only be used when upgrading service instances or migrating plans", notifyURLFlag)
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("the --%s flag must be an http or https URL", notifyURLFlag)
+	}
+	return nil
+}
+
+func validateNotifyTimeout(timeout time.Duration) error {
+	switch {
+	case timeout > notifyTimeoutMaximum:
+		return fmt.Errorf("notify timeout must be less than or equal to %s", notifyTimeoutMaximum)
+	case timeout <= 0:
+		return errors.New("notify timeout must be greater than 0")
+	default:
+		return nil
 	}
 }
 
@@ -0,0 +1,29 @@
+package fakecapi
+
+import (
+	"net/http"
+	"slices"
+)
+
+// listAppProcessesHandler returns a single "web" process for any app
+func (f *FakeCAPI) listAppProcessesHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"resources": [{"type": "web"}]}`))
+	}
+}
+
+// getAppProcessStatsHandler returns one process instance, which has crashed when the app is one of the
+// CrashedApps of a service instance that has been upgraded, and is running otherwise
+func (f *FakeCAPI) getAppProcessStatsHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state := "RUNNING"
+		for _, instance := range f.instances {
+			upgraded := instance.UpdateCount > 0 && instance.LastOperationState == "succeeded"
+			if upgraded && slices.ContainsFunc(instance.CrashedApps, func(name string) bool { return stableGUID(name) == r.PathValue("guid") }) {
+				state = "CRASHED"
+			}
+		}
+
+		w.Write([]byte(`{"resources": [{"type": "` + r.PathValue("type") + `", "index": 0, "state": "` + state + `"}]}`))
+	}
+}
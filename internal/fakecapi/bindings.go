@@ -0,0 +1,86 @@
+package fakecapi
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"code.cloudfoundry.org/jsonry"
+)
+
+type ServiceCredentialBinding struct {
+	GUID                string `json:"guid"`
+	Type                string `json:"type"`
+	AppGUID             string `jsonry:"relationships.app.data.guid"`
+	ServiceInstanceGUID string `jsonry:"relationships.service_instance.data.guid"`
+}
+
+type App struct {
+	Name string `json:"name"`
+	GUID string `json:"guid"`
+}
+
+// listServiceCredentialBindingsHandler returns an app binding for each of the BoundApps of the service instances
+func (f *FakeCAPI) listServiceCredentialBindingsHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			instanceGUIDs []string
+			includeApps   bool
+		)
+		for k := range r.URL.Query() {
+			v := r.URL.Query().Get(k)
+			switch {
+			case k == "per_page": // ignore
+			case k == "include" && v == "app":
+				includeApps = true
+			case k == "service_instance_guids":
+				instanceGUIDs = strings.Split(v, ",")
+			default:
+				http.Error(w, fmt.Sprintf("unknown query filter %q with value %q", k, v), http.StatusBadRequest)
+				return
+			}
+		}
+
+		var (
+			bindings []ServiceCredentialBinding
+			apps     []App
+		)
+		for _, guid := range instanceGUIDs {
+			instance, ok := f.instances[guid]
+			if !ok {
+				continue
+			}
+			for _, name := range instance.BoundApps {
+				app := App{Name: name, GUID: stableGUID(name)}
+				bindings = append(bindings, ServiceCredentialBinding{
+					GUID:                stableGUID(instance.GUID + name),
+					Type:                "app",
+					AppGUID:             app.GUID,
+					ServiceInstanceGUID: instance.GUID,
+				})
+				if includeApps && !slices.Contains(apps, app) {
+					apps = append(apps, app)
+				}
+			}
+		}
+
+		payload, err := jsonry.Marshal(struct {
+			Resources    []ServiceCredentialBinding `json:"resources"`
+			IncludedApps []App                      `jsonry:"included.apps,omitempty"`
+		}{Resources: bindings, IncludedApps: apps})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Write(payload)
+	}
+}
+
+// listServiceRouteBindingsHandler returns no route bindings, as route services are not faked
+func (f *FakeCAPI) listServiceRouteBindingsHandler() func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"resources": []}`))
+	}
+}
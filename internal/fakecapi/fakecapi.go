@@ -86,6 +86,10 @@ func (f *FakeCAPI) capiMux() *http.ServeMux {
 	capi.HandleFunc("GET /v3/service_instances", f.listServiceInstancesHandler())
 	capi.HandleFunc("GET /v3/service_instances/{guid}", f.getServiceInstanceHandler())
 	capi.HandleFunc("PATCH /v3/service_instances/{guid}", f.updateServiceInstanceHandler())
+	capi.HandleFunc("GET /v3/service_credential_bindings", f.listServiceCredentialBindingsHandler())
+	capi.HandleFunc("GET /v3/service_route_bindings", f.listServiceRouteBindingsHandler())
+	capi.HandleFunc("GET /v3/apps/{guid}/processes", f.listAppProcessesHandler())
+	capi.HandleFunc("GET /v3/apps/{guid}/processes/{type}/stats", f.getAppProcessStatsHandler())
 
 	capi.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
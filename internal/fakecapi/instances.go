@@ -45,22 +45,27 @@ func WithServiceInstances(instances ...ServiceInstance) func(*FakeCAPI, ServiceP
 }
 
 type ServiceInstance struct {
-	Name                     string        `json:"name"`
-	GUID                     string        `json:"guid"`
-	ServicePlanGUID          string        `jsonry:"relationships.service_plan.data.guid"`
-	SpaceGUID                string        `jsonry:"relationships.space.data.guid"`
-	ServicePlanName          string        `json:"-"`
-	ServiceOfferingGUID      string        `json:"-"`
-	ServiceOfferingName      string        `json:"-"`
-	Version                  string        `jsonry:"maintenance_info.version"`
-	UpgradeAvailable         bool          `json:"upgrade_available"`
-	LastOperationType        string        `jsonry:"last_operation.type"`
-	LastOperationState       string        `jsonry:"last_operation.state"`
-	LastOperationDescription string        `jsonry:"last_operation.description"`
-	UpdateTime               time.Duration `json:"-"`
-	UpdateCount              int           `json:"-"`
-	FailTimes                int           `json:"-"`
-	Callback                 func()        `json:"-"`
+	Name                     string            `json:"name"`
+	GUID                     string            `json:"guid"`
+	ServicePlanGUID          string            `jsonry:"relationships.service_plan.data.guid"`
+	SpaceGUID                string            `jsonry:"relationships.space.data.guid"`
+	ServicePlanName          string            `json:"-"`
+	ServiceOfferingGUID      string            `json:"-"`
+	ServiceOfferingName      string            `json:"-"`
+	Version                  string            `jsonry:"maintenance_info.version"`
+	UpgradeAvailable         bool              `json:"upgrade_available"`
+	LastOperationType        string            `jsonry:"last_operation.type"`
+	LastOperationState       string            `jsonry:"last_operation.state"`
+	LastOperationDescription string            `jsonry:"last_operation.description"`
+	LastOperationUpdatedAt   time.Time         `jsonry:"last_operation.updated_at"`
+	Annotations              map[string]string `jsonry:"metadata.annotations,omitempty"`
+	BoundApps                []string          `json:"-"`
+	CrashedApps              []string          `json:"-"`
+	UpdateTime               time.Duration     `json:"-"`
+	UpdateCount              int               `json:"-"`
+	FailTimes                int               `json:"-"`
+	KeepVersion              bool              `json:"-"`
+	Callback                 func()            `json:"-"`
 }
 
 type Space struct {
@@ -168,13 +173,47 @@ func (f *FakeCAPI) updateServiceInstanceHandler() func(w http.ResponseWriter, r
 		}
 
 		var receiver struct {
-			Version string `jsonry:"maintenance_info.version"`
+			Version     string            `jsonry:"maintenance_info.version"`
+			PlanGUID    string            `jsonry:"relationships.service_plan.data.guid"`
+			Annotations map[string]string `jsonry:"metadata.annotations"`
 		}
 		if err := jsonry.Unmarshal(data, &receiver); err != nil {
 			http.Error(w, fmt.Sprintf("error parsing body: %s", err), http.StatusBadRequest)
 		}
 
-		if planVersion := f.plans[instance.ServicePlanGUID].Version; receiver.Version != planVersion {
+		// Cloud Controller reserves the cloudfoundry.org domain for its own metadata
+		for key := range receiver.Annotations {
+			if prefix, _, ok := strings.Cut(key, "/"); ok && (prefix == "cloudfoundry.org" || strings.HasSuffix(prefix, ".cloudfoundry.org")) {
+				http.Error(w, fmt.Sprintf("metadata key %q uses the reserved cloudfoundry.org domain", key), http.StatusUnprocessableEntity)
+				return
+			}
+		}
+
+		// Updating only the metadata is synchronous, and does not start an operation
+		if receiver.Annotations != nil && receiver.Version == "" && receiver.PlanGUID == "" {
+			f.lock.Lock()
+			defer f.lock.Unlock()
+			if instance.Annotations == nil {
+				instance.Annotations = make(map[string]string)
+			}
+			maps.Copy(instance.Annotations, receiver.Annotations)
+
+			response, err := jsonry.Marshal(instance)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error marshaling service instance: %s", err), http.StatusInternalServerError)
+			}
+			w.Write(response)
+			return
+		}
+
+		switch plan, ok := f.plans[receiver.PlanGUID]; {
+		case receiver.PlanGUID != "" && !ok:
+			http.Error(w, fmt.Sprintf("plan with guid %q not found", receiver.PlanGUID), http.StatusUnprocessableEntity)
+			return
+		case receiver.PlanGUID != "":
+			instance.ServicePlanGUID = plan.GUID
+			instance.ServicePlanName = plan.Name
+		case receiver.Version != f.plans[instance.ServicePlanGUID].Version:
 			http.Error(w, "plan version %q does not match requested version %q", http.StatusBadRequest)
 			return
 		}
@@ -204,6 +243,12 @@ func (f *FakeCAPI) updateServiceInstanceHandler() func(w http.ResponseWriter, r
 				return
 			}
 
+			// An upgrade moves the instance to the requested version, unless the test setup keeps it at the old version
+			if receiver.Version != "" && !instance.KeepVersion {
+				instance.Version = receiver.Version
+				instance.UpgradeAvailable = false
+			}
+
 			instance.LastOperationState = "succeeded"
 			instance.LastOperationDescription = "succeeded as requested by test setup"
 		}()
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"slices"
 	"strings"
+	"time"
 	"upgrade-all-services-cli-plugin/internal/slicex"
 
 	"code.cloudfoundry.org/jsonry"
@@ -31,12 +32,13 @@ func WithServicePlan(plan ServicePlan, opts ...func(*FakeCAPI, ServicePlan)) fun
 }
 
 type ServicePlan struct {
-	Name                string `json:"name"`
-	GUID                string `json:"guid"`
-	Version             string `jsonry:"maintenance_info.version"`
-	Available           bool   `json:"available"`
-	ServiceOfferingName string `json:"-"`
-	ServiceOfferingGUID string `jsonry:"relationships.service_offering.data.guid"`
+	Name                string    `json:"name"`
+	GUID                string    `json:"guid"`
+	Version             string    `jsonry:"maintenance_info.version"`
+	Available           bool      `json:"available"`
+	ServiceOfferingName string    `json:"-"`
+	ServiceOfferingGUID string    `jsonry:"relationships.service_offering.data.guid"`
+	UpdatedAt           time.Time `json:"updated_at"`
 }
 
 func (f *FakeCAPI) listServicePlansHandler() func(w http.ResponseWriter, r *http.Request) {
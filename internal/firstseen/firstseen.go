@@ -0,0 +1,60 @@
+// Package firstseen records when each service instance was first seen with an upgrade pending,
+// so that the age of a pending upgrade is known across runs of the plugin
+package firstseen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// Record is the time that each service instance was first seen with an upgrade pending, keyed by GUID
+type Record map[string]time.Time
+
+// Load reads a record from the file. A file that does not exist yet is an empty record.
+func Load(path string) (Record, error) {
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return Record{}, nil
+	case err != nil:
+		return nil, fmt.Errorf("error reading pending since file: %w", err)
+	}
+
+	var r Record
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("error parsing pending since file %q: %w", path, err)
+	}
+	return r, nil
+}
+
+// Observe returns a record of the service instances that have an upgrade pending now. Service instances
+// that were already pending keep the time they were first seen, and those that are no longer pending are
+// dropped, so that a service instance that falls behind again starts from the time it is next seen.
+func (r Record) Observe(guids []string, now time.Time) Record {
+	observed := make(Record, len(guids))
+	for _, guid := range guids {
+		if t, ok := r[guid]; ok {
+			observed[guid] = t
+		} else {
+			observed[guid] = now
+		}
+	}
+	return observed
+}
+
+// Save writes the record to the file
+func (r Record) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing pending since file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package firstseen_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestFirstSeen(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "First Seen Suite")
+}
@@ -0,0 +1,57 @@
+package firstseen_test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"upgrade-all-services-cli-plugin/internal/firstseen"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Record", func() {
+	var (
+		path    string
+		earlier time.Time
+		now     time.Time
+	)
+
+	BeforeEach(func() {
+		path = filepath.Join(GinkgoT().TempDir(), "pending-since.json")
+		earlier = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+		now = time.Date(2024, time.April, 1, 12, 0, 0, 0, time.UTC)
+	})
+
+	It("is empty when the file does not exist", func() {
+		r, err := firstseen.Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(r).To(BeEmpty())
+	})
+
+	It("keeps the time that pending service instances were first seen, and drops the others", func() {
+		r := firstseen.Record{"still-pending-guid": earlier, "upgraded-guid": earlier}
+
+		Expect(r.Observe([]string{"still-pending-guid", "new-guid"}, now)).To(Equal(firstseen.Record{
+			"still-pending-guid": earlier,
+			"new-guid":           now,
+		}))
+	})
+
+	It("saves and loads the record", func() {
+		r := firstseen.Record{"fake-guid": earlier}
+		Expect(r.Save(path)).To(Succeed())
+
+		loaded, err := firstseen.Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded).To(HaveKeyWithValue("fake-guid", BeTemporally("==", earlier)))
+	})
+
+	It("fails when the file is not valid", func() {
+		Expect(os.WriteFile(path, []byte("not json"), 0o644)).To(Succeed())
+
+		_, err := firstseen.Load(path)
+		Expect(err).To(MatchError(ContainSubstring("error parsing pending since file")))
+	})
+})
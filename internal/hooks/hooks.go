@@ -0,0 +1,69 @@
+// Package hooks runs user-supplied commands before and after the upgrade of a service instance
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+)
+
+// Hook is a shell command that is run for a service instance. Details of the service instance
+// are passed to the command as environment variables.
+type Hook struct {
+	Command string
+	Timeout time.Duration
+}
+
+func New(command string, timeout time.Duration) Hook {
+	return Hook{
+		Command: command,
+		Timeout: timeout,
+	}
+}
+
+// Run runs the command for the service instance, and returns the combined stdout and stderr output.
+// An error is returned if the command exits with a non-zero code or does not complete within the timeout.
+func (h Hook) Run(instance ccapi.ServiceInstance) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout)
+	defer cancel()
+
+	cmd := shell(ctx, h.Command)
+	cmd.Env = append(os.Environ(), Environment(instance)...)
+	// Ensures that we don't wait forever for output from processes started by the command, should they outlive it
+	cmd.WaitDelay = time.Second
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	err := cmd.Run()
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return output.String(), fmt.Errorf("timed out after %s", h.Timeout)
+	case err != nil:
+		return output.String(), err
+	default:
+		return output.String(), nil
+	}
+}
+
+// Environment returns the environment variables that describe the service instance
+func Environment(instance ccapi.ServiceInstance) []string {
+	return []string{
+		"UAS_INSTANCE_GUID=" + instance.GUID,
+		"UAS_INSTANCE_NAME=" + instance.Name,
+		"UAS_ORG_GUID=" + instance.OrganizationGUID,
+		"UAS_ORG_NAME=" + instance.OrganizationName,
+		"UAS_SPACE_GUID=" + instance.SpaceGUID,
+		"UAS_SPACE_NAME=" + instance.SpaceName,
+		"UAS_OFFERING_NAME=" + instance.ServiceOfferingName,
+		"UAS_PLAN_GUID=" + instance.ServicePlanGUID,
+		"UAS_PLAN_NAME=" + instance.ServicePlanName,
+		"UAS_CURRENT_VERSION=" + instance.MaintenanceInfoVersion,
+		"UAS_TARGET_VERSION=" + instance.ServicePlanMaintenanceInfoVersion,
+	}
+}
@@ -0,0 +1,59 @@
+package hooks_test
+
+import (
+	"path/filepath"
+	"time"
+
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/hooks"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Hook", func() {
+	instance := ccapi.ServiceInstance{
+		GUID:                              "fake-instance-guid",
+		Name:                              "fake-instance-name",
+		OrganizationName:                  "fake-org-name",
+		SpaceName:                         "fake-space-name",
+		ServicePlanName:                   "fake-plan-name",
+		MaintenanceInfoVersion:            "1.2.2",
+		ServicePlanMaintenanceInfoVersion: "1.2.3",
+	}
+
+	It("runs the command with details of the service instance in the environment", func() {
+		output, err := hooks.New(`echo "$UAS_INSTANCE_NAME $UAS_INSTANCE_GUID $UAS_ORG_NAME/$UAS_SPACE_NAME $UAS_PLAN_NAME $UAS_CURRENT_VERSION->$UAS_TARGET_VERSION"`, time.Minute).Run(instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("fake-instance-name fake-instance-guid fake-org-name/fake-space-name fake-plan-name 1.2.2->1.2.3\n"))
+	})
+
+	It("captures stderr as well as stdout", func() {
+		output, err := hooks.New(`echo out; echo err >&2`, time.Minute).Run(instance)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("out\nerr\n"))
+	})
+
+	It("returns an error when the command fails", func() {
+		output, err := hooks.New(`echo "backup failed"; exit 3`, time.Minute).Run(instance)
+		Expect(err).To(MatchError("exit status 3"))
+		Expect(output).To(Equal("backup failed\n"))
+	})
+
+	It("returns an error when the command takes too long", func() {
+		start := time.Now()
+		_, err := hooks.New(`sleep 10`, 100*time.Millisecond).Run(instance)
+		Expect(err).To(MatchError("timed out after 100ms"))
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	})
+
+	It("stops the processes started by the command when it takes too long", func() {
+		marker := filepath.Join(GinkgoT().TempDir(), "marker")
+		start := time.Now()
+		_, err := hooks.New(`(sleep 1; touch "`+marker+`") & sleep 10`, 100*time.Millisecond).Run(instance)
+		Expect(err).To(MatchError("timed out after 100ms"))
+		Expect(time.Since(start)).To(BeNumerically("<", 900*time.Millisecond))
+
+		Consistently(func() string { return marker }).WithTimeout(1500 * time.Millisecond).ShouldNot(BeAnExistingFile())
+	})
+})
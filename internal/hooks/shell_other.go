@@ -0,0 +1,13 @@
+//go:build !unix
+
+package hooks
+
+import (
+	"context"
+	"os/exec"
+)
+
+// shell runs the command with the Windows command interpreter
+func shell(ctx context.Context, command string) *exec.Cmd {
+	return exec.CommandContext(ctx, "cmd", "/C", command)
+}
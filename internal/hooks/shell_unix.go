@@ -0,0 +1,20 @@
+//go:build unix
+
+package hooks
+
+import (
+	"context"
+	"os/exec"
+	"syscall"
+)
+
+// shell runs the command in its own process group. When the timeout is reached the whole group is killed, rather
+// than only the shell, so that processes started by the command do not carry on running and hold its output open.
+func shell(ctx context.Context, command string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd
+}
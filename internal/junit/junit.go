@@ -0,0 +1,139 @@
+// Package junit writes JUnit XML reports, which are rendered by CI systems such as Concourse and GitHub Actions.
+// Each service instance is reported as a test case.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+)
+
+type TestSuite struct {
+	Name     string     `xml:"name,attr"`
+	Tests    int        `xml:"tests,attr"`
+	Failures int        `xml:"failures,attr"`
+	Skipped  int        `xml:"skipped,attr"`
+	Time     float64    `xml:"time,attr"`
+	Cases    []TestCase `xml:"testcase"`
+}
+
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      float64  `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+	Skipped   *Skipped `xml:"skipped,omitempty"`
+	SystemOut *Output  `xml:"system-out,omitempty"`
+
+	// subject identifies the service instance in the failure message
+	subject string
+}
+
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type Output struct {
+	Text string `xml:",cdata"`
+}
+
+type Skipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// NewSuite creates a test suite, counting the test cases, failures and skips
+func NewSuite(name string, cases []TestCase) TestSuite {
+	s := TestSuite{Name: name, Tests: len(cases), Cases: cases}
+	for _, c := range cases {
+		s.Time += c.Time
+		switch {
+		case c.Failure != nil:
+			s.Failures++
+		case c.Skipped != nil:
+			s.Skipped++
+		}
+	}
+	return s
+}
+
+// InstanceCase creates a passing test case for a service instance. The instance name is qualified
+// by the org and space, as instance names are only unique within a space.
+func InstanceCase(instance ccapi.ServiceInstance) TestCase {
+	return TestCase{
+		Name:      fmt.Sprintf("%s/%s/%s", instance.OrganizationName, instance.SpaceName, instance.Name),
+		ClassName: instance.ServiceOfferingName,
+		SystemOut: &Output{Text: InstanceDetails(instance)},
+		subject: fmt.Sprintf("service instance %q (org %q, space %q, offering %q, plan %q)",
+			instance.Name, instance.OrganizationName, instance.SpaceName, instance.ServiceOfferingName, instance.ServicePlanName),
+	}
+}
+
+// WithFailure marks the test case as failed. The details of the service instance move into the failure,
+// which is where CI systems display them. Many only show the failure message, so it starts by identifying
+// the service instance.
+func (c TestCase) WithFailure(kind, message string) TestCase {
+	if c.subject != "" {
+		message = fmt.Sprintf("%s: %s", c.subject, message)
+	}
+	c.Failure = &Failure{Message: message, Type: kind}
+	if c.SystemOut != nil {
+		c.Failure.Text = c.SystemOut.Text
+		c.SystemOut = nil
+	}
+	return c
+}
+
+func (c TestCase) WithSkipped(message string) TestCase {
+	c.Skipped = &Skipped{Message: message}
+	return c
+}
+
+// InstanceDetails describes the service instance, in the same style as the text output of the checks
+func InstanceDetails(instance ccapi.ServiceInstance) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Service Instance Name: %q\n", instance.Name)
+	fmt.Fprintf(&b, "Service Instance GUID: %q\n", instance.GUID)
+	fmt.Fprintf(&b, "Service Instance Version: %q\n", instance.MaintenanceInfoVersion)
+	fmt.Fprintf(&b, "Service Plan Name: %q\n", instance.ServicePlanName)
+	fmt.Fprintf(&b, "Service Plan Version: %q\n", instance.ServicePlanMaintenanceInfoVersion)
+	fmt.Fprintf(&b, "Service Offering Name: %q\n", instance.ServiceOfferingName)
+	fmt.Fprintf(&b, "Space Name: %q\n", instance.SpaceName)
+	fmt.Fprintf(&b, "Organization Name: %q\n", instance.OrganizationName)
+	return b.String()
+}
+
+// Write writes the test suites as a JUnit XML document
+func Write(w io.Writer, suites ...TestSuite) error {
+	doc := struct {
+		XMLName  xml.Name    `xml:"testsuites"`
+		Tests    int         `xml:"tests,attr"`
+		Failures int         `xml:"failures,attr"`
+		Skipped  int         `xml:"skipped,attr"`
+		Time     float64     `xml:"time,attr"`
+		Suites   []TestSuite `xml:"testsuite"`
+	}{Suites: suites}
+
+	for _, s := range suites {
+		doc.Tests += s.Tests
+		doc.Failures += s.Failures
+		doc.Skipped += s.Skipped
+		doc.Time += s.Time
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
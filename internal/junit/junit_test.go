@@ -0,0 +1,79 @@
+package junit_test
+
+import (
+	"bytes"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/junit"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JUnit", func() {
+	instance := ccapi.ServiceInstance{
+		GUID:                              "fake-guid",
+		Name:                              "fake-name",
+		MaintenanceInfoVersion:            "1.2.2",
+		ServicePlanName:                   "fake-plan",
+		ServicePlanMaintenanceInfoVersion: "1.2.3",
+		ServiceOfferingName:               "fake-offering",
+		SpaceName:                         "fake-space",
+		OrganizationName:                  "fake-org",
+	}
+
+	It("writes test suites", func() {
+		passed := junit.InstanceCase(instance)
+		passed.Time = 1.5
+		failed := junit.InstanceCase(instance).WithFailure("UpgradeAvailable", "upgrade available from version 1.2.2 to 1.2.3")
+		skipped := junit.InstanceCase(instance).WithSkipped("instance failed to create")
+
+		var buffer bytes.Buffer
+		Expect(junit.Write(&buffer,
+			junit.NewSuite("first", []junit.TestCase{passed, failed}),
+			junit.NewSuite("second", []junit.TestCase{skipped}),
+		)).To(Succeed())
+
+		Expect(buffer.String()).To(Equal(`<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="3" failures="1" skipped="1" time="1.5">
+  <testsuite name="first" tests="2" failures="1" skipped="0" time="1.5">
+    <testcase name="fake-org/fake-space/fake-name" classname="fake-offering" time="1.5">
+      <system-out><![CDATA[Service Instance Name: "fake-name"
+Service Instance GUID: "fake-guid"
+Service Instance Version: "1.2.2"
+Service Plan Name: "fake-plan"
+Service Plan Version: "1.2.3"
+Service Offering Name: "fake-offering"
+Space Name: "fake-space"
+Organization Name: "fake-org"
+]]></system-out>
+    </testcase>
+    <testcase name="fake-org/fake-space/fake-name" classname="fake-offering" time="0">
+      <failure message="service instance &#34;fake-name&#34; (org &#34;fake-org&#34;, space &#34;fake-space&#34;, offering &#34;fake-offering&#34;, plan &#34;fake-plan&#34;): upgrade available from version 1.2.2 to 1.2.3" type="UpgradeAvailable"><![CDATA[Service Instance Name: "fake-name"
+Service Instance GUID: "fake-guid"
+Service Instance Version: "1.2.2"
+Service Plan Name: "fake-plan"
+Service Plan Version: "1.2.3"
+Service Offering Name: "fake-offering"
+Space Name: "fake-space"
+Organization Name: "fake-org"
+]]></failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="second" tests="1" failures="0" skipped="1" time="0">
+    <testcase name="fake-org/fake-space/fake-name" classname="fake-offering" time="0">
+      <skipped message="instance failed to create"></skipped>
+      <system-out><![CDATA[Service Instance Name: "fake-name"
+Service Instance GUID: "fake-guid"
+Service Instance Version: "1.2.2"
+Service Plan Name: "fake-plan"
+Service Plan Version: "1.2.3"
+Service Offering Name: "fake-offering"
+Space Name: "fake-space"
+Organization Name: "fake-org"
+]]></system-out>
+    </testcase>
+  </testsuite>
+</testsuites>
+`))
+	})
+})
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+)
+
+// EventKind identifies what happened. The values are used in JSON Lines output.
+type EventKind string
+
+const (
+	EventMessage                   EventKind = "message"
+	EventInstanceSkipped           EventKind = "instance_skipped"
+	EventUpgradeStarting           EventKind = "upgrade_starting"
+	EventUpgradeSucceeded          EventKind = "upgrade_succeeded"
+	EventUpgradeFailed             EventKind = "upgrade_failed"
+	EventPreHookFailed             EventKind = "pre_hook_failed"
+	EventPostHookFailed            EventKind = "post_hook_failed"
+	EventInstanceBindings          EventKind = "instance_bindings"
+	EventUpgradeNotAtTargetVersion EventKind = "upgrade_not_at_target_version"
+	EventUpgradeDegraded           EventKind = "upgrade_degraded"
+	EventInitialTotals             EventKind = "initial_totals"
+	EventProgress                  EventKind = "progress"
+	EventFinalTotals               EventKind = "final_totals"
+)
+
+// Operation is what is being done to each service instance. The values are used in JSON Lines output.
+type Operation string
+
+const (
+	OperationUpgrade       Operation = "upgrade"
+	OperationPlanMigration Operation = "plan_migration"
+)
+
+// Event is emitted by the Logger to each of its sinks. Which fields are set depends on the kind of event.
+type Event struct {
+	Kind      EventKind
+	Operation Operation
+	Time      time.Time
+	Message   string
+	Instance  *ccapi.ServiceInstance
+	Attempt   int
+	Of        int
+	Duration  time.Duration
+	Err       error
+	Totals    Totals
+
+	// Bindings is only set for EventInstanceBindings
+	Bindings *ccapi.Bindings
+
+	// Failures, HookFailures, NotAtTargetVersion and Degraded are only set for EventFinalTotals
+	Failures           []Failure
+	HookFailures       []Failure
+	NotAtTargetVersion []Failure
+	Degraded           []Failure
+}
+
+// Failure records a failed attempt to upgrade, or a failed hook
+type Failure struct {
+	Instance    ccapi.ServiceInstance
+	Err         error
+	Attempt, Of int
+}
+
+// Sink consumes the events emitted by the Logger. Events are delivered one at a time, in order.
+type Sink interface {
+	Handle(Event)
+}
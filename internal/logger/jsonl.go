@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+)
+
+// JSONLinesSink writes each event as a JSON object on a single line
+type JSONLinesSink struct {
+	encoder *json.Encoder
+	runID   string
+}
+
+func NewJSONLinesSink(out io.Writer, runID string) *JSONLinesSink {
+	return &JSONLinesSink{
+		encoder: json.NewEncoder(out),
+		runID:   runID,
+	}
+}
+
+func (j *JSONLinesSink) Handle(e Event) {
+	line := jsonLine{
+		Timestamp: e.Time.UTC().Format(time.RFC3339Nano),
+		RunID:     j.runID,
+		Event:     e.Kind,
+		Operation: e.Operation,
+		Message:   e.Message,
+		Attempt:   e.Attempt,
+		Attempts:  e.Of,
+	}
+
+	if e.Instance != nil {
+		line.Instance = newJSONLineInstance(*e.Instance)
+	}
+	if e.Err != nil {
+		line.Error = e.Err.Error()
+	}
+
+	switch e.Kind {
+	case EventUpgradeSucceeded, EventUpgradeFailed:
+		d := e.Duration.Seconds()
+		line.DurationSeconds = &d
+	case EventInstanceBindings:
+		line.Bindings = newJSONLineBindings(*e.Bindings)
+	case EventInitialTotals, EventProgress, EventFinalTotals:
+		line.Totals = &jsonLineTotals{
+			Total:              e.Totals.Total,
+			Upgradable:         e.Totals.Upgradable,
+			Skipped:            e.Totals.Skipped,
+			Succeeded:          e.Totals.Succeeded,
+			Failed:             e.Totals.Failed,
+			PreHookFailed:      e.Totals.PreHookFailed,
+			PostHookFailed:     e.Totals.PostHookFailed,
+			NotAtTargetVersion: e.Totals.NotAtTargetVersion,
+			Degraded:           e.Totals.Degraded,
+		}
+	}
+
+	// There's nowhere sensible to report a failure to write the log
+	_ = j.encoder.Encode(line)
+}
+
+type jsonLine struct {
+	Timestamp       string            `json:"timestamp"`
+	RunID           string            `json:"run_id"`
+	Event           EventKind         `json:"event"`
+	Operation       Operation         `json:"operation"`
+	Message         string            `json:"message,omitempty"`
+	Instance        *jsonLineInstance `json:"instance,omitempty"`
+	Attempt         int               `json:"attempt,omitempty"`
+	Attempts        int               `json:"attempts,omitempty"`
+	DurationSeconds *float64          `json:"duration_seconds,omitempty"`
+	Error           string            `json:"error,omitempty"`
+	Totals          *jsonLineTotals   `json:"totals,omitempty"`
+	Bindings        *jsonLineBindings `json:"bindings,omitempty"`
+}
+
+type jsonLineBindings struct {
+	Apps          []jsonLineBoundApp `json:"apps"`
+	RouteBindings int                `json:"route_bindings"`
+	ServiceKeys   int                `json:"service_keys"`
+}
+
+type jsonLineBoundApp struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+}
+
+func newJSONLineBindings(bindings ccapi.Bindings) *jsonLineBindings {
+	apps := make([]jsonLineBoundApp, 0, len(bindings.Apps))
+	for _, a := range bindings.Apps {
+		apps = append(apps, jsonLineBoundApp{GUID: a.GUID, Name: a.Name})
+	}
+	return &jsonLineBindings{
+		Apps:          apps,
+		RouteBindings: bindings.RouteBindings,
+		ServiceKeys:   bindings.ServiceKeys,
+	}
+}
+
+type jsonLineTotals struct {
+	Total              int `json:"total"`
+	Upgradable         int `json:"upgradable"`
+	Skipped            int `json:"skipped"`
+	Succeeded          int `json:"succeeded"`
+	Failed             int `json:"failed"`
+	PreHookFailed      int `json:"pre_hook_failed"`
+	PostHookFailed     int `json:"post_hook_failed"`
+	NotAtTargetVersion int `json:"not_at_target_version"`
+	Degraded           int `json:"degraded"`
+}
+
+type jsonLineInstance struct {
+	GUID               string `json:"guid"`
+	Name               string `json:"name"`
+	Version            string `json:"version"`
+	PlanName           string `json:"plan"`
+	PlanVersion        string `json:"plan_version"`
+	OfferingName       string `json:"offering"`
+	SpaceName          string `json:"space"`
+	OrganizationName   string `json:"org"`
+	LastOperationType  string `json:"last_operation_type"`
+	LastOperationState string `json:"last_operation_state"`
+}
+
+func newJSONLineInstance(instance ccapi.ServiceInstance) *jsonLineInstance {
+	return &jsonLineInstance{
+		GUID:               instance.GUID,
+		Name:               instance.Name,
+		Version:            instance.MaintenanceInfoVersion,
+		PlanName:           instance.ServicePlanName,
+		PlanVersion:        instance.ServicePlanMaintenanceInfoVersion,
+		OfferingName:       instance.ServiceOfferingName,
+		SpaceName:          instance.SpaceName,
+		OrganizationName:   instance.OrganizationName,
+		LastOperationType:  instance.LastOperationType,
+		LastOperationState: instance.LastOperationState,
+	}
+}
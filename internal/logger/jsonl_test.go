@@ -0,0 +1,148 @@
+package logger_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/logger"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("JSON Lines output", func() {
+	var (
+		l      *logger.Logger
+		buffer *bytes.Buffer
+	)
+
+	lines := func() (result []map[string]any) {
+		for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+			var m map[string]any
+			Expect(json.Unmarshal([]byte(line), &m)).To(Succeed(), line)
+			Expect(time.Parse(time.RFC3339Nano, m["timestamp"].(string))).To(BeTemporally("~", time.Now(), time.Minute))
+			Expect(m).To(HaveKeyWithValue("run_id", "fake-run-id"))
+			delete(m, "timestamp")
+			delete(m, "run_id")
+			result = append(result, m)
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		l = logger.NewWithSinks(time.Minute, logger.NewJSONLinesSink(buffer, "fake-run-id"))
+		DeferCleanup(l.Cleanup)
+	})
+
+	It("writes one JSON object per event", func() {
+		l.Printf("discovering service instances for broker: %s", "fake-broker")
+		l.InitialTotals(3, 2)
+		l.SkippingInstance(createFailedInstance())
+		l.UpgradeStarting(upgradeableInstance(1), 1, 2)
+		l.UpgradeFailed(upgradeableInstance(1), 1, 2, 1500*time.Millisecond, fmt.Errorf("boom"))
+		l.UpgradeSucceeded(upgradeableInstance(1), 2, 2, 2*time.Second)
+		l.FinalTotals()
+
+		result := lines()
+		Expect(result).To(HaveLen(7))
+		Expect(json.Marshal(result[0])).To(MatchJSON(`{"event": "message", "operation": "upgrade", "message": "discovering service instances for broker: fake-broker"}`))
+		Expect(json.Marshal(result[1])).To(MatchJSON(`{
+			"event": "initial_totals",
+			"operation": "upgrade",
+			"totals": {"total": 3, "upgradable": 2, "skipped": 0, "succeeded": 0, "failed": 0, "pre_hook_failed": 0, "post_hook_failed": 0, "not_at_target_version": 0, "degraded": 0}
+		}`))
+		Expect(result[2]).To(HaveKeyWithValue("event", "instance_skipped"))
+		Expect(result[2]).To(HaveKeyWithValue("instance", HaveKeyWithValue("last_operation_state", "failed")))
+		Expect(json.Marshal(result[3])).To(MatchJSON(`{
+			"event": "upgrade_starting",
+			"operation": "upgrade",
+			"attempt": 1,
+			"attempts": 2,
+			"instance": {
+				"guid": "my-service-instance-guid-1",
+				"name": "my-service-instance-1",
+				"version": "fake-version-1",
+				"plan": "fake-plan-name-1",
+				"plan_version": "fake-plan-version-1",
+				"offering": "fake-soffer-name-1",
+				"space": "fake-space-name-1",
+				"org": "fake-org-name-1",
+				"last_operation_type": "last-operation-type-1",
+				"last_operation_state": "last-operation-state-1"
+			}
+		}`))
+		Expect(result[4]).To(HaveKeyWithValue("event", "upgrade_failed"))
+		Expect(result[4]).To(HaveKeyWithValue("duration_seconds", 1.5))
+		Expect(result[4]).To(HaveKeyWithValue("error", "boom"))
+		Expect(result[5]).To(HaveKeyWithValue("event", "upgrade_succeeded"))
+		Expect(result[5]).To(HaveKeyWithValue("duration_seconds", float64(2)))
+		Expect(result[5]).NotTo(HaveKey("error"))
+		Expect(result[6]).To(HaveKeyWithValue("event", "final_totals"))
+		Expect(result[6]).To(HaveKeyWithValue("totals", HaveKeyWithValue("succeeded", float64(1))))
+		Expect(result[6]).To(HaveKeyWithValue("totals", HaveKeyWithValue("skipped", float64(1))))
+	})
+
+	It("names a plan migration in each event", func() {
+		l.SetOperation(logger.OperationPlanMigration)
+		l.UpgradeStarting(upgradeableInstance(1), 1, 1)
+		l.UpgradeSucceeded(upgradeableInstance(1), 1, 1, time.Second)
+
+		result := lines()
+		Expect(result).To(HaveLen(2))
+		Expect(result[0]).To(HaveKeyWithValue("operation", "plan_migration"))
+		Expect(result[1]).To(HaveKeyWithValue("operation", "plan_migration"))
+	})
+
+	It("writes the hook failures", func() {
+		l.PreHookFailed(upgradeableInstance(1), fmt.Errorf("backup failed"))
+		l.PostHookFailed(upgradeableInstance(2), fmt.Errorf("smoke test failed"))
+
+		result := lines()
+		Expect(result).To(HaveLen(2))
+		Expect(result[0]).To(HaveKeyWithValue("event", "pre_hook_failed"))
+		Expect(result[0]).To(HaveKeyWithValue("error", "backup failed"))
+		Expect(result[1]).To(HaveKeyWithValue("event", "post_hook_failed"))
+		Expect(result[1]).To(HaveKeyWithValue("error", "smoke test failed"))
+	})
+
+	It("writes the apps bound to an instance", func() {
+		l.InstanceBindings(upgradeableInstance(1), ccapi.Bindings{Apps: []ccapi.BoundApp{{GUID: "app-guid-1", Name: "app-1"}}, RouteBindings: 1})
+
+		result := lines()
+		Expect(result).To(HaveLen(1))
+		Expect(result[0]).To(HaveKeyWithValue("event", "instance_bindings"))
+		Expect(result[0]).To(HaveKeyWithValue("bindings", map[string]any{
+			"apps":           []any{map[string]any{"guid": "app-guid-1", "name": "app-1"}},
+			"route_bindings": float64(1),
+			"service_keys":   float64(0),
+		}))
+	})
+
+	It("writes the ticker totals", func() {
+		// The ticker writes from another goroutine, so a thread-safe buffer is needed
+		output := gbytes.NewBuffer()
+		l = logger.NewWithSinks(100*time.Millisecond, logger.NewJSONLinesSink(output, "fake-run-id"))
+		DeferCleanup(l.Cleanup)
+		l.InitialTotals(1, 1)
+		l.UpgradeSucceeded(upgradeableInstance(1), 1, 1, time.Minute)
+
+		Eventually(output).Should(gbytes.Say(`"event":"progress".*"succeeded":1`))
+	})
+
+	It("can be combined with text output", func() {
+		var text bytes.Buffer
+		l = logger.NewWithSinks(time.Minute, logger.NewTextSink(&text), logger.NewJSONLinesSink(buffer, "fake-run-id"))
+		DeferCleanup(l.Cleanup)
+
+		l.Printf("a message")
+
+		Expect(text.String()).To(HaveSuffix(": a message\n"))
+		Expect(lines()).To(ConsistOf(HaveKeyWithValue("message", "a message")))
+	})
+})
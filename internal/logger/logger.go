@@ -2,7 +2,9 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"maps"
+	"os"
 	"slices"
 	"sync"
 	"time"
@@ -19,42 +21,79 @@ const (
 	stateSucceeded
 	stateFailed
 	stateSkipped
+	statePostHookFailed
+	stateNotAtTargetVersion
+	stateDegraded
 )
 
 func New(period time.Duration) *Logger {
+	return NewWithOutput(period, stdout{})
+}
+
+// NewWithOutput creates a Logger that writes text to the specified output rather than to stdout
+func NewWithOutput(period time.Duration, out io.Writer) *Logger {
+	return NewWithSinks(period, NewTextSink(out))
+}
+
+// NewWithSinks creates a Logger that emits events to each of the sinks
+func NewWithSinks(period time.Duration, sinks ...Sink) *Logger {
 	l := Logger{
-		ticker: time.NewTicker(period),
-		states: make(map[string]instanceState),
+		operation: OperationUpgrade,
+		sinks:     sinks,
+		ticker:    time.NewTicker(period),
+		states:    make(map[string]instanceState),
 	}
 
 	go func() {
 		for range l.ticker.C {
-			l.Printf("%s", l.tickerMessage())
+			l.lock.Lock()
+			l.emit(Event{Kind: EventProgress, Totals: l.totals()})
+			l.lock.Unlock()
 		}
 	}()
 
 	return &l
 }
 
-type failure struct {
-	instance    ccapi.ServiceInstance
-	err         error
-	attempt, of int
+// Logger keeps track of the state of each service instance, and emits events to its sinks
+type Logger struct {
+	lock             sync.Mutex
+	operation        Operation
+	sinks            []Sink
+	ticker           *time.Ticker
+	total            int
+	target           int
+	states           map[string]instanceState
+	failures         []Failure
+	hookFailures     []Failure
+	notAtTarget      []Failure
+	degraded         []Failure
+	preHookFailures  int
+	postHookFailures int
 }
 
-type Logger struct {
-	lock     sync.Mutex
-	ticker   *time.Ticker
-	target   int
-	states   map[string]instanceState
-	failures []failure
+// SetOperation names what is being done to each service instance in the events, so that a plan migration
+// is not described as an upgrade. The default is OperationUpgrade.
+func (l *Logger) SetOperation(operation Operation) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.operation = operation
+}
+
+// Operation is what is being done to each service instance
+func (l *Logger) Operation() Operation {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.operation
 }
 
 func (l *Logger) Printf(format string, a ...any) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
-	l.printf(format, a...)
+	l.emit(Event{Kind: EventMessage, Message: fmt.Sprintf(format, a...)})
 }
 
 func (l *Logger) SkippingInstance(instance ccapi.ServiceInstance) {
@@ -62,7 +101,7 @@ func (l *Logger) SkippingInstance(instance ccapi.ServiceInstance) {
 	defer l.lock.Unlock()
 
 	l.states[instance.GUID] = stateSkipped
-	l.printf("skipping instance: %q guid: %q Upgrade Available: %v Last Operation Type: %q State: %q", instance.Name, instance.GUID, instance.UpgradeAvailable, instance.LastOperationType, instance.LastOperationState)
+	l.emit(Event{Kind: EventInstanceSkipped, Instance: &instance})
 }
 
 func (l *Logger) UpgradeStarting(instance ccapi.ServiceInstance, attempt, of int) {
@@ -70,7 +109,7 @@ func (l *Logger) UpgradeStarting(instance ccapi.ServiceInstance, attempt, of int
 	defer l.lock.Unlock()
 
 	l.states[instance.GUID] = stateStarted
-	l.printf("starting to upgrade instance: %q guid: %q%s", instance.Name, instance.GUID, attemptMessage(attempt, of))
+	l.emit(Event{Kind: EventUpgradeStarting, Instance: &instance, Attempt: attempt, Of: of})
 }
 
 func (l *Logger) UpgradeSucceeded(instance ccapi.ServiceInstance, attempt, of int, duration time.Duration) {
@@ -78,106 +117,178 @@ func (l *Logger) UpgradeSucceeded(instance ccapi.ServiceInstance, attempt, of in
 	defer l.lock.Unlock()
 
 	l.states[instance.GUID] = stateSucceeded
-	l.printf("finished upgrade of instance: %q guid: %q successfully after %s%s", instance.Name, instance.GUID, duration, attemptMessage(attempt, of))
+	l.emit(Event{Kind: EventUpgradeSucceeded, Instance: &instance, Attempt: attempt, Of: of, Duration: duration})
 }
 
 func (l *Logger) UpgradeFailed(instance ccapi.ServiceInstance, attempt, of int, duration time.Duration, err error) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
-	l.failures = append(l.failures, failure{
-		instance: instance,
-		err:      err,
-		attempt:  attempt,
-		of:       of,
+	l.failures = append(l.failures, Failure{
+		Instance: instance,
+		Err:      err,
+		Attempt:  attempt,
+		Of:       of,
 	})
 	l.states[instance.GUID] = stateFailed
-	l.printf("upgrade of instance: %q guid: %q failed after %s%s: %s", instance.Name, instance.GUID, duration, attemptMessage(attempt, of), err)
+	l.emit(Event{Kind: EventUpgradeFailed, Instance: &instance, Attempt: attempt, Of: of, Duration: duration, Err: err})
+}
+
+func (l *Logger) PreHookFailed(instance ccapi.ServiceInstance, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.hookFailures = append(l.hookFailures, Failure{
+		Instance: instance,
+		Err:      fmt.Errorf("pre-hook failed: %w", err),
+		Attempt:  1,
+		Of:       1,
+	})
+	l.preHookFailures++
+	l.states[instance.GUID] = stateSkipped
+	l.emit(Event{Kind: EventPreHookFailed, Instance: &instance, Err: err})
+}
+
+func (l *Logger) PostHookFailed(instance ccapi.ServiceInstance, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.hookFailures = append(l.hookFailures, Failure{
+		Instance: instance,
+		Err:      fmt.Errorf("post-hook failed: %w", err),
+		Attempt:  1,
+		Of:       1,
+	})
+	l.postHookFailures++
+	l.states[instance.GUID] = statePostHookFailed
+	l.emit(Event{Kind: EventPostHookFailed, Instance: &instance, Err: err})
+}
+
+// UpgradeNotAtTargetVersion records a service instance whose upgrade completed, but which is not at the version
+// of its plan, or still has an upgrade available
+func (l *Logger) UpgradeNotAtTargetVersion(instance ccapi.ServiceInstance, version string, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.notAtTarget = append(l.notAtTarget, Failure{
+		Instance: instance,
+		Err:      err,
+		Attempt:  1,
+		Of:       1,
+	})
+	l.states[instance.GUID] = stateNotAtTargetVersion
+	l.emit(Event{Kind: EventUpgradeNotAtTargetVersion, Instance: &instance, Err: err})
+}
+
+// UpgradeDegraded records a service instance whose upgrade completed, but which has bound apps with crashed
+// process instances during the health check window
+func (l *Logger) UpgradeDegraded(instance ccapi.ServiceInstance, err error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.degraded = append(l.degraded, Failure{
+		Instance: instance,
+		Err:      err,
+		Attempt:  1,
+		Of:       1,
+	})
+	l.states[instance.GUID] = stateDegraded
+	l.emit(Event{Kind: EventUpgradeDegraded, Instance: &instance, Err: err})
+}
+
+func (l *Logger) InstanceBindings(instance ccapi.ServiceInstance, bindings ccapi.Bindings) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.emit(Event{Kind: EventInstanceBindings, Instance: &instance, Bindings: &bindings})
 }
 
 func (l *Logger) InitialTotals(totalServiceInstances, totalUpgradableServiceInstances int) {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
+	l.total = totalServiceInstances
 	l.target = totalUpgradableServiceInstances
-
-	l.separator()
-	l.printf("total instances: %d", totalServiceInstances)
-	l.printf("upgradable instances: %d", totalUpgradableServiceInstances)
-	l.separator()
-	l.printf("starting upgrade...")
+	l.emit(Event{Kind: EventInitialTotals, Totals: l.totals()})
 }
 
 func (l *Logger) FinalTotals() {
 	l.lock.Lock()
 	defer l.lock.Unlock()
 
-	l.printf("%s", l.tickerMessage())
-	l.separator()
-	l.printf("skipped %d instances", l.numInState(stateSkipped))
-	l.printf("successfully upgraded %d instances", l.numInState(stateSucceeded))
+	l.emit(Event{
+		Kind:               EventFinalTotals,
+		Totals:             l.totals(),
+		Failures:           slices.Clone(l.failures),
+		HookFailures:       slices.Clone(l.hookFailures),
+		NotAtTargetVersion: slices.Clone(l.notAtTarget),
+		Degraded:           slices.Clone(l.degraded),
+	})
+}
+
+// Totals is a snapshot of the number of service instances in each state
+type Totals struct {
+	Total              int
+	Upgradable         int
+	Skipped            int
+	Succeeded          int
+	Failed             int
+	PreHookFailed      int
+	PostHookFailed     int
+	NotAtTargetVersion int
+	Degraded           int
+}
 
-	logRowFormatTotals(l)
+func (l *Logger) Totals() Totals {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return l.totals()
 }
 
+// HasUpgradeSucceeded is false when any instance failed to upgrade, was skipped by the pre-hook,
+// had a failed post-hook, was not at the target version after the upgrade, or was degraded
 func (l *Logger) HasUpgradeSucceeded() bool {
 	l.lock.Lock()
 	defer l.lock.Unlock()
-	return len(l.failures) == 0
-}
-
-func logRowFormatTotals(l *Logger) {
-	if len(l.failures) > 0 {
-		l.printf("failed to upgrade %d instances", l.numInState(stateFailed))
-		l.printf("")
-		for _, failure := range l.failures {
-			fmt.Println()
-			fmt.Printf("  Details: %q\n", failure.err)
-			if failure.of != 1 {
-				fmt.Printf("  Attempt %d of %d\n", failure.attempt, failure.of)
-			}
-			fmt.Printf("  Service Instance Name: %q\n", failure.instance.Name)
-			fmt.Printf("  Service Instance GUID: %q\n", failure.instance.GUID)
-			fmt.Printf("  Service Instance Version: %q\n", failure.instance.MaintenanceInfoVersion)
-			fmt.Printf("  Service Plan Name: %q\n", failure.instance.ServicePlanName)
-			fmt.Printf("  Service Plan GUID: %q\n", failure.instance.ServicePlanGUID)
-			fmt.Printf("  Service Plan Version: %q\n", failure.instance.ServicePlanMaintenanceInfoVersion)
-			fmt.Printf("  Service Offering Name: %q\n", failure.instance.ServiceOfferingName)
-			fmt.Printf("  Service Offering GUID: %q\n", failure.instance.ServiceOfferingGUID)
-			fmt.Printf("  Space Name: %q\n", failure.instance.SpaceName)
-			fmt.Printf("  Space GUID: %q\n", failure.instance.SpaceGUID)
-			fmt.Printf("  Organization Name: %q\n", failure.instance.OrganizationName)
-			fmt.Printf("  Organization GUID: %q\n", failure.instance.OrganizationGUID)
-		}
-	}
+	return len(l.failures) == 0 && len(l.hookFailures) == 0 && len(l.notAtTarget) == 0 && len(l.degraded) == 0
 }
 
 func (l *Logger) Cleanup() {
 	l.ticker.Stop()
 }
 
-func (l *Logger) printf(format string, a ...any) {
-	fmt.Print(time.Now().Format(time.RFC3339))
-	fmt.Print(": ")
-	fmt.Printf(format, a...)
-	fmt.Println()
-}
-
-func (l *Logger) separator() {
-	l.printf("---")
+// emit sends an event to each of the sinks. It must be called with the lock held, so that
+// the sinks receive events one at a time, in order.
+func (l *Logger) emit(e Event) {
+	e.Time = time.Now()
+	e.Operation = l.operation
+	for _, s := range l.sinks {
+		s.Handle(e)
+	}
 }
 
-func (l *Logger) tickerMessage() string {
-	return fmt.Sprintf("upgraded %d of %d", l.numInState(stateSucceeded), l.target)
+func (l *Logger) totals() Totals {
+	return Totals{
+		Total:              l.total,
+		Upgradable:         l.target,
+		Skipped:            l.numInState(stateSkipped),
+		Succeeded:          l.numInState(stateSucceeded),
+		Failed:             l.numInState(stateFailed),
+		PreHookFailed:      l.preHookFailures,
+		PostHookFailed:     l.postHookFailures,
+		NotAtTargetVersion: l.numInState(stateNotAtTargetVersion),
+		Degraded:           l.numInState(stateDegraded),
+	}
 }
 
 func (l *Logger) numInState(s instanceState) int {
 	return len(slicex.Filter(slices.Collect(maps.Values(l.states)), func(state instanceState) bool { return state == s }))
 }
 
-func attemptMessage(attempt, of int) string {
-	if of == 1 {
-		return ""
-	}
-	return fmt.Sprintf(" (attempt %d of %d)", attempt, of)
+// stdout writes to whatever os.Stdout is at the time of writing, rather than when the Logger was created
+type stdout struct{}
+
+func (stdout) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
 }
@@ -1,6 +1,7 @@
 package logger_test
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -34,6 +35,18 @@ var _ = Describe("Logger", func() {
 		Expect(result).To(MatchRegexp(timestampRegexp + ": a message\n"))
 	})
 
+	It("can log to another output", func() {
+		var buffer bytes.Buffer
+		other := logger.NewWithOutput(time.Minute, &buffer)
+		DeferCleanup(other.Cleanup)
+
+		result := captureStdout(func() {
+			other.Printf("a message")
+		})
+		Expect(result).To(BeEmpty())
+		Expect(buffer.String()).To(MatchRegexp(timestampRegexp + ": a message\n"))
+	})
+
 	It("can log the initial totals", func() {
 		result := captureStdout(func() {
 			l.InitialTotals(1, 2)
@@ -41,6 +54,30 @@ var _ = Describe("Logger", func() {
 		Expect(result).To(MatchRegexp(`total instances: 1\n.*upgradable instances: 2\n`))
 	})
 
+	It("describes a plan migration rather than an upgrade", func() {
+		var buffer bytes.Buffer
+		other := logger.NewWithOutput(time.Minute, &buffer)
+		DeferCleanup(other.Cleanup)
+		other.SetOperation(logger.OperationPlanMigration)
+		Expect(other.Operation()).To(Equal(logger.OperationPlanMigration))
+
+		other.InitialTotals(2, 2)
+		other.UpgradeStarting(upgradeableInstance(1), 1, 1)
+		other.UpgradeSucceeded(upgradeableInstance(1), 1, 1, time.Minute)
+		other.UpgradeFailed(upgradeableInstance(2), 1, 1, time.Minute, fmt.Errorf("boom"))
+		other.FinalTotals()
+
+		Expect(buffer.String()).To(ContainSubstring("migratable instances: 2\n"))
+		Expect(buffer.String()).To(ContainSubstring("starting plan migration...\n"))
+		Expect(buffer.String()).To(ContainSubstring(`starting to migrate the plan of instance: "my-service-instance-1" guid: "my-service-instance-guid-1"`))
+		Expect(buffer.String()).To(ContainSubstring(`finished plan migration of instance: "my-service-instance-1" guid: "my-service-instance-guid-1" successfully after 1m0s`))
+		Expect(buffer.String()).To(ContainSubstring(`plan migration of instance: "my-service-instance-2" guid: "my-service-instance-guid-2" failed after 1m0s: boom`))
+		Expect(buffer.String()).To(ContainSubstring("migrated 1 of 2\n"))
+		Expect(buffer.String()).To(ContainSubstring("successfully migrated 1 instances\n"))
+		Expect(buffer.String()).To(ContainSubstring("failed to migrate 1 instances\n"))
+		Expect(buffer.String()).NotTo(ContainSubstring("upgrade"))
+	})
+
 	It("can log that it is skipping an instance", func() {
 		result := captureStdout(func() {
 			l.SkippingInstance(createFailedInstance())
@@ -182,6 +219,117 @@ var _ = Describe("Logger", func() {
 		Expect(result).To(MatchRegexp(`Organization GUID: "fake-org-guid-2"\s+`))
 	})
 
+	It("can log that it is skipping an instance because the pre-hook failed", func() {
+		result := captureStdout(func() {
+			l.PreHookFailed(upgradeableInstance(1), fmt.Errorf("exit status 1"))
+		})
+		Expect(result).To(MatchRegexp(timestampRegexp + `: skipping instance: "my-service-instance-1" guid: "my-service-instance-guid-1" as the pre-hook failed: exit status 1\n`))
+	})
+
+	It("can log the failure of a post-hook", func() {
+		result := captureStdout(func() {
+			l.PostHookFailed(upgradeableInstance(1), fmt.Errorf("exit status 2"))
+		})
+		Expect(result).To(MatchRegexp(timestampRegexp + `: post-hook for upgraded instance: "my-service-instance-1" guid: "my-service-instance-guid-1" failed: exit status 2\n`))
+	})
+
+	It("can log the apps bound to an instance", func() {
+		result := captureStdout(func() {
+			l.InstanceBindings(upgradeableInstance(1), ccapi.Bindings{Apps: []ccapi.BoundApp{{GUID: "app-guid-1", Name: "app-1"}, {GUID: "app-guid-2", Name: "app-2"}}})
+		})
+		Expect(result).To(MatchRegexp(timestampRegexp + `: instance: "my-service-instance-1" guid: "my-service-instance-guid-1" org: "fake-org-name-1" is bound to 2 apps: app-1, app-2\n`))
+
+		result = captureStdout(func() {
+			l.InstanceBindings(upgradeableInstance(2), ccapi.Bindings{})
+		})
+		Expect(result).To(MatchRegexp(timestampRegexp + `: instance: "my-service-instance-2" guid: "my-service-instance-guid-2" org: "fake-org-name-2" is bound to 0 apps\n`))
+	})
+
+	It("can log that an upgraded instance is not at the target version", func() {
+		result := captureStdout(func() {
+			l.UpgradeNotAtTargetVersion(upgradeableInstance(1), "1.2.2", fmt.Errorf(`version is "1.2.2" rather than the target version "1.2.3"`))
+		})
+		Expect(result).To(MatchRegexp(timestampRegexp + `: upgrade of instance: "my-service-instance-1" guid: "my-service-instance-guid-1" completed, but it is not at the target version: version is "1.2.2" rather than the target version "1.2.3"\n`))
+	})
+
+	It("can log that an upgraded instance is degraded", func() {
+		result := captureStdout(func() {
+			l.UpgradeDegraded(upgradeableInstance(1), fmt.Errorf(`bound apps have crashed process instances: "my-app" web/0`))
+		})
+		Expect(result).To(MatchRegexp(timestampRegexp + `: upgrade of instance: "my-service-instance-1" guid: "my-service-instance-guid-1" completed, but it is degraded: bound apps have crashed process instances: "my-app" web/0\n`))
+	})
+
+	It("can log the final totals for instances which are degraded", func() {
+		l.InitialTotals(2, 2)
+		l.UpgradeSucceeded(upgradeableInstance(1), 1, 1, time.Minute)
+		l.UpgradeSucceeded(upgradeableInstance(2), 1, 1, time.Minute)
+		l.UpgradeDegraded(upgradeableInstance(2), fmt.Errorf("an app crashed"))
+		Expect(l.HasUpgradeSucceeded()).To(BeFalse())
+		Expect(l.Totals()).To(Equal(logger.Totals{Total: 2, Upgradable: 2, Succeeded: 1, Degraded: 1}))
+
+		result := captureStdout(func() {
+			l.FinalTotals()
+		})
+		Expect(result).To(MatchRegexp(`: successfully upgraded 1 instances\n`))
+		Expect(result).To(MatchRegexp(`: 1 upgraded instances are degraded\n`))
+		Expect(result).To(MatchRegexp(`Details: "an app crashed"\n\s+Service Instance Name: "my-service-instance-2"\n`))
+	})
+
+	It("can log the final totals for instances which are not at the target version", func() {
+		l.InitialTotals(2, 2)
+		l.UpgradeSucceeded(upgradeableInstance(1), 1, 1, time.Minute)
+		l.UpgradeSucceeded(upgradeableInstance(2), 1, 1, time.Minute)
+		l.UpgradeNotAtTargetVersion(upgradeableInstance(2), "1.2.2", fmt.Errorf("an upgrade is still available"))
+		Expect(l.HasUpgradeSucceeded()).To(BeFalse())
+		Expect(l.Totals()).To(Equal(logger.Totals{Total: 2, Upgradable: 2, Succeeded: 1, NotAtTargetVersion: 1}))
+
+		result := captureStdout(func() {
+			l.FinalTotals()
+		})
+		Expect(result).To(MatchRegexp(`: successfully upgraded 1 instances\n`))
+		Expect(result).To(MatchRegexp(`: 1 upgraded instances are not at the target version\n`))
+		Expect(result).To(MatchRegexp(`Details: "an upgrade is still available"\n\s+Service Instance Name: "my-service-instance-2"\n`))
+	})
+
+	It("can log the final totals for hook failures", func() {
+		l.InitialTotals(3, 3)
+		l.PreHookFailed(upgradeableInstance(1), fmt.Errorf("backup failed"))
+		l.UpgradeSucceeded(upgradeableInstance(2), 1, 1, time.Minute)
+		l.PostHookFailed(upgradeableInstance(2), fmt.Errorf("smoke test failed"))
+		l.UpgradeSucceeded(upgradeableInstance(3), 1, 1, time.Minute)
+
+		result := captureStdout(func() {
+			l.FinalTotals()
+		})
+		Expect(result).To(MatchRegexp(`: skipped 1 instances\n`))
+		Expect(result).To(MatchRegexp(`: successfully upgraded 1 instances\n`))
+		Expect(result).NotTo(MatchRegexp(`: failed to upgrade`))
+		Expect(result).To(MatchRegexp(`: pre-hook failed for 1 instances\n`))
+		Expect(result).To(MatchRegexp(`: post-hook failed for 1 upgraded instances\n`))
+		Expect(result).To(MatchRegexp(`Details: "pre-hook failed: backup failed"\n\s+Service Instance Name: "my-service-instance-1"\n`))
+		Expect(result).To(MatchRegexp(`Details: "post-hook failed: smoke test failed"\n\s+Service Instance Name: "my-service-instance-2"\n`))
+	})
+
+	It("can report the totals", func() {
+		l.InitialTotals(10, 4)
+		l.SkippingInstance(createFailedInstance())
+		l.UpgradeSucceeded(upgradeableInstance(1), 1, 1, time.Minute)
+		l.UpgradeFailed(upgradeableInstance(2), 1, 1, time.Minute, fmt.Errorf("boom"))
+		l.PreHookFailed(upgradeableInstance(3), fmt.Errorf("boom"))
+		l.UpgradeSucceeded(upgradeableInstance(4), 1, 1, time.Minute)
+		l.PostHookFailed(upgradeableInstance(4), fmt.Errorf("boom"))
+
+		Expect(l.Totals()).To(Equal(logger.Totals{
+			Total:          10,
+			Upgradable:     4,
+			Skipped:        2,
+			Succeeded:      1,
+			Failed:         1,
+			PreHookFailed:  1,
+			PostHookFailed: 1,
+		}))
+	})
+
 	It("logs on a ticker", func() {
 		l.InitialTotals(10, 5)
 		l.UpgradeSucceeded(upgradeableInstance(1), 1, 1, time.Minute)
@@ -206,6 +354,17 @@ var _ = Describe("Logger", func() {
 			Expect(l.HasUpgradeSucceeded()).To(BeTrue())
 		})
 
+		It("can signal pre-hook failures", func() {
+			l.PreHookFailed(upgradeableInstance(1), fmt.Errorf("boom"))
+			Expect(l.HasUpgradeSucceeded()).To(BeFalse())
+		})
+
+		It("can signal post-hook failures", func() {
+			l.UpgradeSucceeded(upgradeableInstance(1), 1, 1, time.Minute)
+			l.PostHookFailed(upgradeableInstance(1), fmt.Errorf("boom"))
+			Expect(l.HasUpgradeSucceeded()).To(BeFalse())
+		})
+
 	})
 })
 
@@ -0,0 +1,165 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+)
+
+// TextSink formats events as timestamped lines of text
+type TextSink struct {
+	out io.Writer
+}
+
+func NewTextSink(out io.Writer) *TextSink {
+	return &TextSink{out: out}
+}
+
+// wording describes an operation in the text log
+type wording struct {
+	verb       string // starting to <verb> instance
+	noun       string // <noun> of instance ... failed
+	adjective  string // <adjective> instances: 3
+	past       string // successfully <past> 3 instances
+	infinitive string // failed to <infinitive> 3 instances
+}
+
+var wordings = map[Operation]wording{
+	OperationUpgrade:       {verb: "upgrade", noun: "upgrade", adjective: "upgradable", past: "upgraded", infinitive: "upgrade"},
+	OperationPlanMigration: {verb: "migrate the plan of", noun: "plan migration", adjective: "migratable", past: "migrated", infinitive: "migrate"},
+}
+
+// wordingFor returns the wording of the operation, defaulting to an upgrade
+func wordingFor(operation Operation) wording {
+	if w, ok := wordings[operation]; ok {
+		return w
+	}
+	return wordings[OperationUpgrade]
+}
+
+func (t *TextSink) Handle(e Event) {
+	w := wordingFor(e.Operation)
+	switch e.Kind {
+	case EventMessage:
+		t.printf(e.Time, "%s", e.Message)
+	case EventInstanceSkipped:
+		t.printf(e.Time, "skipping instance: %q guid: %q Upgrade Available: %v Last Operation Type: %q State: %q", e.Instance.Name, e.Instance.GUID, e.Instance.UpgradeAvailable, e.Instance.LastOperationType, e.Instance.LastOperationState)
+	case EventUpgradeStarting:
+		t.printf(e.Time, "starting to %s instance: %q guid: %q%s", w.verb, e.Instance.Name, e.Instance.GUID, attemptMessage(e.Attempt, e.Of))
+	case EventUpgradeSucceeded:
+		t.printf(e.Time, "finished %s of instance: %q guid: %q successfully after %s%s", w.noun, e.Instance.Name, e.Instance.GUID, e.Duration, attemptMessage(e.Attempt, e.Of))
+	case EventUpgradeFailed:
+		t.printf(e.Time, "%s of instance: %q guid: %q failed after %s%s: %s", w.noun, e.Instance.Name, e.Instance.GUID, e.Duration, attemptMessage(e.Attempt, e.Of), e.Err)
+	case EventPreHookFailed:
+		t.printf(e.Time, "skipping instance: %q guid: %q as the pre-hook failed: %s", e.Instance.Name, e.Instance.GUID, e.Err)
+	case EventPostHookFailed:
+		t.printf(e.Time, "post-hook for upgraded instance: %q guid: %q failed: %s", e.Instance.Name, e.Instance.GUID, e.Err)
+	case EventUpgradeNotAtTargetVersion:
+		t.printf(e.Time, "upgrade of instance: %q guid: %q completed, but it is not at the target version: %s", e.Instance.Name, e.Instance.GUID, e.Err)
+	case EventUpgradeDegraded:
+		t.printf(e.Time, "upgrade of instance: %q guid: %q completed, but it is degraded: %s", e.Instance.Name, e.Instance.GUID, e.Err)
+	case EventInstanceBindings:
+		t.printf(e.Time, "instance: %q guid: %q org: %q is bound to %d apps%s", e.Instance.Name, e.Instance.GUID, e.Instance.OrganizationName, len(e.Bindings.Apps), boundAppsMessage(e.Bindings.Apps))
+	case EventInitialTotals:
+		t.separator(e.Time)
+		t.printf(e.Time, "total instances: %d", e.Totals.Total)
+		t.printf(e.Time, "%s instances: %d", w.adjective, e.Totals.Upgradable)
+		t.separator(e.Time)
+		t.printf(e.Time, "starting %s...", w.noun)
+	case EventProgress:
+		t.printf(e.Time, "%s", progressMessage(w, e.Totals))
+	case EventFinalTotals:
+		t.finalTotals(e)
+	}
+}
+
+func (t *TextSink) finalTotals(e Event) {
+	w := wordingFor(e.Operation)
+	t.printf(e.Time, "%s", progressMessage(w, e.Totals))
+	t.separator(e.Time)
+	t.printf(e.Time, "skipped %d instances", e.Totals.Skipped)
+	t.printf(e.Time, "successfully %s %d instances", w.past, e.Totals.Succeeded)
+
+	if len(e.Failures) > 0 {
+		t.printf(e.Time, "failed to %s %d instances", w.infinitive, e.Totals.Failed)
+		t.printf(e.Time, "")
+		t.failureDetails(e.Failures)
+	}
+
+	if len(e.NotAtTargetVersion) > 0 {
+		t.printf(e.Time, "%d upgraded instances are not at the target version", e.Totals.NotAtTargetVersion)
+		t.printf(e.Time, "")
+		t.failureDetails(e.NotAtTargetVersion)
+	}
+
+	if len(e.Degraded) > 0 {
+		t.printf(e.Time, "%d upgraded instances are degraded", e.Totals.Degraded)
+		t.printf(e.Time, "")
+		t.failureDetails(e.Degraded)
+	}
+
+	if len(e.HookFailures) > 0 {
+		if e.Totals.PreHookFailed > 0 {
+			t.printf(e.Time, "pre-hook failed for %d instances", e.Totals.PreHookFailed)
+		}
+		if e.Totals.PostHookFailed > 0 {
+			t.printf(e.Time, "post-hook failed for %d upgraded instances", e.Totals.PostHookFailed)
+		}
+		t.printf(e.Time, "")
+		t.failureDetails(e.HookFailures)
+	}
+}
+
+func (t *TextSink) failureDetails(failures []Failure) {
+	for _, failure := range failures {
+		fmt.Fprintln(t.out)
+		fmt.Fprintf(t.out, "  Details: %q\n", failure.Err)
+		if failure.Of != 1 {
+			fmt.Fprintf(t.out, "  Attempt %d of %d\n", failure.Attempt, failure.Of)
+		}
+		fmt.Fprintf(t.out, "  Service Instance Name: %q\n", failure.Instance.Name)
+		fmt.Fprintf(t.out, "  Service Instance GUID: %q\n", failure.Instance.GUID)
+		fmt.Fprintf(t.out, "  Service Instance Version: %q\n", failure.Instance.MaintenanceInfoVersion)
+		fmt.Fprintf(t.out, "  Service Plan Name: %q\n", failure.Instance.ServicePlanName)
+		fmt.Fprintf(t.out, "  Service Plan GUID: %q\n", failure.Instance.ServicePlanGUID)
+		fmt.Fprintf(t.out, "  Service Plan Version: %q\n", failure.Instance.ServicePlanMaintenanceInfoVersion)
+		fmt.Fprintf(t.out, "  Service Offering Name: %q\n", failure.Instance.ServiceOfferingName)
+		fmt.Fprintf(t.out, "  Service Offering GUID: %q\n", failure.Instance.ServiceOfferingGUID)
+		fmt.Fprintf(t.out, "  Space Name: %q\n", failure.Instance.SpaceName)
+		fmt.Fprintf(t.out, "  Space GUID: %q\n", failure.Instance.SpaceGUID)
+		fmt.Fprintf(t.out, "  Organization Name: %q\n", failure.Instance.OrganizationName)
+		fmt.Fprintf(t.out, "  Organization GUID: %q\n", failure.Instance.OrganizationGUID)
+	}
+}
+
+func (t *TextSink) printf(at time.Time, format string, a ...any) {
+	fmt.Fprint(t.out, at.Format(time.RFC3339))
+	fmt.Fprint(t.out, ": ")
+	fmt.Fprintf(t.out, format, a...)
+	fmt.Fprintln(t.out)
+}
+
+func (t *TextSink) separator(at time.Time) {
+	t.printf(at, "---")
+}
+
+func progressMessage(w wording, totals Totals) string {
+	return fmt.Sprintf("%s %d of %d", w.past, totals.Succeeded, totals.Upgradable)
+}
+
+func boundAppsMessage(apps []ccapi.BoundApp) string {
+	if len(apps) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(slicex.Map(apps, func(a ccapi.BoundApp) string { return a.Name }), ", ")
+}
+
+func attemptMessage(attempt, of int) string {
+	if of == 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (attempt %d of %d)", attempt, of)
+}
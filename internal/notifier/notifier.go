@@ -0,0 +1,244 @@
+// Package notifier posts JSON events about the lifecycle of a run to a webhook
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/logger"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+)
+
+const (
+	EventRunStarted     = "run_started"
+	EventInstanceFailed = "instance_failed"
+	EventRunAborted     = "run_aborted"
+	EventRunFinished    = "run_finished"
+)
+
+const (
+	defaultAttempts      = 3
+	defaultRetryInterval = 2 * time.Second
+)
+
+// Logger is the logger that is decorated by the Notifier. The operation and the totals that it keeps are
+// included in each event.
+type Logger interface {
+	upgrader.Logger
+	Totals() logger.Totals
+	Operation() logger.Operation
+}
+
+// Notifier decorates a Logger, sending an event to the webhook at the start and end of a run, and when a
+// service instance fails. Failing to send an event is logged, but does not affect the outcome of the run.
+type Notifier struct {
+	Logger
+	Attempts      int
+	RetryInterval time.Duration
+
+	url        string
+	brokerName string
+	runID      string
+	client     *http.Client
+	lock       sync.Mutex
+	finished   bool
+}
+
+func New(url string, timeout time.Duration, log Logger, brokerName, runID string) *Notifier {
+	return &Notifier{
+		Logger:        log,
+		Attempts:      defaultAttempts,
+		RetryInterval: defaultRetryInterval,
+		url:           url,
+		brokerName:    brokerName,
+		runID:         runID,
+		client:        &http.Client{Timeout: timeout},
+	}
+}
+
+func (n *Notifier) InitialTotals(totalServiceInstances, totalUpgradableServiceInstances int) {
+	n.Logger.InitialTotals(totalServiceInstances, totalUpgradableServiceInstances)
+	n.send(event{Event: EventRunStarted})
+}
+
+// UpgradeFailed only sends an event on the final attempt, as earlier failures may be resolved by a retry
+func (n *Notifier) UpgradeFailed(instance ccapi.ServiceInstance, attempt, of int, duration time.Duration, err error) {
+	n.Logger.UpgradeFailed(instance, attempt, of, duration, err)
+	if attempt == of {
+		n.send(event{Event: EventInstanceFailed, Stage: operationStage(n.Logger.Operation()), Instance: newEventInstance(instance), Attempts: of, Error: err.Error()})
+	}
+}
+
+func (n *Notifier) PreHookFailed(instance ccapi.ServiceInstance, err error) {
+	n.Logger.PreHookFailed(instance, err)
+	n.send(event{Event: EventInstanceFailed, Stage: "pre-hook", Instance: newEventInstance(instance), Error: err.Error()})
+}
+
+func (n *Notifier) PostHookFailed(instance ccapi.ServiceInstance, err error) {
+	n.Logger.PostHookFailed(instance, err)
+	n.send(event{Event: EventInstanceFailed, Stage: "post-hook", Instance: newEventInstance(instance), Error: err.Error()})
+}
+
+func (n *Notifier) UpgradeNotAtTargetVersion(instance ccapi.ServiceInstance, version string, err error) {
+	n.Logger.UpgradeNotAtTargetVersion(instance, version, err)
+	n.send(event{Event: EventInstanceFailed, Stage: "verify", Instance: newEventInstance(instance), Error: err.Error()})
+}
+
+func (n *Notifier) UpgradeDegraded(instance ccapi.ServiceInstance, err error) {
+	n.Logger.UpgradeDegraded(instance, err)
+	n.send(event{Event: EventInstanceFailed, Stage: "health-check", Instance: newEventInstance(instance), Error: err.Error()})
+}
+
+// FinalTotals does not send the run_finished event straight away, because the error that the run ends with,
+// such as the rollout being stopped, is only known once it has completed
+func (n *Notifier) FinalTotals() {
+	n.Logger.FinalTotals()
+
+	n.lock.Lock()
+	n.finished = true
+	n.lock.Unlock()
+}
+
+// Completed is called with the result of the run. Once the final totals have been reached, the run_finished
+// event carries the error, if any, so that a rollout which was stopped, or had failures, is not reported as a
+// success. An error that stopped the run before the final totals were reached is reported as an abort.
+func (n *Notifier) Completed(err error) {
+	n.lock.Lock()
+	finished := n.finished
+	n.lock.Unlock()
+
+	switch {
+	case finished:
+		n.send(event{Event: EventRunFinished, Error: errorMessage(err)})
+	case err != nil:
+		n.send(event{Event: EventRunAborted, Error: err.Error()})
+	}
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// operationStage is the stage of an instance_failed event for the operation on the service instance itself
+func operationStage(operation logger.Operation) string {
+	if operation == logger.OperationPlanMigration {
+		return "plan-migration"
+	}
+	return "upgrade"
+}
+
+type event struct {
+	Event      string         `json:"event"`
+	Timestamp  string         `json:"timestamp"`
+	BrokerName string         `json:"broker"`
+	RunID      string         `json:"run_id"`
+	Operation  string         `json:"operation"`
+	Totals     eventTotals    `json:"totals"`
+	Stage      string         `json:"stage,omitempty"`
+	Instance   *eventInstance `json:"instance,omitempty"`
+	Attempts   int            `json:"attempts,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type eventTotals struct {
+	Total              int `json:"total"`
+	Upgradable         int `json:"upgradable"`
+	Skipped            int `json:"skipped"`
+	Succeeded          int `json:"succeeded"`
+	Failed             int `json:"failed"`
+	PreHookFailed      int `json:"pre_hook_failed"`
+	PostHookFailed     int `json:"post_hook_failed"`
+	NotAtTargetVersion int `json:"not_at_target_version"`
+	Degraded           int `json:"degraded"`
+}
+
+type eventInstance struct {
+	GUID             string `json:"guid"`
+	Name             string `json:"name"`
+	Version          string `json:"version"`
+	PlanName         string `json:"plan"`
+	PlanVersion      string `json:"plan_version"`
+	OfferingName     string `json:"offering"`
+	SpaceName        string `json:"space"`
+	OrganizationName string `json:"org"`
+}
+
+func newEventInstance(instance ccapi.ServiceInstance) *eventInstance {
+	return &eventInstance{
+		GUID:             instance.GUID,
+		Name:             instance.Name,
+		Version:          instance.MaintenanceInfoVersion,
+		PlanName:         instance.ServicePlanName,
+		PlanVersion:      instance.ServicePlanMaintenanceInfoVersion,
+		OfferingName:     instance.ServiceOfferingName,
+		SpaceName:        instance.SpaceName,
+		OrganizationName: instance.OrganizationName,
+	}
+}
+
+// send fills in the details common to all events, and posts the event to the webhook
+func (n *Notifier) send(e event) {
+	t := n.Logger.Totals()
+	e.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	e.BrokerName = n.brokerName
+	e.RunID = n.runID
+	e.Operation = string(n.Logger.Operation())
+	e.Totals = eventTotals{
+		Total:              t.Total,
+		Upgradable:         t.Upgradable,
+		Skipped:            t.Skipped,
+		Succeeded:          t.Succeeded,
+		Failed:             t.Failed,
+		PreHookFailed:      t.PreHookFailed,
+		PostHookFailed:     t.PostHookFailed,
+		NotAtTargetVersion: t.NotAtTargetVersion,
+		Degraded:           t.Degraded,
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		n.Logger.Printf("failed to send %s notification: %s", e.Event, err)
+		return
+	}
+
+	attempts := max(n.Attempts, 1)
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = n.post(data)
+		if err == nil {
+			return
+		}
+		if attempt < attempts {
+			time.Sleep(n.RetryInterval)
+		}
+	}
+	n.Logger.Printf("failed to send %s notification after %d attempts: %s", e.Event, attempts, err)
+}
+
+func (n *Notifier) post(data []byte) error {
+	response, err := n.client.Post(n.url, "application/json", bytes.NewReader(data))
+	// Webhook URLs often contain a secret, so avoid including the URL in the error
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &urlErr):
+		return fmt.Errorf("http request error: %s", urlErr.Err)
+	case err != nil:
+		return fmt.Errorf("http request error: %s", err)
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("http_error: %s", response.Status)
+	}
+	return nil
+}
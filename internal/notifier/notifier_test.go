@@ -0,0 +1,279 @@
+package notifier_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/logger"
+	"upgrade-all-services-cli-plugin/internal/notifier"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+var _ upgrader.Logger = &notifier.Notifier{}
+
+// fakeLogger adds the totals and operation to the generated fake
+type fakeLogger struct {
+	*upgraderfakes.FakeLogger
+	totals    logger.Totals
+	operation logger.Operation
+}
+
+func (f fakeLogger) Totals() logger.Totals {
+	return f.totals
+}
+
+func (f fakeLogger) Operation() logger.Operation {
+	return f.operation
+}
+
+var _ = Describe("Notifier", func() {
+	var (
+		fakeServer *ghttp.Server
+		fakeLog    fakeLogger
+		n          *notifier.Notifier
+		instance   ccapi.ServiceInstance
+		events     []map[string]any
+	)
+
+	recordEvent := func(w http.ResponseWriter, r *http.Request) {
+		defer GinkgoRecover()
+		var e map[string]any
+		Expect(json.NewDecoder(r.Body).Decode(&e)).To(Succeed())
+		events = append(events, e)
+	}
+
+	BeforeEach(func() {
+		events = nil
+		fakeServer = ghttp.NewServer()
+		DeferCleanup(fakeServer.Close)
+
+		fakeLog = fakeLogger{
+			FakeLogger: &upgraderfakes.FakeLogger{},
+			totals:     logger.Totals{Total: 5, Upgradable: 3, Skipped: 1, Succeeded: 1, Failed: 1},
+			operation:  logger.OperationUpgrade,
+		}
+
+		instance = ccapi.ServiceInstance{
+			GUID:                              "fake-instance-guid",
+			Name:                              "fake-instance-name",
+			MaintenanceInfoVersion:            "1.2.2",
+			ServicePlanMaintenanceInfoVersion: "1.2.3",
+			ServicePlanName:                   "fake-plan-name",
+			ServiceOfferingName:               "fake-offering-name",
+			SpaceName:                         "fake-space-name",
+			OrganizationName:                  "fake-org-name",
+		}
+
+		n = notifier.New(fakeServer.URL()+"/hook", time.Second, fakeLog, "fake-broker", "fake-run-id")
+		n.RetryInterval = time.Millisecond
+	})
+
+	It("announces the start of a run", func() {
+		fakeServer.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest(http.MethodPost, "/hook"),
+			ghttp.VerifyContentType("application/json"),
+			recordEvent,
+		))
+
+		n.InitialTotals(5, 3)
+
+		Expect(fakeLog.InitialTotalsCallCount()).To(Equal(1))
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(MatchKeys(IgnoreExtras, Keys{
+			"event":     Equal("run_started"),
+			"broker":    Equal("fake-broker"),
+			"run_id":    Equal("fake-run-id"),
+			"operation": Equal("upgrade"),
+			"totals": Equal(map[string]any{
+				"total":                 float64(5),
+				"upgradable":            float64(3),
+				"skipped":               float64(1),
+				"succeeded":             float64(1),
+				"failed":                float64(1),
+				"pre_hook_failed":       float64(0),
+				"post_hook_failed":      float64(0),
+				"not_at_target_version": float64(0),
+				"degraded":              float64(0),
+			}),
+		}))
+		Expect(time.Parse(time.RFC3339, events[0]["timestamp"].(string))).To(BeTemporally("~", time.Now(), time.Minute))
+	})
+
+	It("flags an instance that has failed its final attempt", func() {
+		fakeServer.AppendHandlers(recordEvent)
+
+		n.UpgradeFailed(instance, 1, 2, time.Minute, fmt.Errorf("boom"))
+		n.UpgradeFailed(instance, 2, 2, time.Minute, fmt.Errorf("bang"))
+
+		Expect(fakeLog.UpgradeFailedCallCount()).To(Equal(2))
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(MatchKeys(IgnoreExtras, Keys{
+			"event":    Equal("instance_failed"),
+			"stage":    Equal("upgrade"),
+			"attempts": Equal(float64(2)),
+			"error":    Equal("bang"),
+			"instance": Equal(map[string]any{
+				"guid":         "fake-instance-guid",
+				"name":         "fake-instance-name",
+				"version":      "1.2.2",
+				"plan":         "fake-plan-name",
+				"plan_version": "1.2.3",
+				"offering":     "fake-offering-name",
+				"space":        "fake-space-name",
+				"org":          "fake-org-name",
+			}),
+		}))
+	})
+
+	It("names a plan migration", func() {
+		fakeLog.operation = logger.OperationPlanMigration
+		n = notifier.New(fakeServer.URL()+"/hook", time.Second, fakeLog, "fake-broker", "fake-run-id")
+		fakeServer.AppendHandlers(recordEvent)
+
+		n.UpgradeFailed(instance, 1, 1, time.Minute, fmt.Errorf("boom"))
+
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(MatchKeys(IgnoreExtras, Keys{"event": Equal("instance_failed"), "operation": Equal("plan_migration"), "stage": Equal("plan-migration")}))
+	})
+
+	It("flags hook failures", func() {
+		fakeServer.AppendHandlers(recordEvent, recordEvent)
+
+		n.PreHookFailed(instance, fmt.Errorf("backup failed"))
+		n.PostHookFailed(instance, fmt.Errorf("smoke test failed"))
+
+		Expect(fakeLog.PreHookFailedCallCount()).To(Equal(1))
+		Expect(fakeLog.PostHookFailedCallCount()).To(Equal(1))
+		Expect(events).To(HaveLen(2))
+		Expect(events[0]).To(MatchKeys(IgnoreExtras, Keys{"event": Equal("instance_failed"), "stage": Equal("pre-hook"), "error": Equal("backup failed")}))
+		Expect(events[1]).To(MatchKeys(IgnoreExtras, Keys{"event": Equal("instance_failed"), "stage": Equal("post-hook"), "error": Equal("smoke test failed")}))
+	})
+
+	It("flags an upgraded instance that is not at the target version", func() {
+		fakeServer.AppendHandlers(recordEvent)
+
+		n.UpgradeNotAtTargetVersion(instance, "1.2.2", fmt.Errorf("an upgrade is still available"))
+
+		Expect(fakeLog.UpgradeNotAtTargetVersionCallCount()).To(Equal(1))
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(MatchKeys(IgnoreExtras, Keys{"event": Equal("instance_failed"), "stage": Equal("verify"), "error": Equal("an upgrade is still available")}))
+	})
+
+	It("flags an upgraded instance that is degraded", func() {
+		fakeServer.AppendHandlers(recordEvent)
+
+		n.UpgradeDegraded(instance, fmt.Errorf("an app crashed"))
+
+		Expect(fakeLog.UpgradeDegradedCallCount()).To(Equal(1))
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(MatchKeys(IgnoreExtras, Keys{"event": Equal("instance_failed"), "stage": Equal("health-check"), "error": Equal("an app crashed")}))
+	})
+
+	It("sends a summary at the end of the run, and does not report an abort", func() {
+		fakeServer.AppendHandlers(recordEvent)
+
+		n.FinalTotals()
+		n.Completed(nil)
+
+		Expect(fakeLog.FinalTotalsCallCount()).To(Equal(1))
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(HaveKeyWithValue("event", "run_finished"))
+		Expect(events[0]).NotTo(HaveKey("error"))
+	})
+
+	It("includes the error in the summary when there were failures", func() {
+		fakeServer.AppendHandlers(recordEvent)
+
+		n.FinalTotals()
+		n.Completed(fmt.Errorf("there were failures upgrading one or more instances"))
+
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(MatchKeys(IgnoreExtras, Keys{
+			"event": Equal("run_finished"),
+			"error": Equal("there were failures upgrading one or more instances"),
+		}))
+	})
+
+	It("includes the error in the summary when the rollout was stopped", func() {
+		fakeServer.AppendHandlers(recordEvent)
+
+		n.FinalTotals()
+		n.Completed(fmt.Errorf("the upgrade was stopped because apps bound to an upgraded instance crashed. Review the logs for more information"))
+
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(MatchKeys(IgnoreExtras, Keys{
+			"event": Equal("run_finished"),
+			"error": Equal("the upgrade was stopped because apps bound to an upgraded instance crashed. Review the logs for more information"),
+		}))
+	})
+
+	It("reports an abort when the run stops early", func() {
+		fakeServer.AppendHandlers(recordEvent)
+
+		n.Completed(fmt.Errorf("no service plans available for broker: fake-broker"))
+
+		Expect(events).To(HaveLen(1))
+		Expect(events[0]).To(MatchKeys(IgnoreExtras, Keys{
+			"event": Equal("run_aborted"),
+			"error": Equal("no service plans available for broker: fake-broker"),
+		}))
+	})
+
+	It("does not send an event when the run completes without error", func() {
+		n.Completed(nil)
+		Expect(fakeServer.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("retries a failed notification", func() {
+		fakeServer.AppendHandlers(
+			ghttp.RespondWith(http.StatusBadGateway, ""),
+			ghttp.RespondWith(http.StatusServiceUnavailable, ""),
+			recordEvent,
+		)
+
+		n.FinalTotals()
+		n.Completed(nil)
+
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
+		Expect(events).To(HaveLen(1))
+		Expect(fakeLog.PrintfCallCount()).To(BeZero())
+	})
+
+	It("logs a notification that could not be sent without including the URL", func() {
+		fakeServer.AppendHandlers(
+			ghttp.RespondWith(http.StatusInternalServerError, ""),
+			ghttp.RespondWith(http.StatusInternalServerError, ""),
+			ghttp.RespondWith(http.StatusInternalServerError, ""),
+		)
+
+		n.FinalTotals()
+		n.Completed(nil)
+
+		Expect(fakeServer.ReceivedRequests()).To(HaveLen(3))
+		Expect(fakeLog.PrintfCallCount()).To(Equal(1))
+		format, args := fakeLog.PrintfArgsForCall(0)
+		Expect(fmt.Sprintf(format, args...)).To(Equal("failed to send run_finished notification after 3 attempts: http_error: 500 Internal Server Error"))
+	})
+
+	It("times out", func() {
+		n = notifier.New(fakeServer.URL()+"/secret-token", 10*time.Millisecond, fakeLog, "fake-broker", "fake-run-id")
+		n.Attempts = 1
+		fakeServer.AppendHandlers(func(http.ResponseWriter, *http.Request) { time.Sleep(100 * time.Millisecond) })
+
+		n.FinalTotals()
+		n.Completed(nil)
+
+		format, args := fakeLog.PrintfArgsForCall(0)
+		message := fmt.Sprintf(format, args...)
+		Expect(message).To(HavePrefix("failed to send run_finished notification after 1 attempts: http request error:"))
+		Expect(message).NotTo(ContainSubstring("secret-token"))
+	})
+})
@@ -0,0 +1,323 @@
+// Package report records the outcome of each service instance during an upgrade, so that
+// it can be written as a JSON report at the end of the run
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/junit"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+)
+
+const (
+	OutcomeSucceeded          = "succeeded"
+	OutcomeFailed             = "failed"
+	OutcomeSkipped            = "skipped"
+	OutcomePreHookFailed      = "pre_hook_failed"
+	OutcomePostHookFailed     = "post_hook_failed"
+	OutcomeNotAtTargetVersion = "not_at_target_version"
+	OutcomeDegraded           = "degraded"
+)
+
+// Recorder decorates an upgrader.Logger, recording the events for each service instance
+type Recorder struct {
+	upgrader.Logger
+
+	// IncludeSummary adds a breakdown of the instances with each outcome to the JSON report
+	IncludeSummary bool
+
+	lock       sync.Mutex
+	brokerName string
+	runID      string
+	startedAt  time.Time
+	total      int
+	upgradable int
+	order      []string
+	instances  map[string]*instanceReport
+	boundApps  map[string][]string
+}
+
+func New(log upgrader.Logger, brokerName, runID string) *Recorder {
+	return &Recorder{
+		Logger:     log,
+		brokerName: brokerName,
+		runID:      runID,
+		startedAt:  time.Now(),
+		instances:  make(map[string]*instanceReport),
+		boundApps:  make(map[string][]string),
+	}
+}
+
+func (r *Recorder) InitialTotals(totalServiceInstances, totalUpgradableServiceInstances int) {
+	r.Logger.InitialTotals(totalServiceInstances, totalUpgradableServiceInstances)
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.total = totalServiceInstances
+	r.upgradable = totalUpgradableServiceInstances
+}
+
+func (r *Recorder) SkippingInstance(instance ccapi.ServiceInstance) {
+	r.Logger.SkippingInstance(instance)
+	r.record(instance, func(i *instanceReport) {
+		i.Outcome = OutcomeSkipped
+	})
+}
+
+func (r *Recorder) UpgradeSucceeded(instance ccapi.ServiceInstance, attempt, of int, duration time.Duration) {
+	r.Logger.UpgradeSucceeded(instance, attempt, of, duration)
+	r.record(instance, func(i *instanceReport) {
+		i.Outcome = OutcomeSucceeded
+		i.VersionAfter = instance.ServicePlanMaintenanceInfoVersion
+		i.Attempts = append(i.Attempts, attemptReport{Attempt: attempt, DurationSeconds: duration.Seconds()})
+	})
+}
+
+func (r *Recorder) UpgradeFailed(instance ccapi.ServiceInstance, attempt, of int, duration time.Duration, err error) {
+	r.Logger.UpgradeFailed(instance, attempt, of, duration, err)
+	r.record(instance, func(i *instanceReport) {
+		i.Outcome = OutcomeFailed
+		i.Attempts = append(i.Attempts, attemptReport{Attempt: attempt, DurationSeconds: duration.Seconds(), Error: err.Error()})
+	})
+}
+
+func (r *Recorder) PreHookFailed(instance ccapi.ServiceInstance, err error) {
+	r.Logger.PreHookFailed(instance, err)
+	r.record(instance, func(i *instanceReport) {
+		i.Outcome = OutcomePreHookFailed
+		i.Error = err.Error()
+	})
+}
+
+func (r *Recorder) PostHookFailed(instance ccapi.ServiceInstance, err error) {
+	r.Logger.PostHookFailed(instance, err)
+	r.record(instance, func(i *instanceReport) {
+		i.Outcome = OutcomePostHookFailed
+		i.Error = err.Error()
+	})
+}
+
+// UpgradeNotAtTargetVersion records the version that the service instance is at after the upgrade
+func (r *Recorder) UpgradeNotAtTargetVersion(instance ccapi.ServiceInstance, version string, err error) {
+	r.Logger.UpgradeNotAtTargetVersion(instance, version, err)
+	r.record(instance, func(i *instanceReport) {
+		i.Outcome = OutcomeNotAtTargetVersion
+		i.VersionAfter = version
+		i.Error = err.Error()
+	})
+}
+
+// UpgradeDegraded records that apps bound to the service instance crashed after the upgrade
+func (r *Recorder) UpgradeDegraded(instance ccapi.ServiceInstance, err error) {
+	r.Logger.UpgradeDegraded(instance, err)
+	r.record(instance, func(i *instanceReport) {
+		i.Outcome = OutcomeDegraded
+		i.Error = err.Error()
+	})
+}
+
+func (r *Recorder) InstanceBindings(instance ccapi.ServiceInstance, bindings ccapi.Bindings) {
+	r.Logger.InstanceBindings(instance, bindings)
+
+	// The bindings are logged before the upgrade starts, so are kept until the instance has an outcome
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	apps := make([]string, 0, len(bindings.Apps))
+	for _, app := range bindings.Apps {
+		apps = append(apps, app.Name)
+	}
+	r.boundApps[instance.GUID] = apps
+}
+
+// record applies a change to the report for a service instance, creating it if necessary
+func (r *Recorder) record(instance ccapi.ServiceInstance, change func(*instanceReport)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	i, ok := r.instances[instance.GUID]
+	if !ok {
+		i = newInstanceReport(instance)
+		i.BoundApps = r.boundApps[instance.GUID]
+		r.instances[instance.GUID] = i
+		r.order = append(r.order, instance.GUID)
+	}
+	change(i)
+}
+
+// Write writes the JSON report. The error from the run, if any, is included in the report.
+func (r *Recorder) Write(w io.Writer, runErr error) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	rep := report{
+		BrokerName: r.brokerName,
+		RunID:      r.runID,
+		StartedAt:  r.startedAt.UTC().Format(time.RFC3339),
+		FinishedAt: time.Now().UTC().Format(time.RFC3339),
+		Totals: totals{
+			Total:      r.total,
+			Upgradable: r.upgradable,
+			ByOutcome: map[string]int{
+				OutcomeSucceeded:          0,
+				OutcomeFailed:             0,
+				OutcomeSkipped:            0,
+				OutcomePreHookFailed:      0,
+				OutcomePostHookFailed:     0,
+				OutcomeNotAtTargetVersion: 0,
+				OutcomeDegraded:           0,
+			},
+		},
+		Instances: make([]instanceReport, 0, len(r.order)),
+	}
+	if runErr != nil {
+		rep.Error = runErr.Error()
+	}
+	if r.IncludeSummary {
+		rep.Summary = summary.ByKey(r.summarySets()...)
+	}
+
+	for _, guid := range r.order {
+		i := r.instances[guid]
+		rep.Totals.ByOutcome[i.Outcome]++
+		rep.Instances = append(rep.Instances, *i)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rep)
+}
+
+// WriteSummary writes a breakdown of the instances with each outcome as text
+func (r *Recorder) WriteSummary(w io.Writer) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return summary.WriteText(w, r.summarySets()...)
+}
+
+// summarySets groups the instances by outcome. The version of an upgraded instance is the version before the upgrade.
+func (r *Recorder) summarySets() []summary.Set {
+	sets := []summary.Set{
+		{Key: OutcomeSucceeded, Title: "upgraded service instances"},
+		{Key: OutcomeFailed, Title: "service instances which failed to upgrade"},
+		{Key: OutcomeSkipped, Title: "skipped service instances"},
+		{Key: OutcomePreHookFailed, Title: "service instances where the pre-hook failed"},
+		{Key: OutcomePostHookFailed, Title: "upgraded service instances where the post-hook failed"},
+		{Key: OutcomeNotAtTargetVersion, Title: "upgraded service instances which are not at the target version"},
+		{Key: OutcomeDegraded, Title: "upgraded service instances with crashed bound apps"},
+	}
+	for _, guid := range r.order {
+		i := r.instances[guid]
+		for s := range sets {
+			if sets[s].Key == i.Outcome {
+				sets[s].Instances = append(sets[s].Instances, i.instance)
+			}
+		}
+	}
+	return sets
+}
+
+// WriteJUnit writes a JUnit XML report with a test case for each service instance
+func (r *Recorder) WriteJUnit(w io.Writer) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	cases := make([]junit.TestCase, 0, len(r.order))
+	for _, guid := range r.order {
+		cases = append(cases, r.instances[guid].testCase())
+	}
+	return junit.Write(w, junit.NewSuite("upgrade", cases))
+}
+
+func (i instanceReport) testCase() junit.TestCase {
+	c := junit.InstanceCase(i.instance)
+	for _, a := range i.Attempts {
+		c.Time += a.DurationSeconds
+	}
+
+	var lastError string
+	if len(i.Attempts) > 0 {
+		lastError = i.Attempts[len(i.Attempts)-1].Error
+	}
+
+	switch i.Outcome {
+	case OutcomeFailed:
+		return c.WithFailure("UpgradeFailed", fmt.Sprintf("upgrade failed after %d attempts: %s", len(i.Attempts), lastError))
+	case OutcomePreHookFailed:
+		return c.WithFailure("PreHookFailed", fmt.Sprintf("pre-hook failed: %s", i.Error))
+	case OutcomePostHookFailed:
+		return c.WithFailure("PostHookFailed", fmt.Sprintf("post-hook failed: %s", i.Error))
+	case OutcomeNotAtTargetVersion:
+		return c.WithFailure("NotAtTargetVersion", fmt.Sprintf("upgrade completed, but the service instance is not at the target version: %s", i.Error))
+	case OutcomeDegraded:
+		return c.WithFailure("Degraded", fmt.Sprintf("upgrade completed, but bound apps crashed: %s", i.Error))
+	case OutcomeSkipped:
+		return c.WithSkipped("the service instance failed to create")
+	default:
+		return c
+	}
+}
+
+type report struct {
+	BrokerName string           `json:"broker"`
+	RunID      string           `json:"run_id"`
+	StartedAt  string           `json:"started_at"`
+	FinishedAt string           `json:"finished_at"`
+	Error      string           `json:"error,omitempty"`
+	Totals     totals           `json:"totals"`
+	Instances  []instanceReport `json:"instances"`
+
+	Summary map[string]summary.Summary `json:"summary,omitempty"`
+}
+
+type totals struct {
+	Total      int            `json:"total"`
+	Upgradable int            `json:"upgradable"`
+	ByOutcome  map[string]int `json:"by_outcome"`
+}
+
+type instanceReport struct {
+	GUID                string          `json:"guid"`
+	Name                string          `json:"name"`
+	OrganizationName    string          `json:"org"`
+	SpaceName           string          `json:"space"`
+	ServiceOfferingName string          `json:"offering"`
+	ServicePlanName     string          `json:"plan"`
+	Outcome             string          `json:"outcome"`
+	VersionBefore       string          `json:"version_before"`
+	VersionAfter        string          `json:"version_after"`
+	Attempts            []attemptReport `json:"attempts"`
+	Error               string          `json:"error,omitempty"`
+
+	// BoundApps is only set with --blast-radius
+	BoundApps []string `json:"bound_apps,omitzero"`
+
+	instance ccapi.ServiceInstance
+}
+
+type attemptReport struct {
+	Attempt         int     `json:"attempt"`
+	DurationSeconds float64 `json:"duration_seconds"`
+	Error           string  `json:"error,omitempty"`
+}
+
+// newInstanceReport creates a report in which the version is unchanged, until an upgrade succeeds
+func newInstanceReport(instance ccapi.ServiceInstance) *instanceReport {
+	return &instanceReport{
+		instance:            instance,
+		GUID:                instance.GUID,
+		Name:                instance.Name,
+		OrganizationName:    instance.OrganizationName,
+		SpaceName:           instance.SpaceName,
+		ServiceOfferingName: instance.ServiceOfferingName,
+		ServicePlanName:     instance.ServicePlanName,
+		VersionBefore:       instance.MaintenanceInfoVersion,
+		VersionAfter:        instance.MaintenanceInfoVersion,
+		Attempts:            []attemptReport{},
+	}
+}
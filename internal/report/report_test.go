@@ -0,0 +1,270 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/report"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ upgrader.Logger = &report.Recorder{}
+
+var _ = Describe("Recorder", func() {
+	var (
+		fakeLogger *upgraderfakes.FakeLogger
+		recorder   *report.Recorder
+	)
+
+	instance := func(name string) ccapi.ServiceInstance {
+		return ccapi.ServiceInstance{
+			GUID:                              name + "-guid",
+			Name:                              name,
+			OrganizationName:                  "fake-org",
+			SpaceName:                         "fake-space",
+			ServiceOfferingName:               "fake-offering",
+			ServicePlanName:                   "fake-plan",
+			MaintenanceInfoVersion:            "1.2.2",
+			ServicePlanMaintenanceInfoVersion: "1.2.3",
+		}
+	}
+
+	write := func(runErr error) string {
+		var buffer bytes.Buffer
+		Expect(recorder.Write(&buffer, runErr)).To(Succeed())
+		return buffer.String()
+	}
+
+	BeforeEach(func() {
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		recorder = report.New(fakeLogger, "fake-broker", "fake-run-id")
+	})
+
+	It("passes events on to the logger", func() {
+		recorder.InitialTotals(3, 2)
+		recorder.SkippingInstance(instance("a"))
+		recorder.UpgradeSucceeded(instance("b"), 1, 1, time.Second)
+		recorder.UpgradeFailed(instance("c"), 1, 1, time.Second, fmt.Errorf("boom"))
+		recorder.PreHookFailed(instance("d"), fmt.Errorf("boom"))
+		recorder.PostHookFailed(instance("b"), fmt.Errorf("boom"))
+		recorder.UpgradeNotAtTargetVersion(instance("e"), "1.2.2", fmt.Errorf("boom"))
+		recorder.UpgradeDegraded(instance("f"), fmt.Errorf("boom"))
+		recorder.InstanceBindings(instance("b"), ccapi.Bindings{})
+
+		Expect(fakeLogger.InitialTotalsCallCount()).To(Equal(1))
+		Expect(fakeLogger.SkippingInstanceCallCount()).To(Equal(1))
+		Expect(fakeLogger.UpgradeSucceededCallCount()).To(Equal(1))
+		Expect(fakeLogger.UpgradeFailedCallCount()).To(Equal(1))
+		Expect(fakeLogger.PreHookFailedCallCount()).To(Equal(1))
+		Expect(fakeLogger.PostHookFailedCallCount()).To(Equal(1))
+		Expect(fakeLogger.UpgradeNotAtTargetVersionCallCount()).To(Equal(1))
+		Expect(fakeLogger.UpgradeDegradedCallCount()).To(Equal(1))
+		Expect(fakeLogger.InstanceBindingsCallCount()).To(Equal(1))
+	})
+
+	It("reports the apps bound to each instance when they were looked up", func() {
+		recorder.InitialTotals(3, 3)
+		recorder.InstanceBindings(instance("bound"), ccapi.Bindings{Apps: []ccapi.BoundApp{{GUID: "app-guid-1", Name: "app-1"}, {GUID: "app-guid-2", Name: "app-2"}}})
+		recorder.InstanceBindings(instance("unbound"), ccapi.Bindings{})
+		recorder.UpgradeSucceeded(instance("bound"), 1, 1, time.Second)
+		recorder.UpgradeSucceeded(instance("unbound"), 1, 1, time.Second)
+		recorder.UpgradeSucceeded(instance("unknown"), 1, 1, time.Second)
+
+		var receiver struct {
+			Instances []map[string]any `json:"instances"`
+		}
+		Expect(json.Unmarshal([]byte(write(nil)), &receiver)).To(Succeed())
+		Expect(receiver.Instances).To(HaveLen(3))
+		Expect(receiver.Instances[0]).To(HaveKeyWithValue("bound_apps", []any{"app-1", "app-2"}))
+		Expect(receiver.Instances[1]).To(HaveKeyWithValue("bound_apps", BeEmpty()))
+		Expect(receiver.Instances[2]).NotTo(HaveKey("bound_apps"))
+	})
+
+	It("reports the outcome, attempts and versions of each instance", func() {
+		recorder.InitialTotals(8, 7)
+		recorder.SkippingInstance(instance("skipped"))
+		recorder.UpgradeFailed(instance("retried"), 1, 2, 1500*time.Millisecond, fmt.Errorf("boom"))
+		recorder.UpgradeSucceeded(instance("retried"), 2, 2, 2*time.Second)
+		recorder.UpgradeFailed(instance("failed"), 1, 1, time.Second, fmt.Errorf("bang"))
+		recorder.PreHookFailed(instance("no-backup"), fmt.Errorf("exit status 1"))
+		recorder.UpgradeSucceeded(instance("smoke-test-failed"), 1, 1, time.Second)
+		recorder.PostHookFailed(instance("smoke-test-failed"), fmt.Errorf("exit status 2"))
+		recorder.UpgradeSucceeded(instance("not-upgraded"), 1, 1, time.Second)
+		recorder.UpgradeNotAtTargetVersion(instance("not-upgraded"), "1.2.2", fmt.Errorf("an upgrade is still available"))
+		recorder.UpgradeSucceeded(instance("crashed"), 1, 1, time.Second)
+		recorder.UpgradeDegraded(instance("crashed"), fmt.Errorf("an app crashed"))
+
+		var receiver map[string]any
+		Expect(json.Unmarshal([]byte(write(nil)), &receiver)).To(Succeed())
+		Expect(receiver).To(HaveKeyWithValue("broker", "fake-broker"))
+		Expect(receiver).To(HaveKeyWithValue("run_id", "fake-run-id"))
+		Expect(receiver).NotTo(HaveKey("error"))
+		Expect(time.Parse(time.RFC3339, receiver["started_at"].(string))).To(BeTemporally("~", time.Now(), time.Minute))
+		Expect(time.Parse(time.RFC3339, receiver["finished_at"].(string))).To(BeTemporally("~", time.Now(), time.Minute))
+
+		delete(receiver, "started_at")
+		delete(receiver, "finished_at")
+		Expect(json.Marshal(receiver)).To(MatchJSON(`{
+			"broker": "fake-broker",
+			"run_id": "fake-run-id",
+			"totals": {
+				"total": 8,
+				"upgradable": 7,
+				"by_outcome": {"succeeded": 1, "failed": 1, "skipped": 1, "pre_hook_failed": 1, "post_hook_failed": 1, "not_at_target_version": 1, "degraded": 1}
+			},
+			"instances": [
+				{
+					"guid": "skipped-guid", "name": "skipped", "org": "fake-org", "space": "fake-space", "offering": "fake-offering", "plan": "fake-plan",
+					"outcome": "skipped", "version_before": "1.2.2", "version_after": "1.2.2", "attempts": []
+				},
+				{
+					"guid": "retried-guid", "name": "retried", "org": "fake-org", "space": "fake-space", "offering": "fake-offering", "plan": "fake-plan",
+					"outcome": "succeeded", "version_before": "1.2.2", "version_after": "1.2.3",
+					"attempts": [{"attempt": 1, "duration_seconds": 1.5, "error": "boom"}, {"attempt": 2, "duration_seconds": 2}]
+				},
+				{
+					"guid": "failed-guid", "name": "failed", "org": "fake-org", "space": "fake-space", "offering": "fake-offering", "plan": "fake-plan",
+					"outcome": "failed", "version_before": "1.2.2", "version_after": "1.2.2",
+					"attempts": [{"attempt": 1, "duration_seconds": 1, "error": "bang"}]
+				},
+				{
+					"guid": "no-backup-guid", "name": "no-backup", "org": "fake-org", "space": "fake-space", "offering": "fake-offering", "plan": "fake-plan",
+					"outcome": "pre_hook_failed", "version_before": "1.2.2", "version_after": "1.2.2", "attempts": [], "error": "exit status 1"
+				},
+				{
+					"guid": "smoke-test-failed-guid", "name": "smoke-test-failed", "org": "fake-org", "space": "fake-space", "offering": "fake-offering", "plan": "fake-plan",
+					"outcome": "post_hook_failed", "version_before": "1.2.2", "version_after": "1.2.3",
+					"attempts": [{"attempt": 1, "duration_seconds": 1}], "error": "exit status 2"
+				},
+				{
+					"guid": "not-upgraded-guid", "name": "not-upgraded", "org": "fake-org", "space": "fake-space", "offering": "fake-offering", "plan": "fake-plan",
+					"outcome": "not_at_target_version", "version_before": "1.2.2", "version_after": "1.2.2",
+					"attempts": [{"attempt": 1, "duration_seconds": 1}], "error": "an upgrade is still available"
+				},
+				{
+					"guid": "crashed-guid", "name": "crashed", "org": "fake-org", "space": "fake-space", "offering": "fake-offering", "plan": "fake-plan",
+					"outcome": "degraded", "version_before": "1.2.2", "version_after": "1.2.3",
+					"attempts": [{"attempt": 1, "duration_seconds": 1}], "error": "an app crashed"
+				}
+			]
+		}`))
+	})
+
+	It("writes a JUnit test case for each instance", func() {
+		recorder.SkippingInstance(instance("skipped"))
+		recorder.UpgradeFailed(instance("retried"), 1, 2, 1500*time.Millisecond, fmt.Errorf("boom"))
+		recorder.UpgradeSucceeded(instance("retried"), 2, 2, 2*time.Second)
+		recorder.UpgradeFailed(instance("failed"), 1, 1, time.Second, fmt.Errorf("bang"))
+		recorder.PreHookFailed(instance("no-backup"), fmt.Errorf("exit status 1"))
+		recorder.UpgradeSucceeded(instance("smoke-test-failed"), 1, 1, time.Second)
+		recorder.PostHookFailed(instance("smoke-test-failed"), fmt.Errorf("exit status 2"))
+		recorder.UpgradeSucceeded(instance("not-upgraded"), 1, 1, time.Second)
+		recorder.UpgradeNotAtTargetVersion(instance("not-upgraded"), "1.2.2", fmt.Errorf("an upgrade is still available"))
+		recorder.UpgradeSucceeded(instance("crashed"), 1, 1, time.Second)
+		recorder.UpgradeDegraded(instance("crashed"), fmt.Errorf("an app crashed"))
+
+		var buffer bytes.Buffer
+		Expect(recorder.WriteJUnit(&buffer)).To(Succeed())
+
+		var receiver struct {
+			Suites []struct {
+				Name     string `xml:"name,attr"`
+				Tests    int    `xml:"tests,attr"`
+				Failures int    `xml:"failures,attr"`
+				Skipped  int    `xml:"skipped,attr"`
+				Cases    []struct {
+					Name    string  `xml:"name,attr"`
+					Time    float64 `xml:"time,attr"`
+					Failure *struct {
+						Message string `xml:"message,attr"`
+						Type    string `xml:"type,attr"`
+					} `xml:"failure"`
+					Skipped *struct{} `xml:"skipped"`
+				} `xml:"testcase"`
+			} `xml:"testsuite"`
+		}
+		Expect(xml.Unmarshal(buffer.Bytes(), &receiver)).To(Succeed())
+		Expect(receiver.Suites).To(HaveLen(1))
+
+		suite := receiver.Suites[0]
+		Expect(suite.Name).To(Equal("upgrade"))
+		Expect(suite.Tests).To(Equal(7))
+		Expect(suite.Failures).To(Equal(5))
+		Expect(suite.Skipped).To(Equal(1))
+
+		Expect(suite.Cases[0].Name).To(Equal("fake-org/fake-space/skipped"))
+		Expect(suite.Cases[0].Skipped).NotTo(BeNil())
+		Expect(suite.Cases[1].Failure).To(BeNil())
+		Expect(suite.Cases[1].Time).To(Equal(3.5))
+		Expect(suite.Cases[2].Failure.Type).To(Equal("UpgradeFailed"))
+		Expect(suite.Cases[2].Failure.Message).To(Equal(`service instance "failed" (org "fake-org", space "fake-space", offering "fake-offering", plan "fake-plan"): upgrade failed after 1 attempts: bang`))
+		Expect(suite.Cases[3].Failure.Type).To(Equal("PreHookFailed"))
+		Expect(suite.Cases[3].Failure.Message).To(HaveSuffix(": pre-hook failed: exit status 1"))
+		Expect(suite.Cases[4].Failure.Type).To(Equal("PostHookFailed"))
+		Expect(suite.Cases[4].Failure.Message).To(HaveSuffix(": post-hook failed: exit status 2"))
+		Expect(suite.Cases[5].Failure.Type).To(Equal("NotAtTargetVersion"))
+		Expect(suite.Cases[5].Failure.Message).To(HaveSuffix(": upgrade completed, but the service instance is not at the target version: an upgrade is still available"))
+		Expect(suite.Cases[6].Failure.Type).To(Equal("Degraded"))
+		Expect(suite.Cases[6].Failure.Message).To(HaveSuffix(": upgrade completed, but bound apps crashed: an app crashed"))
+	})
+
+	It("includes the error from the run", func() {
+		var receiver struct {
+			Error     string `json:"error"`
+			Instances []any  `json:"instances"`
+		}
+		Expect(json.Unmarshal([]byte(write(fmt.Errorf("no service plans available for broker: fake-broker"))), &receiver)).To(Succeed())
+		Expect(receiver.Error).To(Equal("no service plans available for broker: fake-broker"))
+		Expect(receiver.Instances).To(BeEmpty())
+	})
+
+	It("omits the summary unless it is included", func() {
+		recorder.UpgradeSucceeded(instance("a"), 1, 1, time.Second)
+
+		var receiver map[string]any
+		Expect(json.Unmarshal([]byte(write(nil)), &receiver)).To(Succeed())
+		Expect(receiver).NotTo(HaveKey("summary"))
+	})
+
+	When("the summary is included", func() {
+		BeforeEach(func() {
+			recorder.IncludeSummary = true
+			recorder.UpgradeSucceeded(instance("a"), 1, 1, time.Second)
+			recorder.UpgradeSucceeded(instance("b"), 1, 1, time.Second)
+			recorder.UpgradeFailed(instance("c"), 1, 1, time.Second, fmt.Errorf("boom"))
+		})
+
+		It("adds a summary for each outcome to the report", func() {
+			var receiver struct {
+				Summary map[string]struct {
+					Total     int `json:"total"`
+					ByVersion []struct {
+						Name  string `json:"name"`
+						Count int    `json:"count"`
+					} `json:"by_version"`
+				} `json:"summary"`
+			}
+			Expect(json.Unmarshal([]byte(write(nil)), &receiver)).To(Succeed())
+			Expect(receiver.Summary).To(HaveLen(7))
+			Expect(receiver.Summary[report.OutcomeSucceeded].Total).To(Equal(2))
+			Expect(receiver.Summary[report.OutcomeSucceeded].ByVersion[0].Name).To(Equal("1.2.2"))
+			Expect(receiver.Summary[report.OutcomeFailed].Total).To(Equal(1))
+			Expect(receiver.Summary[report.OutcomeSkipped].Total).To(BeZero())
+		})
+
+		It("writes the summary as text", func() {
+			var buffer bytes.Buffer
+			Expect(recorder.WriteSummary(&buffer)).To(Succeed())
+			Expect(buffer.String()).To(ContainSubstring("Summary of upgraded service instances: 2\n"))
+			Expect(buffer.String()).To(ContainSubstring("Summary of service instances which failed to upgrade: 1\n"))
+			Expect(buffer.String()).To(ContainSubstring("Summary of skipped service instances: 0\n"))
+		})
+	})
+})
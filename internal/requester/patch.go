@@ -10,7 +10,9 @@ import (
 	"code.cloudfoundry.org/jsonry"
 )
 
-// Patch performs an HTTP PATCH. It takes a struct as input data.
+// Patch performs an HTTP PATCH. It takes a struct as input data. CAPI responds with
+// "202 Accepted" when an asynchronous operation was started, and "200 OK" when the change
+// was made synchronously, for example when only updating metadata.
 func (r Requester) Patch(url string, data any) error {
 	d, err := jsonry.Marshal(data)
 	if err != nil {
@@ -34,7 +36,7 @@ func (r Requester) Patch(url string, data any) error {
 	}
 	r.Logger.Printf("Response status %s", response.Status)
 
-	if response.StatusCode != http.StatusAccepted {
+	if response.StatusCode != http.StatusAccepted && response.StatusCode != http.StatusOK {
 		defer response.Body.Close()
 		data, err := io.ReadAll(response.Body)
 		if err != nil {
@@ -112,6 +112,22 @@ var _ = Describe("Requester", func() {
 			})
 		})
 
+		When("the change is made synchronously", func() {
+			BeforeEach(func() {
+				fakeServer.AppendHandlers(
+					ghttp.CombineHandlers(
+						ghttp.VerifyRequest("PATCH", "/test-endpoint", ""),
+						ghttp.RespondWith(http.StatusOK, `{}`, nil),
+					),
+				)
+			})
+
+			It("succeeds", func() {
+				err := testRequester.Patch("test-endpoint", testBody)
+				Expect(err).NotTo(HaveOccurred())
+			})
+		})
+
 		When("the patch request fails", func() {
 			When("fails with unexpected error", func() {
 				BeforeEach(func() {
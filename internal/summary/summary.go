@@ -0,0 +1,115 @@
+// Package summary breaks down a set of service instances by org, space, service offering, plan and version,
+// with the count and percentage of the instances in each group.
+package summary
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"text/tabwriter"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+)
+
+// Set is a named set of service instances, such as those with an upgrade available
+type Set struct {
+	Key       string
+	Title     string
+	Instances []ccapi.ServiceInstance
+}
+
+type Summary struct {
+	Total      int     `json:"total"`
+	ByOrg      []Group `json:"by_org"`
+	BySpace    []Group `json:"by_space"`
+	ByOffering []Group `json:"by_offering"`
+	ByPlan     []Group `json:"by_plan"`
+	ByVersion  []Group `json:"by_version"`
+}
+
+type Group struct {
+	Name    string  `json:"name"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// New summarizes the service instances. Space and plan names are only unique within an org
+// or service offering, so they are qualified by it.
+func New(instances []ccapi.ServiceInstance) Summary {
+	return Summary{
+		Total:      len(instances),
+		ByOrg:      groupBy(instances, func(i ccapi.ServiceInstance) string { return i.OrganizationName }),
+		BySpace:    groupBy(instances, func(i ccapi.ServiceInstance) string { return i.OrganizationName + "/" + i.SpaceName }),
+		ByOffering: groupBy(instances, func(i ccapi.ServiceInstance) string { return i.ServiceOfferingName }),
+		ByPlan:     groupBy(instances, func(i ccapi.ServiceInstance) string { return i.ServiceOfferingName + "/" + i.ServicePlanName }),
+		ByVersion:  groupBy(instances, func(i ccapi.ServiceInstance) string { return i.MaintenanceInfoVersion }),
+	}
+}
+
+// ByKey summarizes each set, for JSON output
+func ByKey(sets ...Set) map[string]Summary {
+	summaries := make(map[string]Summary, len(sets))
+	for _, s := range sets {
+		summaries[s.Key] = New(s.Instances)
+	}
+	return summaries
+}
+
+// WriteText writes a summary of each set, in order
+func WriteText(w io.Writer, sets ...Set) error {
+	for _, s := range sets {
+		if err := New(s.Instances).WriteText(w, s.Title); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteText writes the breakdowns as aligned columns, largest group first
+func (s Summary) WriteText(w io.Writer, title string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Summary of %s: %d\n", title, s.Total)
+	for _, b := range []struct {
+		name   string
+		groups []Group
+	}{
+		{name: "org", groups: s.ByOrg},
+		{name: "space", groups: s.BySpace},
+		{name: "service offering", groups: s.ByOffering},
+		{name: "service plan", groups: s.ByPlan},
+		{name: "version", groups: s.ByVersion},
+	} {
+		if len(b.groups) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(tw, "  By %s:\n", b.name)
+		for _, g := range b.groups {
+			fmt.Fprintf(tw, "    %s\t%d\t%.1f%%\n", g.Name, g.Count, g.Percent)
+		}
+	}
+	fmt.Fprintln(tw)
+	return tw.Flush()
+}
+
+func groupBy(instances []ccapi.ServiceInstance, key func(ccapi.ServiceInstance) string) []Group {
+	counts := make(map[string]int)
+	for _, instance := range instances {
+		counts[key(instance)]++
+	}
+
+	groups := make([]Group, 0, len(counts))
+	for name, count := range counts {
+		groups = append(groups, Group{Name: name, Count: count, Percent: percent(count, len(instances))})
+	}
+	slices.SortFunc(groups, func(a, b Group) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Name, b.Name))
+	})
+	return groups
+}
+
+// percent is rounded to one decimal place
+func percent(count, total int) float64 {
+	return math.Round(float64(count)*1000/float64(total)) / 10
+}
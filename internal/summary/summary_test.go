@@ -0,0 +1,119 @@
+package summary_test
+
+import (
+	"strings"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/summary"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("summary", func() {
+	var instances []ccapi.ServiceInstance
+
+	BeforeEach(func() {
+		instance := func(org, space, offering, plan, version string) ccapi.ServiceInstance {
+			return ccapi.ServiceInstance{
+				OrganizationName:       org,
+				SpaceName:              space,
+				ServiceOfferingName:    offering,
+				ServicePlanName:        plan,
+				MaintenanceInfoVersion: version,
+			}
+		}
+
+		instances = []ccapi.ServiceInstance{
+			instance("org-x", "dev", "mysql", "small", "1.2.1"),
+			instance("org-x", "prod", "mysql", "large", "1.2.2"),
+			instance("org-x", "prod", "mysql", "large", "1.2.2"),
+			instance("org-y", "dev", "redis", "small", "1.2.2"),
+		}
+	})
+
+	It("groups by each dimension, largest first", func() {
+		Expect(summary.New(instances)).To(Equal(summary.Summary{
+			Total: 4,
+			ByOrg: []summary.Group{
+				{Name: "org-x", Count: 3, Percent: 75},
+				{Name: "org-y", Count: 1, Percent: 25},
+			},
+			BySpace: []summary.Group{
+				{Name: "org-x/prod", Count: 2, Percent: 50},
+				{Name: "org-x/dev", Count: 1, Percent: 25},
+				{Name: "org-y/dev", Count: 1, Percent: 25},
+			},
+			ByOffering: []summary.Group{
+				{Name: "mysql", Count: 3, Percent: 75},
+				{Name: "redis", Count: 1, Percent: 25},
+			},
+			ByPlan: []summary.Group{
+				{Name: "mysql/large", Count: 2, Percent: 50},
+				{Name: "mysql/small", Count: 1, Percent: 25},
+				{Name: "redis/small", Count: 1, Percent: 25},
+			},
+			ByVersion: []summary.Group{
+				{Name: "1.2.2", Count: 3, Percent: 75},
+				{Name: "1.2.1", Count: 1, Percent: 25},
+			},
+		}))
+	})
+
+	It("rounds percentages to one decimal place", func() {
+		Expect(summary.New(instances[1:]).ByOrg).To(Equal([]summary.Group{
+			{Name: "org-x", Count: 2, Percent: 66.7},
+			{Name: "org-y", Count: 1, Percent: 33.3},
+		}))
+	})
+
+	It("summarizes an empty set", func() {
+		Expect(summary.New(nil)).To(Equal(summary.Summary{
+			ByOrg:      []summary.Group{},
+			BySpace:    []summary.Group{},
+			ByOffering: []summary.Group{},
+			ByPlan:     []summary.Group{},
+			ByVersion:  []summary.Group{},
+		}))
+	})
+
+	It("summarizes each set by key", func() {
+		summaries := summary.ByKey(
+			summary.Set{Key: "first", Instances: instances[:1]},
+			summary.Set{Key: "rest", Instances: instances[1:]},
+		)
+		Expect(summaries).To(HaveLen(2))
+		Expect(summaries["first"].Total).To(Equal(1))
+		Expect(summaries["rest"].Total).To(Equal(3))
+	})
+
+	It("writes text", func() {
+		var b strings.Builder
+		Expect(summary.WriteText(&b,
+			summary.Set{Title: "service instances with an upgrade available", Instances: instances},
+			summary.Set{Title: "service instances which failed to create"},
+		)).To(Succeed())
+
+		Expect(b.String()).To(Equal(`Summary of service instances with an upgrade available: 4
+  By org:
+    org-x  3  75.0%
+    org-y  1  25.0%
+  By space:
+    org-x/prod  2  50.0%
+    org-x/dev   1  25.0%
+    org-y/dev   1  25.0%
+  By service offering:
+    mysql  3  75.0%
+    redis  1  25.0%
+  By service plan:
+    mysql/large  2  50.0%
+    mysql/small  1  25.0%
+    redis/small  1  25.0%
+  By version:
+    1.2.2  3  75.0%
+    1.2.1  1  25.0%
+
+Summary of service instances which failed to create: 0
+
+`))
+	})
+})
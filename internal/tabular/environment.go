@@ -0,0 +1,14 @@
+package tabular
+
+import (
+	"os"
+	"strconv"
+)
+
+func widthFromEnvironment() int {
+	width, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || width < 0 {
+		return 0
+	}
+	return width
+}
@@ -0,0 +1,108 @@
+// Package tabular writes rows of values as CSV, or as a table with aligned columns that is truncated to fit the terminal.
+package tabular
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// gap is the space between two columns of a table
+	gap = "  "
+
+	// minimumWidth is the narrowest that a column is truncated to when fitting a table to the terminal
+	minimumWidth = 8
+
+	// ellipsis marks a value that has been truncated
+	ellipsis = "…"
+)
+
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// WriteCSV writes the header and rows as CSV
+func WriteCSV(w io.Writer, t Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.Header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.Rows); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WriteTable writes the header and rows as aligned columns. When the width is greater than zero, the widest
+// columns are truncated until each line fits within the width, or until they cannot be truncated any further.
+func WriteTable(w io.Writer, t Table, width int) error {
+	widths := columnWidths(t)
+	if width > 0 {
+		fit(widths, width)
+	}
+
+	for _, row := range append([][]string{t.Header}, t.Rows...) {
+		if _, err := fmt.Fprintln(w, formatRow(row, widths)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func columnWidths(t Table) []int {
+	widths := make([]int, len(t.Header))
+	for _, row := range append([][]string{t.Header}, t.Rows...) {
+		for i, value := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(value))
+		}
+	}
+	return widths
+}
+
+// fit reduces the widest column one character at a time, so that the columns share the available space
+func fit(widths []int, width int) {
+	for lineWidth(widths) > width {
+		widest := 0
+		for i := range widths {
+			if widths[i] > widths[widest] {
+				widest = i
+			}
+		}
+		if widths[widest] <= minimumWidth {
+			return
+		}
+		widths[widest]--
+	}
+}
+
+func lineWidth(widths []int) int {
+	total := len(gap) * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	return total
+}
+
+func formatRow(row []string, widths []int) string {
+	var b strings.Builder
+	for i, value := range row {
+		value = truncate(value, widths[i])
+		b.WriteString(value)
+		if i < len(row)-1 {
+			b.WriteString(strings.Repeat(" ", widths[i]-utf8.RuneCountInString(value)))
+			b.WriteString(gap)
+		}
+	}
+	return b.String()
+}
+
+func truncate(value string, width int) string {
+	if utf8.RuneCountInString(value) <= width {
+		return value
+	}
+	return string([]rune(value)[:width-1]) + ellipsis
+}
@@ -0,0 +1,70 @@
+package tabular_test
+
+import (
+	"bytes"
+	"upgrade-all-services-cli-plugin/internal/tabular"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("tabular", func() {
+	var table tabular.Table
+
+	BeforeEach(func() {
+		table = tabular.Table{
+			Header: []string{"name", "guid", "version"},
+			Rows: [][]string{
+				{"my-db", "2a1b6f3e-4c0d-4a8e-9f2b-7d5c3e1a0b9f", "1.2.3"},
+				{"a-much-longer-name", "0c9e8d7f-6a5b-4c3d-2e1f-0a9b8c7d6e5f", "1.2.10"},
+			},
+		}
+	})
+
+	Describe("WriteCSV", func() {
+		It("writes the header and rows", func() {
+			table.Rows = append(table.Rows, []string{"with,comma", "guid", `"quoted"`})
+
+			var buf bytes.Buffer
+			Expect(tabular.WriteCSV(&buf, table)).To(Succeed())
+			Expect(buf.String()).To(Equal(
+				"name,guid,version\n" +
+					"my-db,2a1b6f3e-4c0d-4a8e-9f2b-7d5c3e1a0b9f,1.2.3\n" +
+					"a-much-longer-name,0c9e8d7f-6a5b-4c3d-2e1f-0a9b8c7d6e5f,1.2.10\n" +
+					`"with,comma",guid,"""quoted"""` + "\n",
+			))
+		})
+	})
+
+	Describe("WriteTable", func() {
+		It("aligns the columns", func() {
+			var buf bytes.Buffer
+			Expect(tabular.WriteTable(&buf, table, 0)).To(Succeed())
+			Expect(buf.String()).To(Equal(
+				"name                guid                                  version\n" +
+					"my-db               2a1b6f3e-4c0d-4a8e-9f2b-7d5c3e1a0b9f  1.2.3\n" +
+					"a-much-longer-name  0c9e8d7f-6a5b-4c3d-2e1f-0a9b8c7d6e5f  1.2.10\n",
+			))
+		})
+
+		It("truncates the widest columns to fit the width", func() {
+			var buf bytes.Buffer
+			Expect(tabular.WriteTable(&buf, table, 40)).To(Succeed())
+			Expect(buf.String()).To(Equal(
+				"name            guid             version\n" +
+					"my-db           2a1b6f3e-4c0d-…  1.2.3\n" +
+					"a-much-longer…  0c9e8d7f-6a5b-…  1.2.10\n",
+			))
+		})
+
+		It("does not truncate columns below a minimum width", func() {
+			var buf bytes.Buffer
+			Expect(tabular.WriteTable(&buf, table, 10)).To(Succeed())
+			Expect(buf.String()).To(Equal(
+				"name      guid      version\n" +
+					"my-db     2a1b6f3…  1.2.3\n" +
+					"a-much-…  0c9e8d7…  1.2.10\n",
+			))
+		})
+	})
+})
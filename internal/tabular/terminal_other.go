@@ -0,0 +1,8 @@
+//go:build !unix
+
+package tabular
+
+// TerminalWidth returns the width set by the COLUMNS environment variable, or zero when it is not set
+func TerminalWidth() int {
+	return widthFromEnvironment()
+}
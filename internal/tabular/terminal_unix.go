@@ -0,0 +1,18 @@
+//go:build unix
+
+package tabular
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// TerminalWidth returns the width of the terminal that stdout is written to. When stdout is not a terminal, it
+// returns the width set by the COLUMNS environment variable, or zero when that is not set.
+func TerminalWidth() int {
+	if ws, err := unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ); err == nil {
+		return int(ws.Col)
+	}
+	return widthFromEnvironment()
+}
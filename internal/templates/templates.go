@@ -0,0 +1,184 @@
+// Package templates renders the service instances found by a dry run or a check through a user-supplied Go template.
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+)
+
+// Data is the value that a template is executed with
+type Data struct {
+	BrokerName               string
+	MinVersion               string
+	VersionConstraint        string
+	All                      []ccapi.ServiceInstance
+	Upgradeable              []ccapi.ServiceInstance
+	DeactivatedPlan          []ccapi.ServiceInstance
+	CreateFailed             []ccapi.ServiceInstance
+	BelowMinVersion          []ccapi.ServiceInstance
+	OutsideVersionConstraint []ccapi.ServiceInstance
+	UnknownVersion           []ccapi.ServiceInstance
+	Replacements             map[string]Replacement
+}
+
+// Replacement is where a service instance on a deactivated plan could be moved, keyed by service instance GUID in
+// the data. The candidates are the active plans of the same service offering, and the plan is the suggested one,
+// or empty when there is a choice, in which case there is no command or migration entry.
+type Replacement struct {
+	Candidates []string
+	Plan       string
+	Preferred  bool
+	Command    string
+	Migration  string
+}
+
+type Totals struct {
+	All                      int
+	Upgradeable              int
+	DeactivatedPlan          int
+	CreateFailed             int
+	BelowMinVersion          int
+	OutsideVersionConstraint int
+	UnknownVersion           int
+}
+
+// Totals counts the service instances in each group
+func (d Data) Totals() Totals {
+	return Totals{
+		All:                      len(d.All),
+		Upgradeable:              len(d.Upgradeable),
+		DeactivatedPlan:          len(d.DeactivatedPlan),
+		CreateFailed:             len(d.CreateFailed),
+		BelowMinVersion:          len(d.BelowMinVersion),
+		OutsideVersionConstraint: len(d.OutsideVersionConstraint),
+		UnknownVersion:           len(d.UnknownVersion),
+	}
+}
+
+// ParseFile reads and parses a template file, so that mistakes are reported before any work is done
+func ParseFile(path string) (*template.Template, error) {
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template file: %w", err)
+	}
+
+	t, err := Parse(filepath.Base(path), string(text))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template file: %w", err)
+	}
+	return t, nil
+}
+
+// Parse parses the text of a template with the helper functions available
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcs()).Option("missingkey=error").Parse(text)
+}
+
+// Render executes the template
+func Render(w io.Writer, t *template.Template, data Data) error {
+	if err := t.Execute(w, data); err != nil {
+		return fmt.Errorf("error rendering template: %w", err)
+	}
+	return nil
+}
+
+func funcs() template.FuncMap {
+	return template.FuncMap{
+		"join":    func(sep string, values []string) string { return strings.Join(values, sep) },
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"trim":    strings.TrimSpace,
+		"replace": func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"pad":     func(width int, s string) string { return fmt.Sprintf("%-*s", width, s) },
+		"plural":  plural,
+		"json":    toJSON,
+		"now":     time.Now,
+		"field":   field,
+		"pluck":   pluck,
+		"groupBy": groupBy,
+		"sortBy":  sortBy,
+	}
+}
+
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// field returns a value of the service instance, using the same names as the --columns flag
+func field(name string, instance ccapi.ServiceInstance) (string, error) {
+	column, err := parseColumn(name)
+	if err != nil {
+		return "", err
+	}
+	return column.Value(instance), nil
+}
+
+func pluck(name string, instances []ccapi.ServiceInstance) ([]string, error) {
+	column, err := parseColumn(name)
+	if err != nil {
+		return nil, err
+	}
+	return slicex.Map(instances, column.Value), nil
+}
+
+// groupBy returns a map, which templates range over in key order
+func groupBy(name string, instances []ccapi.ServiceInstance) (map[string][]ccapi.ServiceInstance, error) {
+	column, err := parseColumn(name)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]ccapi.ServiceInstance)
+	for _, instance := range instances {
+		key := column.Value(instance)
+		groups[key] = append(groups[key], instance)
+	}
+	return groups, nil
+}
+
+func sortBy(name string, instances []ccapi.ServiceInstance) ([]ccapi.ServiceInstance, error) {
+	column, err := parseColumn(name)
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := slices.Clone(instances)
+	slices.SortStableFunc(sorted, func(a, b ccapi.ServiceInstance) int {
+		return strings.Compare(column.Value(a), column.Value(b))
+	})
+	return sorted, nil
+}
+
+// parseColumn rejects the status and replacement columns, which are not fields of a service instance
+func parseColumn(name string) (config.Column, error) {
+	column, err := config.ParseColumn(name)
+	switch {
+	case err != nil:
+		return "", err
+	case column == config.StatusColumn, column == config.ReplacementCandidatesColumn, column == config.ReplacementPlanColumn:
+		return "", fmt.Errorf("the %q field is not available in templates", column)
+	default:
+		return column, nil
+	}
+}
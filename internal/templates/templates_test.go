@@ -0,0 +1,119 @@
+package templates_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/templates"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("templates", func() {
+	var data templates.Data
+
+	render := func(text string) (string, error) {
+		t, err := templates.Parse("test", text)
+		Expect(err).NotTo(HaveOccurred())
+
+		var b strings.Builder
+		err = templates.Render(&b, t, data)
+		return b.String(), err
+	}
+
+	BeforeEach(func() {
+		db := ccapi.ServiceInstance{Name: "db", OrganizationName: "org-b", SpaceName: "prod", MaintenanceInfoVersion: "1.0.0"}
+		cache := ccapi.ServiceInstance{Name: "cache", OrganizationName: "org-a", SpaceName: "dev", MaintenanceInfoVersion: "1.1.0"}
+		queue := ccapi.ServiceInstance{Name: "queue", OrganizationName: "org-b", SpaceName: "dev", MaintenanceInfoVersion: "1.2.0"}
+
+		data = templates.Data{
+			BrokerName:      "my-broker",
+			All:             []ccapi.ServiceInstance{db, cache, queue},
+			Upgradeable:     []ccapi.ServiceInstance{db, cache},
+			DeactivatedPlan: []ccapi.ServiceInstance{queue},
+		}
+	})
+
+	It("renders the groups and totals", func() {
+		output, err := render(`{{.BrokerName}}: {{.Totals.Upgradeable}} of {{.Totals.All}} {{plural .Totals.All "instance" "instances"}} to upgrade, {{.Totals.DeactivatedPlan}} on deactivated plans, {{.Totals.CreateFailed}} failed to create`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("my-broker: 2 of 3 instances to upgrade, 1 on deactivated plans, 0 failed to create"))
+	})
+
+	It("renders the fields of service instances", func() {
+		output, err := render(`{{range .All}}{{.Name}}={{.MaintenanceInfoVersion}} {{end}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("db=1.0.0 cache=1.1.0 queue=1.2.0 "))
+	})
+
+	It("has string helpers", func() {
+		output, err := render(`{{upper "a"}} {{lower "B"}} [{{trim "  c  "}}] {{replace "-" "_" "d-e"}} [{{pad 4 "f"}}] {{json .BrokerName}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal(`A b [c] d_e [f   ] "my-broker"`))
+	})
+
+	It("plucks and joins fields by column name", func() {
+		output, err := render(`{{join ", " (pluck "name" .Upgradeable)}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("db, cache"))
+	})
+
+	It("groups by column name in key order", func() {
+		output, err := render(`{{range $org, $instances := groupBy "org" .All}}{{$org}}: {{len $instances}}
+{{end}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("org-a: 1\norg-b: 2\n"))
+	})
+
+	It("sorts by column name", func() {
+		output, err := render(`{{range sortBy "space" .All}}{{field "space" .}}/{{field "name" .}} {{end}}`)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output).To(Equal("dev/cache dev/queue prod/db "))
+	})
+
+	It("fails for an unknown column", func() {
+		_, err := render(`{{pluck "colour" .All}}`)
+		Expect(err).To(MatchError(ContainSubstring(`error rendering template: template: test:1:2: executing "test" at <pluck "colour" .All>: error calling pluck: invalid column "colour"`)))
+	})
+
+	It("fails for the status column", func() {
+		_, err := render(`{{groupBy "status" .All}}`)
+		Expect(err).To(MatchError(ContainSubstring(`the "status" field is not available in templates`)))
+	})
+
+	It("fails for the replacement columns", func() {
+		_, err := render(`{{groupBy "replacement_plan" .All}}`)
+		Expect(err).To(MatchError(ContainSubstring(`the "replacement_plan" field is not available in templates`)))
+	})
+
+	It("fails for an unknown field", func() {
+		_, err := render(`{{.Colour}}`)
+		Expect(err).To(MatchError(ContainSubstring("can't evaluate field Colour")))
+	})
+
+	Describe("ParseFile", func() {
+		It("parses a template file", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "report.tmpl")
+			Expect(os.WriteFile(path, []byte(`{{len .All}}`), 0o600)).To(Succeed())
+
+			t, err := templates.ParseFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.Name()).To(Equal("report.tmpl"))
+		})
+
+		It("fails when the file cannot be read", func() {
+			_, err := templates.ParseFile(filepath.Join(GinkgoT().TempDir(), "missing.tmpl"))
+			Expect(err).To(MatchError(ContainSubstring("error reading template file: ")))
+		})
+
+		It("fails when the template is invalid", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "report.tmpl")
+			Expect(os.WriteFile(path, []byte(`{{range .All}}`), 0o600)).To(Succeed())
+
+			_, err := templates.ParseFile(path)
+			Expect(err).To(MatchError(ContainSubstring("error parsing template file: template: report.tmpl:1: unexpected EOF")))
+		})
+	})
+})
@@ -0,0 +1,151 @@
+package upgrader_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("--annotate", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{{GUID: "fake-plan-guid", MaintenanceInfoVersion: "1.2.3"}}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{Name: "fake-instance", GUID: "fake-instance-guid", UpgradeAvailable: true, MaintenanceInfoVersion: "1.2.2", ServicePlanMaintenanceInfoVersion: "1.2.3"},
+		}, nil)
+		fakeCFClient.GetServiceInstanceReturns(ccapi.ServiceInstance{GUID: "fake-instance-guid", MaintenanceInfoVersion: "1.2.3"}, nil)
+
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		fakeLogger.HasUpgradeSucceededReturns(true)
+	})
+
+	It("annotates upgraded instances with the provenance of the upgrade", func() {
+		err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+			BrokerName:       fakeBrokerName,
+			ParallelUpgrades: 1,
+			Annotate:         true,
+			Username:         "fake-user",
+			RunID:            "fake-run-id",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeCFClient.AnnotateServiceInstanceCallCount()).To(Equal(1))
+		guid, provenance := fakeCFClient.AnnotateServiceInstanceArgsForCall(0)
+		Expect(guid).To(Equal("fake-instance-guid"))
+		Expect(provenance.UpgradedBy).To(Equal("fake-user"))
+		Expect(provenance.PreviousVersion).To(Equal("1.2.2"))
+		Expect(provenance.RunID).To(Equal("fake-run-id"))
+		Expect(time.Parse(time.RFC3339, provenance.UpgradedAt)).To(BeTemporally("~", time.Now(), time.Minute))
+	})
+
+	It("does not annotate instances that failed to upgrade", func() {
+		fakeCFClient.UpgradeServiceInstanceReturns(fmt.Errorf("boom"))
+
+		_ = upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+			BrokerName:       fakeBrokerName,
+			ParallelUpgrades: 1,
+			Annotate:         true,
+		})
+
+		Expect(fakeCFClient.AnnotateServiceInstanceCallCount()).To(BeZero())
+	})
+
+	It("logs annotation failures without failing the upgrade", func() {
+		fakeCFClient.AnnotateServiceInstanceReturns(fmt.Errorf("boom"))
+
+		err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+			BrokerName:       fakeBrokerName,
+			ParallelUpgrades: 1,
+			Annotate:         true,
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(Equal(1))
+		Expect(fakeLogger.UpgradeSucceededCallCount()).To(Equal(1))
+		Expect(fakeLogger.UpgradeFailedCallCount()).To(BeZero())
+
+		format, args := fakeLogger.PrintfArgsForCall(fakeLogger.PrintfCallCount() - 1)
+		Expect(fmt.Sprintf(format, args...)).To(Equal(`failed to annotate instance: "fake-instance" guid: "fake-instance-guid": boom`))
+	})
+
+	It("does not annotate unless requested", func() {
+		err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+			BrokerName:       fakeBrokerName,
+			ParallelUpgrades: 1,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fakeCFClient.AnnotateServiceInstanceCallCount()).To(BeZero())
+	})
+
+	Describe("reading back the provenance", func() {
+		BeforeEach(func() {
+			fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+				{
+					Name:             "fake-instance",
+					GUID:             "fake-instance-guid",
+					UpgradeAvailable: true,
+					Annotations: map[string]string{
+						"upgrade-all-services-cli-plugin/upgraded-by":      "fake-user",
+						"upgrade-all-services-cli-plugin/upgraded-at":      "2024-05-01T10:11:12Z",
+						"upgrade-all-services-cli-plugin/previous-version": "1.2.1",
+						"upgrade-all-services-cli-plugin/run-id":           "fake-run-id",
+					},
+				},
+			}, nil)
+		})
+
+		It("includes the provenance in text output", func() {
+			output := captureStdout(func() {
+				err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName: fakeBrokerName,
+					Action:     config.CheckUpToDateAction,
+				})
+				Expect(err).To(HaveOccurred())
+			})
+
+			Expect(output).To(ContainSubstring(`  Last Upgraded By: "fake-user"`))
+			Expect(output).To(ContainSubstring(`  Last Upgraded At: "2024-05-01T10:11:12Z"`))
+			Expect(output).To(ContainSubstring(`  Last Upgraded From Version: "1.2.1"`))
+			Expect(output).To(ContainSubstring(`  Last Upgrade Run ID: "fake-run-id"`))
+		})
+
+		It("includes the provenance in JSON output", func() {
+			output := captureStdout(func() {
+				err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName: fakeBrokerName,
+					Action:     config.DryRunAction,
+					JSONOutput: true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			var receiver struct {
+				Upgrade []struct {
+					LastUpgrade json.RawMessage `json:"last_upgrade"`
+				} `json:"upgrade"`
+			}
+			Expect(json.Unmarshal([]byte(output), &receiver)).To(Succeed())
+			Expect(receiver.Upgrade).To(HaveLen(1))
+			Expect(receiver.Upgrade[0].LastUpgrade).To(MatchJSON(`{
+				"upgraded_by": "fake-user",
+				"upgraded_at": "2024-05-01T10:11:12Z",
+				"previous_version": "1.2.1",
+				"run_id": "fake-run-id"
+			}`))
+		})
+	})
+})
@@ -0,0 +1,84 @@
+package upgrader
+
+import (
+	"cmp"
+	"slices"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+)
+
+// orgBlastRadius is the number of service instances in an org that would be upgraded, and the number of
+// different apps that are bound to them
+type orgBlastRadius struct {
+	orgName   string
+	orgGUID   string
+	instances int
+	apps      int
+}
+
+// logBlastRadius looks up the apps bound to each of the service instances and logs them, followed by the
+// totals for each org, so that the service instances which affect the most apps stand out before upgrading
+func logBlastRadius(api CFClient, log Logger, instances []ccapi.ServiceInstance) (map[string]ccapi.Bindings, error) {
+	bindings, err := api.GetServiceInstanceBindings(slicex.Map(instances, func(i ccapi.ServiceInstance) string { return i.GUID }))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instance := range instances {
+		log.InstanceBindings(instance, bindings[instance.GUID])
+	}
+	for _, org := range blastRadiusByOrg(instances, bindings) {
+		log.Printf("org: %q guid: %q has %d upgradable instances bound to %d apps", org.orgName, org.orgGUID, org.instances, org.apps)
+	}
+
+	return bindings, nil
+}
+
+// blastRadiusByOrg totals the service instances and bound apps for each org, with the most apps first.
+// An app bound to more than one of the service instances is only counted once.
+func blastRadiusByOrg(instances []ccapi.ServiceInstance, bindings map[string]ccapi.Bindings) []orgBlastRadius {
+	var (
+		orgs []orgBlastRadius
+		apps = make(map[string]map[string]struct{})
+	)
+	for _, instance := range instances {
+		i := slices.IndexFunc(orgs, func(o orgBlastRadius) bool { return o.orgGUID == instance.OrganizationGUID })
+		if i < 0 {
+			orgs = append(orgs, orgBlastRadius{orgName: instance.OrganizationName, orgGUID: instance.OrganizationGUID})
+			apps[instance.OrganizationGUID] = make(map[string]struct{})
+			i = len(orgs) - 1
+		}
+
+		orgs[i].instances++
+		for _, app := range bindings[instance.GUID].Apps {
+			apps[instance.OrganizationGUID][app.GUID] = struct{}{}
+		}
+	}
+
+	for i := range orgs {
+		orgs[i].apps = len(apps[orgs[i].orgGUID])
+	}
+	slices.SortStableFunc(orgs, func(a, b orgBlastRadius) int {
+		return cmp.Or(cmp.Compare(b.apps, a.apps), cmp.Compare(a.orgName, b.orgName))
+	})
+	return orgs
+}
+
+func withBindings(i jsonOutputServiceInstance, bindings ccapi.Bindings) jsonOutputServiceInstance {
+	i.Bindings = &jsonOutputBindings{
+		AppCount:      len(bindings.Apps),
+		Apps:          slicex.Map(bindings.Apps, func(a ccapi.BoundApp) jsonOutputBoundApp { return jsonOutputBoundApp(a) }),
+		RouteBindings: bindings.RouteBindings,
+		ServiceKeys:   bindings.ServiceKeys,
+	}
+	return i
+}
+
+func newJSONOutputBlastRadius(org orgBlastRadius) jsonOutputBlastRadius {
+	return jsonOutputBlastRadius{
+		OrgName:   org.orgName,
+		OrgGUID:   org.orgGUID,
+		Instances: org.instances,
+		Apps:      org.apps,
+	}
+}
@@ -0,0 +1,151 @@
+package upgrader_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("--blast-radius", func() {
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{{GUID: "fake-plan-guid"}}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "instance-guid-1", Name: "instance-1", UpgradeAvailable: true, OrganizationName: "org-a", OrganizationGUID: "org-guid-a"},
+			{GUID: "instance-guid-2", Name: "instance-2", UpgradeAvailable: true, OrganizationName: "org-b", OrganizationGUID: "org-guid-b"},
+			{GUID: "instance-guid-3", Name: "instance-3", UpgradeAvailable: true, OrganizationName: "org-b", OrganizationGUID: "org-guid-b"},
+			{GUID: "up-to-date-guid", Name: "up-to-date", OrganizationName: "org-a", OrganizationGUID: "org-guid-a"},
+		}, nil)
+		fakeCFClient.GetServiceInstanceBindingsReturns(map[string]ccapi.Bindings{
+			"instance-guid-2": {Apps: []ccapi.BoundApp{{GUID: "app-guid-1", Name: "app-1"}, {GUID: "app-guid-2", Name: "app-2"}}, ServiceKeys: 1},
+			"instance-guid-3": {Apps: []ccapi.BoundApp{{GUID: "app-guid-2", Name: "app-2"}}, RouteBindings: 1},
+		}, nil)
+
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		fakeLogger.HasUpgradeSucceededReturns(true)
+
+		cfg = upgrader.UpgradeConfig{
+			BrokerName:       "fake-broker-name",
+			ParallelUpgrades: 1,
+			BlastRadius:      true,
+		}
+	})
+
+	It("logs the apps bound to each instance before upgrading", func() {
+		Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+
+		Expect(fakeCFClient.GetServiceInstanceBindingsCallCount()).To(Equal(1))
+		Expect(fakeCFClient.GetServiceInstanceBindingsArgsForCall(0)).To(Equal([]string{"instance-guid-1", "instance-guid-2", "instance-guid-3"}))
+
+		Expect(fakeLogger.InstanceBindingsCallCount()).To(Equal(3))
+		instance, bindings := fakeLogger.InstanceBindingsArgsForCall(0)
+		Expect(instance.GUID).To(Equal("instance-guid-1"))
+		Expect(bindings).To(BeZero())
+		instance, bindings = fakeLogger.InstanceBindingsArgsForCall(1)
+		Expect(instance.GUID).To(Equal("instance-guid-2"))
+		Expect(bindings.Apps).To(HaveLen(2))
+
+		Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(Equal(3))
+	})
+
+	It("logs the totals for each org, with the most apps first", func() {
+		Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+
+		var messages []string
+		for i := range fakeLogger.PrintfCallCount() {
+			format, args := fakeLogger.PrintfArgsForCall(i)
+			if format == `org: %q guid: %q has %d upgradable instances bound to %d apps` {
+				messages = append(messages, fmt.Sprintf(format, args...))
+			}
+		}
+		Expect(messages).To(Equal([]string{
+			`org: "org-b" guid: "org-guid-b" has 2 upgradable instances bound to 2 apps`,
+			`org: "org-a" guid: "org-guid-a" has 1 upgradable instances bound to 0 apps`,
+		}))
+	})
+
+	It("does not upgrade when the bindings cannot be read", func() {
+		fakeCFClient.GetServiceInstanceBindingsReturns(nil, errors.New("boom"))
+
+		Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError("boom"))
+		Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(BeZero())
+	})
+
+	It("does not read the bindings unless asked to", func() {
+		cfg.BlastRadius = false
+		Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+
+		Expect(fakeCFClient.GetServiceInstanceBindingsCallCount()).To(BeZero())
+		Expect(fakeLogger.InstanceBindingsCallCount()).To(BeZero())
+	})
+
+	When("running with --dry-run", func() {
+		BeforeEach(func() {
+			cfg.Action = config.DryRunAction
+		})
+
+		It("logs the apps bound to each instance", func() {
+			captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+			})
+
+			Expect(fakeLogger.InstanceBindingsCallCount()).To(Equal(3))
+			Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(BeZero())
+		})
+
+		It("adds the bindings and the totals for each org to the JSON output", func() {
+			cfg.JSONOutput = true
+			output := captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+			})
+
+			var data struct {
+				Upgrade     []map[string]any `json:"upgrade"`
+				BlastRadius []map[string]any `json:"blast_radius"`
+			}
+			Expect(json.Unmarshal([]byte(output), &data)).To(Succeed())
+			Expect(data.Upgrade).To(HaveLen(3))
+			Expect(data.Upgrade[0]).To(HaveKeyWithValue("bindings", map[string]any{
+				"app_count":      float64(0),
+				"apps":           []any{},
+				"route_bindings": float64(0),
+				"service_keys":   float64(0),
+			}))
+			Expect(data.Upgrade[1]).To(HaveKeyWithValue("bindings", map[string]any{
+				"app_count":      float64(2),
+				"apps":           []any{map[string]any{"guid": "app-guid-1", "name": "app-1"}, map[string]any{"guid": "app-guid-2", "name": "app-2"}},
+				"route_bindings": float64(0),
+				"service_keys":   float64(1),
+			}))
+			Expect(data.BlastRadius).To(Equal([]map[string]any{
+				{"org": "org-b", "org_guid": "org-guid-b", "instances": float64(2), "apps": float64(2)},
+				{"org": "org-a", "org_guid": "org-guid-a", "instances": float64(1), "apps": float64(0)},
+			}))
+		})
+
+		It("does not add the bindings to the JSON output unless asked to", func() {
+			cfg.JSONOutput = true
+			cfg.BlastRadius = false
+			output := captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+			})
+
+			Expect(output).NotTo(ContainSubstring("bindings"))
+			Expect(output).NotTo(ContainSubstring("blast_radius"))
+			Expect(fakeCFClient.GetServiceInstanceBindingsCallCount()).To(BeZero())
+		})
+	})
+})
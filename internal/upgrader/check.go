@@ -0,0 +1,189 @@
+package upgrader
+
+import (
+	"fmt"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/junit"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/templates"
+)
+
+// Check is a check of the service instances of a broker. Given the service instances once they have been
+// discovered and grouped, it finds the service instances that violate the check, and the reason why.
+// The output, and whether the run fails, are then handled in the same way for every check, with the
+// check only adding its details about each service instance.
+type Check interface {
+	// Name identifies the check in the output, and is the name of its JUnit test suite
+	Name() string
+	// Run evaluates the check against the service instances
+	Run(instances groupedServiceInstances) (checkResult, error)
+}
+
+// instanceDetails is what a check found out about each service instance, such as the rule that it broke,
+// which is added to the service instance in each of the outputs
+type instanceDetails interface {
+	// text writes the lines that follow the service instance in the text output
+	text(instance ccapi.ServiceInstance)
+	// json adds the details to the service instance in the JSON output
+	json(i jsonOutputServiceInstance, instance ccapi.ServiceInstance) jsonOutputServiceInstance
+	// template adds what the check found to the data for a template
+	template(data *templates.Data)
+	// testCase is the test case for the service instance in a JUnit report
+	testCase(instance ccapi.ServiceInstance) junit.TestCase
+}
+
+// noDetails is embedded by the instanceDetails of checks that add nothing to the text and JSON outputs, or
+// to the data for a template, so that they only need to implement testCase
+type noDetails struct{}
+
+func (noDetails) text(ccapi.ServiceInstance) {}
+
+func (noDetails) json(i jsonOutputServiceInstance, _ ccapi.ServiceInstance) jsonOutputServiceInstance {
+	return i
+}
+
+func (noDetails) template(*templates.Data) {}
+
+// checkRegistry creates the check that is selected by each action. A new check is added here,
+// and given a name in the config package so that it can be selected.
+var checkRegistry = map[config.Action]func(cfg UpgradeConfig) Check{
+	config.DryRunAction:                func(UpgradeConfig) Check { return dryRunCheck{} },
+	config.CheckUpToDateAction:         func(cfg UpgradeConfig) Check { return newUpToDateCheck(cfg) },
+	config.CheckDeactivatedPlansAction: func(cfg UpgradeConfig) Check { return deactivatedPlansCheck{replacementPlans: cfg.ReplacementPlans} },
+	config.CheckFailedOperationsAction: func(UpgradeConfig) Check { return failedOperationsCheck{now: time.Now()} },
+	config.CheckVersionAnomaliesAction: func(cfg UpgradeConfig) Check { return versionAnomaliesCheck{comparison: cfg.VersionComparison} },
+	config.MinVersionCheckAction:       func(cfg UpgradeConfig) Check { return versionCheck{cfg: cfg} },
+	config.VersionConstraintAction:     func(cfg UpgradeConfig) Check { return versionCheck{cfg: cfg} },
+	config.PendingAgeAction:            func(cfg UpgradeConfig) Check { return newPendingAgeCheck(cfg) },
+}
+
+// performCheck discovers the service instances, runs the check against them, and writes the output in the
+// requested format. The combined checks run each check in the same way, and share the output of each section.
+func performCheck(api CFClient, cfg UpgradeConfig, check Check) error {
+	instances, err := getGroupedServiceInstances(api, cfg.BrokerName, 0)
+	if err != nil {
+		return err
+	}
+
+	result, err := runCheck(check, instances, cfg.Thresholds)
+	if err != nil {
+		return err
+	}
+
+	if err := writeJUnitReport(cfg.JUnitReport, instances.all, result); err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.JSONOutput:
+		err = outputCheckJSON(result, cfg.Summary)
+	case cfg.Template != nil:
+		data := newTemplateData(cfg.BrokerName, instances)
+		if result.details != nil {
+			result.details.template(&data)
+		}
+		err = outputTemplate(cfg.Template, data)
+	case cfg.Output.IsTabular():
+		err = outputTabular(cfg.Output, cfg.Columns, sectionRows(result)...)
+	default:
+		outputCheckText(result, len(instances.all), cfg.BrokerName)
+		err = outputSummaryText(cfg.Summary, result.sections...)
+	}
+	if err != nil {
+		return err
+	}
+
+	return result.err()
+}
+
+func newCheck(action config.Action, cfg UpgradeConfig) (Check, error) {
+	create, ok := checkRegistry[action]
+	if !ok {
+		return nil, fmt.Errorf("unexpected check: %d", action)
+	}
+
+	cfg.Action = action
+	return create(cfg), nil
+}
+
+// checkResult is the outcome of a check. The violations are the service instances that fail the check, and the
+// reason describes them. The sections are the groups of service instances that the check found, using the same
+// keys and titles as the summaries, and the details are added to each service instance in the output. A check
+// without details, such as the dry run, has no JUnit test suite. When there are violations, the check can still
+// pass if they are within the thresholds. The grace period of the thresholds only applies to the checks of upgrades
+// that are available, which give the time that the upgrade of each service instance became available as pendingSince.
+//
+// The text output uses the headings in place of the titles of the sections, when they are more specific, and
+// only lists the sections with service instances after the none found message, which is set when the check
+// found nothing. The JSON output is a list of the service instances in the first section when listOutput is
+// set, as it was for the checks that were written first, and is otherwise an object keyed by section.
+type checkResult struct {
+	name             string
+	violations       []ccapi.ServiceInstance
+	reason           string
+	sections         []summary.Set
+	headings         map[string]string
+	noneFound        string
+	listOutput       bool
+	details          instanceDetails
+	pendingSince     map[string]time.Time
+	withinThresholds bool
+}
+
+// heading introduces a section in the text output
+func (r checkResult) heading(section summary.Set) string {
+	if heading, ok := r.headings[section.Key]; ok {
+		return heading
+	}
+	return section.Title
+}
+
+func (r checkResult) logDetails(instance ccapi.ServiceInstance) {
+	if r.details != nil {
+		r.details.text(instance)
+	}
+}
+
+func (r checkResult) jsonInstance(instance ccapi.ServiceInstance) jsonOutputServiceInstance {
+	i := newJSONOutputServiceInstance(instance)
+	if r.details != nil {
+		i = r.details.json(i, instance)
+	}
+	return i
+}
+
+func (r checkResult) passed() bool {
+	return len(r.violations) == 0 || r.withinThresholds
+}
+
+// failure is the reason the check failed, or empty when it passed
+func (r checkResult) failure() string {
+	if r.passed() {
+		return ""
+	}
+	return r.reason
+}
+
+// err is an InstanceError when the check failed, so that it can be ignored with --ignore-instance-errors
+func (r checkResult) err() error {
+	if r.passed() {
+		return nil
+	}
+	return newInstanceError(r.reason)
+}
+
+// dryRunCheck lists the service instances that would be upgraded. It never fails, and has no JUnit test suite.
+type dryRunCheck struct{}
+
+func (dryRunCheck) Name() string {
+	return "dry-run"
+}
+
+func (c dryRunCheck) Run(instances groupedServiceInstances) (checkResult, error) {
+	return checkResult{
+		name:     c.Name(),
+		sections: []summary.Set{upgradeSet(instances.upgradeable), skipSet(instances.createFailed)},
+	}, nil
+}
@@ -1,77 +1,72 @@
 package upgrader
 
 import (
-	"encoding/json"
-	"fmt"
+	"time"
 	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/junit"
 	"upgrade-all-services-cli-plugin/internal/slicex"
+	"upgrade-all-services-cli-plugin/internal/summary"
 )
 
-// performUpToDateCheck performs multiple checks:
-// - it lists service instances associated with deactivated plans (the same as performDeactivatedPlansCheck)
+// upToDateCheck performs multiple checks:
+// - it lists service instances associated with deactivated plans (the same as deactivatedPlansCheck)
 // - it lists service instances that have an upgrade available and failed to create
 // - it lists service instances that have an upgrade available and did not fail to create (similar to performing a dry run)
-func performUpToDateCheck(api CFClient, cfg UpgradeConfig) error {
-	instances, err := getGroupedServiceInstances(api, cfg.BrokerName, 0)
-	if err != nil {
-		return err
-	}
-
-	switch cfg.JSONOutput {
-	case true:
-		if err := outputUpToDateJSON(instances.deactivatedPlan, instances.upgradeable, instances.createFailed); err != nil {
-			return err
-		}
-	default:
-		outputUpToDateText(instances.deactivatedPlan, instances.upgradeable, instances.createFailed, len(instances.all), cfg.BrokerName)
-	}
+//
+// It fails for service instances associated with deactivated plans or with an upgrade available.
+// Service instances that failed to create are listed, but do not fail the check. The grace period applies to
+// the service instances with an upgrade available, and when there is a pending since file, it is updated each
+// time the check runs, in the same way as for pendingAgeCheck.
+type upToDateCheck struct {
+	pendingSinceFile string
+	now              time.Time
+}
 
-	if len(instances.deactivatedPlan) > 0 || len(instances.upgradeable) > 0 {
-		return newInstanceError("discovered service instances associated with deactivated plans or with an upgrade available")
+func newUpToDateCheck(cfg UpgradeConfig) upToDateCheck {
+	return upToDateCheck{
+		pendingSinceFile: cfg.PendingSinceFile,
+		now:              time.Now(),
 	}
-
-	return nil
 }
 
-func outputUpToDateText(instancesWithDeactivatedPlans, upgradableInstances, createFailedInstances []ccapi.ServiceInstance, totalServiceInstances int, brokerName string) {
-	fmt.Printf("Discovering service instances for broker: %s\n", brokerName)
-	fmt.Printf("Total number of service instances: %d\n", totalServiceInstances)
-
-	fmt.Printf("Number of service instances associated with deactivated plans: %d\n", len(instancesWithDeactivatedPlans))
-	fmt.Println()
-	logServiceInstances(instancesWithDeactivatedPlans)
-
-	fmt.Printf("Number of service instances with an upgrade available: %d\n", len(upgradableInstances))
-	fmt.Println()
-	logServiceInstances(upgradableInstances)
-
-	fmt.Printf("Number of service instances which failed to create: %d\n", len(createFailedInstances))
-	fmt.Println()
-	logServiceInstances(createFailedInstances)
-
-	if len(instancesWithDeactivatedPlans) == 0 && len(upgradableInstances) == 0 {
-		fmt.Println("No instances found associated with deactivated plans or with an upgrade available")
-	}
+func (upToDateCheck) Name() string {
+	return "check-up-to-date"
 }
 
-func outputUpToDateJSON(instancesWithDeactivatedPlans, upgradableInstances, createFailedInstances []ccapi.ServiceInstance) error {
-	type formatter struct {
-		DeactivatedPlans []jsonOutputServiceInstance `json:"plan_deactivated"`
-		UpgradePending   []jsonOutputServiceInstance `json:"upgrade_pending"`
-		CreateFailed     []jsonOutputServiceInstance `json:"create_failed"`
+func (c upToDateCheck) Run(instances groupedServiceInstances) (checkResult, error) {
+	pendingSince, err := upgradesPendingSince(instances.upgradeable, c.pendingSinceFile, c.now)
+	if err != nil {
+		return checkResult{}, err
 	}
 
-	data := formatter{
-		DeactivatedPlans: slicex.Map(instancesWithDeactivatedPlans, newJSONOutputServiceInstance),
-		UpgradePending:   slicex.Map(upgradableInstances, newJSONOutputServiceInstance),
-		CreateFailed:     slicex.Map(createFailedInstances, newJSONOutputServiceInstance),
+	// A service instance on a deactivated plan always counts, however recently its upgrade became available
+	for _, instance := range instances.deactivatedPlan {
+		delete(pendingSince, instance.GUID)
 	}
 
-	output, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return err
+	r := checkResult{
+		name:         c.Name(),
+		violations:   slicex.Filter(instances.all, isOutOfDate),
+		reason:       "discovered service instances associated with deactivated plans or with an upgrade available",
+		sections:     []summary.Set{planDeactivatedSet(instances.deactivatedPlan), upgradePendingSet(instances.upgradeable), createFailedSet(instances.createFailed)},
+		details:      upToDateDetails{},
+		pendingSince: pendingSince,
 	}
+	if len(instances.deactivatedPlan) == 0 && len(instances.upgradeable) == 0 {
+		r.noneFound = "No instances found associated with deactivated plans or with an upgrade available"
+	}
+	return r, nil
+}
+
+func isOutOfDate(instance ccapi.ServiceInstance) bool {
+	return instance.ServicePlanDeactivated || (instance.UpgradeAvailable && !ccapi.HasInstanceCreateFailedStatus(instance))
+}
+
+// upToDateDetails only adds the reason that each service instance is out of date to the JUnit report
+type upToDateDetails struct {
+	noDetails
+}
 
-	fmt.Println(string(output))
-	return nil
+func (upToDateDetails) testCase(instance ccapi.ServiceInstance) junit.TestCase {
+	return upToDateTestCase(instance)
 }
@@ -0,0 +1,112 @@
+package upgrader
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+)
+
+// performCombinedChecks discovers the service instances once, and runs each of the checks against them.
+// There is a section for each check in the output, and the run fails when any of the checks fails.
+func performCombinedChecks(api CFClient, cfg UpgradeConfig) error {
+	instances, err := getGroupedServiceInstances(api, cfg.BrokerName, 0)
+	if err != nil {
+		return err
+	}
+
+	var results []checkResult
+	for _, action := range cfg.Checks {
+		check, err := newCheck(action, cfg)
+		if err != nil {
+			return err
+		}
+
+		r, err := runCheck(check, instances, cfg.Thresholds)
+		if err != nil {
+			return err
+		}
+		results = append(results, r)
+	}
+
+	if err := writeJUnitReport(cfg.JUnitReport, instances.all, results...); err != nil {
+		return err
+	}
+
+	if cfg.JSONOutput {
+		if err := outputCombinedChecksJSON(results); err != nil {
+			return err
+		}
+	} else {
+		outputCombinedChecksText(results, len(instances.all), cfg.BrokerName)
+	}
+
+	failed := slicex.Filter(results, func(r checkResult) bool { return !r.passed() })
+	if len(failed) > 0 {
+		names := slicex.Map(failed, func(r checkResult) string { return r.name })
+		return newInstanceErrorf("%d of %d checks failed: %s", len(failed), len(results), strings.Join(names, ", "))
+	}
+
+	return nil
+}
+
+func outputCombinedChecksText(results []checkResult, totalServiceInstances int, brokerName string) {
+	logDiscovery(brokerName, totalServiceInstances)
+	fmt.Println()
+
+	var failed int
+	for _, r := range results {
+		switch {
+		case r.passed() && len(r.violations) > 0:
+			fmt.Printf("Check %s: passed, %d service instances are within the thresholds\n", r.name, len(r.violations))
+		case r.passed():
+			fmt.Printf("Check %s: passed\n", r.name)
+		default:
+			failed++
+			fmt.Printf("Check %s: failed, %s\n", r.name, r.failure())
+		}
+
+		for _, section := range r.sections {
+			logCheckSection(r, section)
+		}
+	}
+
+	if failed == 0 {
+		fmt.Printf("All %d checks passed\n", len(results))
+	} else {
+		fmt.Printf("%d of %d checks failed\n", failed, len(results))
+	}
+}
+
+func outputCombinedChecksJSON(results []checkResult) error {
+	type check struct {
+		Name      string                                 `json:"name"`
+		Passed    bool                                   `json:"passed"`
+		Failure   string                                 `json:"failure,omitempty"`
+		Instances map[string][]jsonOutputServiceInstance `json:"instances"`
+	}
+
+	type formatter struct {
+		Passed bool    `json:"passed"`
+		Checks []check `json:"checks"`
+	}
+
+	data := formatter{Passed: true}
+	for _, r := range results {
+		c := check{Name: r.name, Passed: r.passed(), Failure: r.failure(), Instances: make(map[string][]jsonOutputServiceInstance)}
+		for _, section := range r.sections {
+			c.Instances[section.Key] = slicex.Map(section.Instances, r.jsonInstance)
+		}
+
+		data.Passed = data.Passed && c.Passed
+		data.Checks = append(data.Checks, c)
+	}
+
+	output, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(output))
+	return nil
+}
@@ -0,0 +1,130 @@
+package upgrader_test
+
+import (
+	"encoding/json"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("combined checks", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+			{GUID: "fake-plan-guid", Available: true, MaintenanceInfoVersion: "1.6.0"},
+		}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "current-guid", ServicePlanGUID: "fake-plan-guid", MaintenanceInfoVersion: "1.6.0"},
+			{GUID: "outdated-guid", ServicePlanGUID: "fake-plan-guid", MaintenanceInfoVersion: "1.3.0", UpgradeAvailable: true},
+		}, nil)
+
+		cfg = upgrader.UpgradeConfig{
+			BrokerName:        fakeBrokerName,
+			Action:            config.CombinedChecksAction,
+			Checks:            []config.Action{config.CheckDeactivatedPlansAction, config.VersionConstraintAction},
+			VersionConstraint: version.MustConstraints(version.NewConstraint(">= 1.4.0")),
+		}
+	})
+
+	It("discovers the service instances once", func() {
+		captureStdout(func() {
+			_ = upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+		})
+
+		Expect(fakeCFClient.GetServicePlansCallCount()).To(Equal(1))
+		Expect(fakeCFClient.GetServiceInstancesForServicePlansCallCount()).To(Equal(1))
+	})
+
+	It("reports a section for each check and fails when any check fails", func() {
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+			Expect(err).To(MatchError("1 of 2 checks failed: version-constraint"))
+			Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(ContainSubstring("Total number of service instances: 2"))
+		Expect(output).To(ContainSubstring("Check check-deactivated-plans: passed"))
+		Expect(output).To(ContainSubstring("Number of service instances associated with deactivated plans: 0"))
+		Expect(output).To(ContainSubstring("Check version-constraint: failed, found 1 service instances outside the version constraint"))
+		Expect(output).To(ContainSubstring(`Number of service instances outside the version constraint ">= 1.4.0": 1`))
+		Expect(output).To(ContainSubstring(`Service Instance GUID: "outdated-guid"`))
+		Expect(output).To(ContainSubstring("1 of 2 checks failed"))
+	})
+
+	It("adds the details of each check to its service instances", func() {
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "current-guid", ServicePlanGUID: "fake-plan-guid", MaintenanceInfoVersion: "1.6.0", ServicePlanDeactivated: true},
+		}, nil)
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(output).To(MatchRegexp(`(?s)Check check-deactivated-plans: failed.*"current-guid".*Replacement Plan Candidates: "none".*Check version-constraint: passed`))
+	})
+
+	It("succeeds when every check passes", func() {
+		cfg.Checks = []config.Action{config.DryRunAction, config.CheckDeactivatedPlansAction}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(ContainSubstring("Check dry-run: passed"))
+		Expect(output).To(ContainSubstring("Number of service instances that would be upgraded: 1"))
+		Expect(output).To(ContainSubstring("All 2 checks passed"))
+	})
+
+	It("reports the checks as JSON", func() {
+		cfg.JSONOutput = true
+		cfg.Checks = []config.Action{config.CheckUpToDateAction, config.VersionConstraintAction}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError("2 of 2 checks failed: check-up-to-date, version-constraint"))
+		})
+
+		var report struct {
+			Passed bool `json:"passed"`
+			Checks []struct {
+				Name      string                      `json:"name"`
+				Passed    bool                        `json:"passed"`
+				Failure   string                      `json:"failure"`
+				Instances map[string][]map[string]any `json:"instances"`
+			} `json:"checks"`
+		}
+		Expect(json.Unmarshal([]byte(output), &report)).To(Succeed())
+		Expect(report.Passed).To(BeFalse())
+		Expect(report.Checks).To(HaveLen(2))
+		Expect(report.Checks[0].Name).To(Equal("check-up-to-date"))
+		Expect(report.Checks[0].Failure).To(Equal("discovered service instances associated with deactivated plans or with an upgrade available"))
+		Expect(report.Checks[0].Instances).To(HaveKey("plan_deactivated"))
+		Expect(report.Checks[0].Instances["upgrade_pending"]).To(HaveLen(1))
+		Expect(report.Checks[1].Name).To(Equal("version-constraint"))
+		Expect(report.Checks[1].Instances["outside_version_constraint"]).To(HaveLen(1))
+	})
+
+	It("writes a JUnit test suite for each check", func() {
+		junitReport := gbytes.NewBuffer()
+		cfg.JUnitReport = junitReport
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`name="check-deactivated-plans"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`name="version-constraint"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`type="OutsideVersionConstraint"`))
+	})
+})
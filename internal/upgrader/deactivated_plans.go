@@ -1,56 +1,180 @@
 package upgrader
 
 import (
-	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"upgrade-all-services-cli-plugin/internal/ccapi"
-	"upgrade-all-services-cli-plugin/internal/slicex"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/junit"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/templates"
 )
 
-// performDeactivatedPlansCheck lists service instances associated with deactivated plans
-func performDeactivatedPlansCheck(api CFClient, cfg UpgradeConfig) error {
-	instances, err := getAllServiceInstances(api, cfg.BrokerName)
+// deactivatedPlansCheck fails for service instances associated with deactivated plans. For each service instance it
+// suggests a replacement plan, which is the preferred replacement when there is one, or otherwise the only active plan
+// of the same service offering.
+type deactivatedPlansCheck struct {
+	replacementPlans []config.PlanMapping
+}
+
+func (deactivatedPlansCheck) Name() string {
+	return "check-deactivated-plans"
+}
+
+func (c deactivatedPlansCheck) Run(instances groupedServiceInstances) (checkResult, error) {
+	preferred, err := resolvePlanMappings(instances.plans, c.replacementPlans)
 	if err != nil {
-		return err
+		return checkResult{}, fmt.Errorf("error resolving replacement plans: %w", err)
 	}
 
-	instancesWithDeactivatedPlans := slicex.Filter(instances, func(instance ccapi.ServiceInstance) bool { return instance.ServicePlanDeactivated })
+	replacements := make(planReplacements, len(instances.deactivatedPlan))
+	for _, instance := range instances.deactivatedPlan {
+		replacements[instance.GUID] = newPlanReplacement(instance, instances.plans, preferred)
+	}
 
-	switch {
-	case cfg.JSONOutput:
-		if err := outputDeactivatedPlansJSON(instancesWithDeactivatedPlans); err != nil {
-			return err
+	r := checkResult{
+		name:       c.Name(),
+		violations: instances.deactivatedPlan,
+		reason:     "discovered deactivated plans associated with instances",
+		sections:   []summary.Set{planDeactivatedSet(instances.deactivatedPlan)},
+		listOutput: true,
+		details:    replacements,
+	}
+	if len(instances.deactivatedPlan) == 0 {
+		r.noneFound = "No instances found associated with deactivated plans"
+	}
+	return r, nil
+}
+
+// planReplacement is where a service instance on a deactivated plan could be moved. The candidates are the names
+// of the active plans of the same service offering, and the plan is the suggested one, or empty when there is a choice.
+type planReplacement struct {
+	candidates []string
+	plan       string
+	preferred  bool
+	migration  config.PlanMapping
+}
+
+func newPlanReplacement(instance ccapi.ServiceInstance, plans []ccapi.ServicePlan, preferred map[string]ccapi.ServicePlan) planReplacement {
+	r := planReplacement{candidates: []string{}}
+	for _, plan := range plans {
+		if plan.Available && plan.ServiceOfferingGUID == instance.ServiceOfferingGUID && plan.GUID != instance.ServicePlanGUID {
+			r.candidates = append(r.candidates, plan.Name)
 		}
+	}
+	slices.Sort(r.candidates)
+
+	switch target, ok := preferred[instance.ServicePlanGUID]; {
+	case ok:
+		r.plan, r.preferred = target.Name, true
+	case len(r.candidates) == 1:
+		r.plan = r.candidates[0]
 	default:
-		outputDeactivatedPlansText(instancesWithDeactivatedPlans, cfg.BrokerName, len(instances))
+		return r
 	}
 
-	if len(instancesWithDeactivatedPlans) > 0 {
-		return newInstanceError("discovered deactivated plans associated with instances")
+	r.migration = config.PlanMapping{ServiceOfferingName: instance.ServiceOfferingName, FromPlanName: instance.ServicePlanName, ToPlanName: r.plan}
+	return r
+}
+
+// command is the cf CLI command that targets the org and space of the service instance and moves it to the suggested plan
+func (r planReplacement) command(instance ccapi.ServiceInstance) string {
+	if r.plan == "" {
+		return ""
 	}
+	return fmt.Sprintf("cf target -o %s -s %s && cf update-service %s -p %s",
+		shellQuote(instance.OrganizationName), shellQuote(instance.SpaceName), shellQuote(instance.Name), shellQuote(r.plan))
+}
 
-	return nil
+// shellQuote quotes a name for a POSIX shell, unless it only has characters that the shell does not interpret
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.,:/@%+=") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
-func outputDeactivatedPlansText(instancesWithDeactivatedPlans []ccapi.ServiceInstance, brokerName string, totalServiceInstances int) {
-	fmt.Printf("Discovering service instances for broker: %s\n", brokerName)
-	fmt.Printf("Total number of service instances: %d\n", totalServiceInstances)
-	if len(instancesWithDeactivatedPlans) == 0 {
-		fmt.Println("No instances found associated with deactivated plans")
+// planReplacements are the replacements for the service instances on deactivated plans, keyed by service instance GUID
+type planReplacements map[string]planReplacement
+
+func (p planReplacements) text(instance ccapi.ServiceInstance) {
+	r, ok := p[instance.GUID]
+	if !ok {
 		return
 	}
 
-	fmt.Printf("Number of service instances associated with deactivated plans: %d\n", len(instancesWithDeactivatedPlans))
-	fmt.Println()
-	logServiceInstances(instancesWithDeactivatedPlans)
+	candidates := "none"
+	if len(r.candidates) > 0 {
+		candidates = strings.Join(r.candidates, ", ")
+	}
+	fmt.Printf("  Replacement Plan Candidates: %q\n", candidates)
+	if r.plan == "" {
+		return
+	}
+
+	source := "only candidate"
+	if r.preferred {
+		source = "preferred"
+	}
+	fmt.Printf("  Suggested Replacement Plan: %q (%s)\n", r.plan, source)
+	fmt.Printf("  Migration Command: %q\n", r.command(instance))
+	fmt.Printf("  Migration Entry: %q\n", r.migration)
 }
 
-func outputDeactivatedPlansJSON(instancesWithDeactivatedPlans []ccapi.ServiceInstance) error {
-	output, err := json.MarshalIndent(slicex.Map(instancesWithDeactivatedPlans, newJSONOutputServiceInstance), "", "  ")
-	if err != nil {
-		return err
+func (p planReplacements) json(i jsonOutputServiceInstance, instance ccapi.ServiceInstance) jsonOutputServiceInstance {
+	r, ok := p[instance.GUID]
+	if !ok {
+		return i
+	}
+
+	i.Replacement = &jsonOutputReplacement{
+		Candidates: r.candidates,
+		Plan:       r.plan,
+		Preferred:  r.preferred,
+		Command:    r.command(instance),
+	}
+	if r.plan != "" {
+		i.Replacement.Migration = r.migration.String()
+	}
+	return i
+}
+
+func (p planReplacements) template(data *templates.Data) {
+	data.Replacements = make(map[string]templates.Replacement, len(p))
+	for _, instance := range data.DeactivatedPlan {
+		r, ok := p[instance.GUID]
+		if !ok {
+			continue
+		}
+
+		replacement := templates.Replacement{
+			Candidates: r.candidates,
+			Plan:       r.plan,
+			Preferred:  r.preferred,
+			Command:    r.command(instance),
+		}
+		if r.plan != "" {
+			replacement.Migration = r.migration.String()
+		}
+		data.Replacements[instance.GUID] = replacement
+	}
+}
+
+func (p planReplacements) column(column config.Column, instance ccapi.ServiceInstance) (string, bool) {
+	r, ok := p[instance.GUID]
+	switch {
+	case !ok:
+		return "", false
+	case column == config.ReplacementCandidatesColumn:
+		return strings.Join(r.candidates, ", "), true
+	case column == config.ReplacementPlanColumn:
+		return r.plan, true
+	default:
+		return "", false
 	}
+}
 
-	fmt.Println(string(output))
-	return nil
+func (planReplacements) testCase(instance ccapi.ServiceInstance) junit.TestCase {
+	return deactivatedPlanTestCase(instance)
 }
@@ -1,8 +1,10 @@
 package upgrader_test
 
 import (
+	"encoding/json"
 	"upgrade-all-services-cli-plugin/internal/ccapi"
 	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/templates"
 	"upgrade-all-services-cli-plugin/internal/upgrader"
 	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
 
@@ -91,4 +93,110 @@ var _ = Describe("--check-deactivated-plans", func() {
 			Expect(output).To(ContainSubstring(fakeInstanceGUID))
 		})
 	})
+
+	Describe("replacement plans", func() {
+		var cfg upgrader.UpgradeConfig
+
+		BeforeEach(func() {
+			fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+				{GUID: "small-guid", Name: "small", Available: false, ServiceOfferingGUID: "db-guid", ServiceOfferingName: "db"},
+				{GUID: "medium-guid", Name: "medium", Available: true, ServiceOfferingGUID: "db-guid", ServiceOfferingName: "db"},
+				{GUID: "large-guid", Name: "large", Available: true, ServiceOfferingGUID: "db-guid", ServiceOfferingName: "db"},
+				{GUID: "legacy-guid", Name: "legacy", Available: false, ServiceOfferingGUID: "cache-guid", ServiceOfferingName: "cache"},
+				{GUID: "standard-guid", Name: "standard", Available: true, ServiceOfferingGUID: "cache-guid", ServiceOfferingName: "cache"},
+				{GUID: "other-guid", Name: "other", Available: true, ServiceOfferingGUID: "queue-guid", ServiceOfferingName: "queue"},
+			}, nil)
+			fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+				{GUID: "db-instance-guid", Name: "db-instance", ServicePlanGUID: "small-guid", ServicePlanName: "small", ServiceOfferingGUID: "db-guid", ServiceOfferingName: "db", SpaceName: "dev", OrganizationName: "my-org", ServicePlanDeactivated: true},
+				{GUID: "cache-instance-guid", Name: "cache-instance", ServicePlanGUID: "legacy-guid", ServicePlanName: "legacy", ServiceOfferingGUID: "cache-guid", ServiceOfferingName: "cache", SpaceName: "dev space", OrganizationName: "my-org", ServicePlanDeactivated: true},
+			}, nil)
+
+			cfg = upgrader.UpgradeConfig{
+				BrokerName: fakeBrokerName,
+				Action:     config.CheckDeactivatedPlansAction,
+			}
+		})
+
+		It("suggests the active plans of the same service offering", func() {
+			output := captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+			})
+
+			Expect(output).To(MatchRegexp(`(?s)"db-instance".*Replacement Plan Candidates: "large, medium"\n\n`))
+			Expect(output).To(MatchRegexp(`(?s)"cache-instance".*Replacement Plan Candidates: "standard"\n.*Suggested Replacement Plan: "standard" \(only candidate\)`))
+			Expect(output).To(ContainSubstring(`Migration Command: "cf target -o my-org -s 'dev space' && cf update-service cache-instance -p standard"`))
+			Expect(output).To(ContainSubstring(`Migration Entry: "cache:legacy=standard"`))
+			Expect(output).NotTo(ContainSubstring("other"))
+		})
+
+		It("prefers the replacement plans from the file", func() {
+			cfg.ReplacementPlans = []config.PlanMapping{{ServiceOfferingName: "db", FromPlanName: "small", ToPlanName: "medium"}}
+			output := captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+			})
+
+			Expect(output).To(ContainSubstring(`Suggested Replacement Plan: "medium" (preferred)`))
+			Expect(output).To(ContainSubstring(`Migration Command: "cf target -o my-org -s dev && cf update-service db-instance -p medium"`))
+		})
+
+		It("fails when a replacement plan is not active", func() {
+			cfg.ReplacementPlans = []config.PlanMapping{{FromPlanName: "small", ToPlanName: "legacy"}}
+			captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError(ContainSubstring("error resolving replacement plans: invalid plan mapping")))
+			})
+		})
+
+		It("adds the replacement to the JSON output", func() {
+			cfg.JSONOutput = true
+			output := captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+			})
+
+			var instances []map[string]any
+			Expect(json.Unmarshal([]byte(output), &instances)).To(Succeed())
+			Expect(instances).To(HaveLen(2))
+			Expect(instances[0]).To(HaveKeyWithValue("replacement", Equal(map[string]any{"candidates": []any{"large", "medium"}})))
+			Expect(instances[1]).To(HaveKeyWithValue("replacement", Equal(map[string]any{
+				"candidates": []any{"standard"},
+				"plan":       "standard",
+				"command":    "cf target -o my-org -s 'dev space' && cf update-service cache-instance -p standard",
+				"migration":  "cache:legacy=standard",
+			})))
+		})
+
+		It("quotes names in the migration command", func() {
+			fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+				{GUID: "cache-instance-guid", Name: "Bob's cache", ServicePlanGUID: "legacy-guid", ServicePlanName: "legacy", ServiceOfferingGUID: "cache-guid", ServiceOfferingName: "cache", SpaceName: "dev", OrganizationName: "my org", ServicePlanDeactivated: true},
+			}, nil)
+			cfg.JSONOutput = true
+			output := captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+			})
+
+			var instances []map[string]any
+			Expect(json.Unmarshal([]byte(output), &instances)).To(Succeed())
+			Expect(instances).To(ConsistOf(HaveKeyWithValue("replacement", HaveKeyWithValue("command", `cf target -o 'my org' -s dev && cf update-service 'Bob'\''s cache' -p standard`))))
+		})
+
+		It("writes the replacement columns", func() {
+			cfg.Output = config.CSVOutput
+			cfg.Columns = []config.Column{config.NameColumn, config.ReplacementCandidatesColumn, config.ReplacementPlanColumn}
+			output := captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+			})
+
+			Expect(output).To(Equal("name,replacement_candidates,replacement_plan\ndb-instance,\"large, medium\",\ncache-instance,standard,standard\n"))
+		})
+
+		It("adds the replacements to the template data", func() {
+			t, err := templates.Parse("test", `{{range .DeactivatedPlan}}{{.Name}}:{{with index $.Replacements .GUID}}{{join "|" .Candidates}}>{{.Plan}} {{.Command}}{{end}};{{end}}`)
+			Expect(err).NotTo(HaveOccurred())
+			cfg.Template = t
+			output := captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+			})
+
+			Expect(output).To(Equal("db-instance:large|medium> ;cache-instance:standard>standard cf target -o my-org -s 'dev space' && cf update-service cache-instance -p standard;"))
+		})
+	})
 })
@@ -0,0 +1,137 @@
+package upgrader
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/junit"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/templates"
+)
+
+// operationTypes are the types of last operation that always have a section in the output, in order.
+// A failed operation of any other type gets a section after these.
+var operationTypes = []string{"create", "update", "delete"}
+
+// failedOperation is the last operation of a service instance, when it failed
+type failedOperation struct {
+	operationType string
+	description   string
+	at            time.Time
+	age           time.Duration
+}
+
+// failedOperationsCheck fails for service instances whose last operation failed, oldest first
+type failedOperationsCheck struct {
+	now time.Time
+}
+
+func (failedOperationsCheck) Name() string {
+	return "check-failed-operations"
+}
+
+func (c failedOperationsCheck) Run(instances groupedServiceInstances) (checkResult, error) {
+	failed := slicex.Filter(instances.all, ccapi.HasFailedLastOperation)
+	slices.SortStableFunc(failed, func(a, b ccapi.ServiceInstance) int {
+		return cmp.Or(a.LastOperationUpdatedAt.Compare(b.LastOperationUpdatedAt), cmp.Compare(a.Name, b.Name))
+	})
+
+	operations := make(failedOperations, len(failed))
+	for _, instance := range failed {
+		operations[instance.GUID] = newFailedOperation(instance, c.now)
+	}
+
+	r := checkResult{
+		name:       c.Name(),
+		violations: failed,
+		reason:     fmt.Sprintf("found %d service instances whose last operation failed", len(failed)),
+		sections:   failedOperationSets(failed),
+		details:    operations,
+	}
+	if len(failed) == 0 {
+		r.noneFound = "No instances found whose last operation failed"
+	}
+	return r, nil
+}
+
+func newFailedOperation(instance ccapi.ServiceInstance, now time.Time) failedOperation {
+	op := failedOperation{
+		operationType: instance.LastOperationType,
+		description:   instance.LastOperationDescription,
+		at:            instance.LastOperationUpdatedAt,
+	}
+	if !op.at.IsZero() {
+		op.age = now.Sub(op.at)
+	}
+	return op
+}
+
+// failedOperationSets groups the service instances by the type of their last operation
+func failedOperationSets(failed []ccapi.ServiceInstance) []summary.Set {
+	types := slices.Clone(operationTypes)
+	for _, instance := range failed {
+		if !slices.Contains(types, instance.LastOperationType) {
+			types = append(types, instance.LastOperationType)
+		}
+	}
+
+	return slicex.Map(types, func(operationType string) summary.Set {
+		return operationFailedSet(operationType, slicex.Filter(failed, func(instance ccapi.ServiceInstance) bool {
+			return instance.LastOperationType == operationType
+		}))
+	})
+}
+
+func (op failedOperation) message() string {
+	if op.at.IsZero() {
+		return fmt.Sprintf("last %s operation failed: %s", op.operationType, op.description)
+	}
+	return fmt.Sprintf("last %s operation failed %s ago: %s", op.operationType, formatAge(op.age), op.description)
+}
+
+// failedOperations are the failed last operations of the service instances, keyed by service instance GUID
+type failedOperations map[string]failedOperation
+
+func (f failedOperations) text(instance ccapi.ServiceInstance) {
+	op, ok := f[instance.GUID]
+	if !ok {
+		return
+	}
+
+	fmt.Printf("  Last Operation Type: %q\n", op.operationType)
+	fmt.Printf("  Last Operation Message: %q\n", op.description)
+	if !op.at.IsZero() {
+		fmt.Printf("  Last Operation Failed At: %q\n", op.at.UTC().Format(time.RFC3339))
+		fmt.Printf("  Last Operation Failed: %q\n", formatAge(op.age)+" ago")
+	}
+}
+
+func (f failedOperations) json(i jsonOutputServiceInstance, instance ccapi.ServiceInstance) jsonOutputServiceInstance {
+	op, ok := f[instance.GUID]
+	if !ok {
+		return i
+	}
+
+	i.LastOperation = &jsonOutputLastOperation{
+		Type:        op.operationType,
+		Description: op.description,
+	}
+	if !op.at.IsZero() {
+		i.LastOperation.UpdatedAt = op.at.UTC().Format(time.RFC3339)
+		i.LastOperation.AgeDays = int(op.age / day)
+	}
+	return i
+}
+
+func (failedOperations) template(*templates.Data) {}
+
+func (f failedOperations) testCase(instance ccapi.ServiceInstance) junit.TestCase {
+	op, ok := f[instance.GUID]
+	if !ok {
+		return junit.InstanceCase(instance)
+	}
+	return junit.InstanceCase(instance).WithFailure("FailedOperation", op.message())
+}
@@ -0,0 +1,130 @@
+package upgrader_test
+
+import (
+	"encoding/json"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("--check-failed-operations", func() {
+	const (
+		fakeBrokerName = "fake-broker-name"
+		day            = 24 * time.Hour
+	)
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+			{GUID: "fake-plan-guid", Available: true, MaintenanceInfoVersion: "1.6.0"},
+		}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "healthy-guid", Name: "healthy", LastOperationType: "update", LastOperationState: "succeeded"},
+			{GUID: "update-guid", Name: "update", LastOperationType: "update", LastOperationState: "failed", LastOperationDescription: "broker timed out", LastOperationUpdatedAt: time.Now().Add(-3 * day)},
+			{GUID: "create-guid", Name: "create", LastOperationType: "create", LastOperationState: "failed", LastOperationDescription: "quota exceeded", LastOperationUpdatedAt: time.Now().Add(-10 * day), UpgradeAvailable: true},
+			{GUID: "older-update-guid", Name: "older-update", LastOperationType: "update", LastOperationState: "failed", LastOperationDescription: "disk full", LastOperationUpdatedAt: time.Now().Add(-5 * day)},
+			{GUID: "in-progress-guid", Name: "in-progress", LastOperationType: "delete", LastOperationState: "in progress"},
+		}, nil)
+
+		cfg = upgrader.UpgradeConfig{
+			BrokerName: fakeBrokerName,
+			Action:     config.CheckFailedOperationsAction,
+		}
+	})
+
+	It("lists the service instances whose last operation failed, by type of operation", func() {
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+			Expect(err).To(MatchError("found 3 service instances whose last operation failed"))
+			Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(ContainSubstring("Number of service instances which failed to create: 1"))
+		Expect(output).To(ContainSubstring("Number of service instances which failed to update: 2"))
+		Expect(output).To(ContainSubstring("Number of service instances which failed to delete: 0"))
+		Expect(output).To(MatchRegexp(`(?s)"create".*Last Operation Message: "quota exceeded".*Last Operation Failed: "10 days ago"`))
+		Expect(output).To(MatchRegexp(`(?s)"older-update".*Last Operation Message: "disk full".*"update-guid".*Last Operation Message: "broker timed out".*Last Operation Failed: "3 days ago"`))
+		Expect(output).NotTo(ContainSubstring("healthy-guid"))
+		Expect(output).NotTo(ContainSubstring("in-progress-guid"))
+	})
+
+	It("succeeds when no operation failed", func() {
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "healthy-guid", Name: "healthy", LastOperationType: "update", LastOperationState: "succeeded"},
+		}, nil)
+
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(ContainSubstring("No instances found whose last operation failed"))
+	})
+
+	It("lists the service instances as JSON", func() {
+		cfg.JSONOutput = true
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		var data map[string][]map[string]any
+		Expect(json.Unmarshal([]byte(output), &data)).To(Succeed())
+		Expect(data).To(HaveKeyWithValue("create_failed", HaveLen(1)))
+		Expect(data).To(HaveKeyWithValue("update_failed", HaveLen(2)))
+		Expect(data).To(HaveKeyWithValue("delete_failed", BeEmpty()))
+		Expect(data["update_failed"][0]).To(HaveKeyWithValue("guid", "older-update-guid"))
+		Expect(data["update_failed"][0]).To(HaveKeyWithValue("last_operation", SatisfyAll(
+			HaveKeyWithValue("type", "update"),
+			HaveKeyWithValue("description", "disk full"),
+			HaveKeyWithValue("age_days", BeNumerically("==", 5)),
+			HaveKey("updated_at"),
+		)))
+	})
+
+	It("writes the status column", func() {
+		cfg.Output = config.CSVOutput
+		cfg.Columns = []config.Column{config.StatusColumn, config.GUIDColumn}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(output).To(Equal("status,guid\ncreate_failed,create-guid\nupdate_failed,older-update-guid\nupdate_failed,update-guid\n"))
+	})
+
+	It("fails the test cases in the JUnit report", func() {
+		junitReport := gbytes.NewBuffer()
+		cfg.JUnitReport = junitReport
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`name="check-failed-operations"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`type="FailedOperation"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`last update operation failed 3 days ago: broker timed out`))
+	})
+
+	It("can be combined with other checks", func() {
+		cfg.Action = config.CombinedChecksAction
+		cfg.Checks = []config.Action{config.CheckDeactivatedPlansAction, config.CheckFailedOperationsAction}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError("1 of 2 checks failed: check-failed-operations"))
+		})
+
+		Expect(output).To(ContainSubstring("Check check-deactivated-plans: passed"))
+		Expect(output).To(ContainSubstring("Check check-failed-operations: failed"))
+		Expect(output).To(ContainSubstring(`Last Operation Message: "quota exceeded"`))
+	})
+})
@@ -0,0 +1,107 @@
+package upgrader
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+)
+
+// healthBaseline is the state of the apps bound to a service instance just before it is upgraded, so that
+// process instances which had already crashed are not blamed on the upgrade
+type healthBaseline struct {
+	apps    []ccapi.BoundApp
+	crashed map[crashedProcess]bool
+}
+
+// crashedProcess identifies a crashed process instance of an app
+type crashedProcess struct {
+	appGUID     string
+	appName     string
+	processType string
+	index       int
+}
+
+// String describes the process instance, for example `"my-app" web/0`
+func (c crashedProcess) String() string {
+	return fmt.Sprintf("%q %s/%d", c.appName, c.processType, c.index)
+}
+
+// healthBeforeUpgrade looks up the apps bound to a service instance and their crashed process instances before it
+// is upgraded. It returns false when there are no bound apps to check, or when looking them up fails, which is
+// logged, but does not stop the upgrade.
+func healthBeforeUpgrade(api CFClient, log Logger, instance ccapi.ServiceInstance) (healthBaseline, bool) {
+	bindings, err := api.GetServiceInstanceBindings([]string{instance.GUID})
+	if err != nil {
+		log.Printf("failed to check health of apps bound to instance: %q guid: %q: %s", instance.Name, instance.GUID, err)
+		return healthBaseline{}, false
+	}
+
+	apps := bindings[instance.GUID].Apps
+	if len(apps) == 0 {
+		return healthBaseline{}, false
+	}
+
+	crashed, err := crashedProcessInstances(api, apps)
+	if err != nil {
+		log.Printf("failed to check health of apps bound to instance: %q guid: %q: %s", instance.Name, instance.GUID, err)
+		return healthBaseline{}, false
+	}
+
+	baseline := healthBaseline{apps: apps, crashed: make(map[crashedProcess]bool, len(crashed))}
+	for _, c := range crashed {
+		baseline.crashed[c] = true
+	}
+	return baseline, true
+}
+
+// checkHealth watches the apps bound to a service instance for the health check window after it has been upgraded,
+// because a broker can report that an upgrade succeeded when the apps using the service instance no longer work.
+// The upgrade is degraded when a process instance of a bound app has crashed, unless it had already crashed before
+// the upgrade. The process instances are checked straight away, then at each interval until the window has passed.
+// Failing to look up the processes is logged, but is not treated as the upgrade being degraded.
+func checkHealth(api CFClient, log Logger, instance ccapi.ServiceInstance, baseline healthBaseline, window, interval time.Duration) bool {
+	log.Printf("checking health of %d apps bound to instance: %q guid: %q for %s", len(baseline.apps), instance.Name, instance.GUID, window)
+	deadline := time.Now().Add(window)
+	for {
+		crashed, err := crashedProcessInstances(api, baseline.apps)
+		if err != nil {
+			log.Printf("failed to check health of apps bound to instance: %q guid: %q: %s", instance.Name, instance.GUID, err)
+			return true
+		}
+
+		var degraded []string
+		for _, c := range crashed {
+			if !baseline.crashed[c] {
+				degraded = append(degraded, c.String())
+			}
+		}
+		if len(degraded) > 0 {
+			log.UpgradeDegraded(instance, fmt.Errorf("bound apps have crashed process instances: %s", strings.Join(degraded, ", ")))
+			return false
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return true
+		}
+		time.Sleep(min(interval, remaining))
+	}
+}
+
+// crashedProcessInstances lists the crashed process instances of the apps
+func crashedProcessInstances(api CFClient, apps []ccapi.BoundApp) ([]crashedProcess, error) {
+	var crashed []crashedProcess
+	for _, app := range apps {
+		instances, err := api.GetAppProcessInstances(app.GUID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range instances {
+			if p.HasCrashed() {
+				crashed = append(crashed, crashedProcess{appGUID: app.GUID, appName: app.Name, processType: p.Type, index: p.Index})
+			}
+		}
+	}
+	return crashed, nil
+}
@@ -0,0 +1,172 @@
+package upgrader_test
+
+import (
+	"fmt"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("--health-check-window", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	running := []ccapi.ProcessInstance{{Type: "web", Index: 0, State: "RUNNING"}, {Type: "web", Index: 1, State: "RUNNING"}}
+	crashed := []ccapi.ProcessInstance{{Type: "web", Index: 0, State: "RUNNING"}, {Type: "web", Index: 1, State: "CRASHED"}}
+
+	messages := func() (result []string) {
+		for i := range fakeLogger.PrintfCallCount() {
+			format, args := fakeLogger.PrintfArgsForCall(i)
+			result = append(result, fmt.Sprintf(format, args...))
+		}
+		return result
+	}
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{{GUID: "fake-plan-guid", MaintenanceInfoVersion: "1.2.3"}}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{Name: "fake-instance-1", GUID: "fake-instance-guid-1", UpgradeAvailable: true, MaintenanceInfoVersion: "1.2.2", ServicePlanMaintenanceInfoVersion: "1.2.3"},
+			{Name: "fake-instance-2", GUID: "fake-instance-guid-2", UpgradeAvailable: true, MaintenanceInfoVersion: "1.2.2", ServicePlanMaintenanceInfoVersion: "1.2.3"},
+		}, nil)
+		fakeCFClient.GetServiceInstanceReturns(ccapi.ServiceInstance{MaintenanceInfoVersion: "1.2.3"}, nil)
+		fakeCFClient.GetServiceInstanceBindingsStub = func(guids []string) (map[string]ccapi.Bindings, error) {
+			return map[string]ccapi.Bindings{
+				guids[0]: {Apps: []ccapi.BoundApp{{GUID: "app-guid-1", Name: "app-1"}, {GUID: "app-guid-2", Name: "app-2"}}},
+			}, nil
+		}
+		fakeCFClient.GetAppProcessInstancesReturns(running, nil)
+
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		fakeLogger.HasUpgradeSucceededReturns(true)
+
+		cfg = upgrader.UpgradeConfig{
+			BrokerName:          fakeBrokerName,
+			ParallelUpgrades:    1,
+			HealthCheckWindow:   20 * time.Millisecond,
+			HealthCheckInterval: 5 * time.Millisecond,
+		}
+	})
+
+	It("checks the processes of the bound apps until the window has passed", func() {
+		start := time.Now()
+		Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically(">=", 2*cfg.HealthCheckWindow))
+
+		Expect(fakeCFClient.GetServiceInstanceBindingsCallCount()).To(Equal(2))
+		Expect(fakeCFClient.GetServiceInstanceBindingsArgsForCall(0)).To(Equal([]string{"fake-instance-guid-1"}))
+		Expect(fakeCFClient.GetAppProcessInstancesCallCount()).To(BeNumerically(">=", 8))
+		Expect(fakeCFClient.GetAppProcessInstancesArgsForCall(0)).To(Equal("app-guid-1"))
+		Expect(fakeCFClient.GetAppProcessInstancesArgsForCall(1)).To(Equal("app-guid-2"))
+		Expect(fakeLogger.UpgradeDegradedCallCount()).To(BeZero())
+		Expect(messages()).To(ContainElement(`checking health of 2 apps bound to instance: "fake-instance-1" guid: "fake-instance-guid-1" for 20ms`))
+	})
+
+	It("does not check instances without bound apps", func() {
+		fakeCFClient.GetServiceInstanceBindingsReturns(map[string]ccapi.Bindings{}, nil)
+		fakeCFClient.GetServiceInstanceBindingsStub = nil
+
+		Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		Expect(fakeCFClient.GetAppProcessInstancesCallCount()).To(BeZero())
+	})
+
+	It("does not check the health when there is no window", func() {
+		cfg.HealthCheckWindow = 0
+
+		Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		Expect(fakeCFClient.GetServiceInstanceBindingsCallCount()).To(BeZero())
+	})
+
+	When("a bound app crashes during the window", func() {
+		BeforeEach(func() {
+			fakeCFClient.GetAppProcessInstancesReturnsOnCall(3, crashed, nil)
+			cfg.PostHook = &upgraderfakes.FakeHookRunner{}
+		})
+
+		It("logs that the upgrade is degraded and skips the post-hook", func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+
+			Expect(fakeLogger.UpgradeDegradedCallCount()).To(Equal(1))
+			instance, err := fakeLogger.UpgradeDegradedArgsForCall(0)
+			Expect(instance.GUID).To(Equal("fake-instance-guid-1"))
+			Expect(err).To(MatchError(`bound apps have crashed process instances: "app-2" web/1`))
+			Expect(cfg.PostHook.(*upgraderfakes.FakeHookRunner).RunCallCount()).To(Equal(1))
+		})
+
+		It("carries on upgrading the other instances", func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+			Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(Equal(2))
+		})
+
+		It("stops upgrading with --stop-on-degraded", func() {
+			cfg.StopOnDegraded = true
+
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+			Expect(err).To(MatchError("the upgrade was stopped because apps bound to an upgraded instance crashed. Review the logs for more information"))
+			Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(Equal(1))
+			Expect(messages()).To(ContainElement(`not upgrading instance: "fake-instance-2" guid: "fake-instance-guid-2" as the upgrade was stopped`))
+			Expect(fakeLogger.FinalTotalsCallCount()).To(Equal(1))
+		})
+	})
+
+	When("a bound app had already crashed before the upgrade", func() {
+		BeforeEach(func() {
+			fakeCFClient.GetAppProcessInstancesStub = func(guid string) ([]ccapi.ProcessInstance, error) {
+				if guid == "app-guid-2" {
+					return crashed, nil
+				}
+				return running, nil
+			}
+		})
+
+		It("does not degrade the upgrade", func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+			Expect(fakeCFClient.GetAppProcessInstancesCallCount()).To(BeNumerically(">=", 4))
+			Expect(fakeLogger.UpgradeDegradedCallCount()).To(BeZero())
+		})
+
+		It("degrades the upgrade when another process instance crashes", func() {
+			fakeCFClient.GetAppProcessInstancesStub = func(guid string) ([]ccapi.ProcessInstance, error) {
+				switch {
+				case guid == "app-guid-2":
+					return crashed, nil
+				case fakeCFClient.GetAppProcessInstancesCallCount() > 2:
+					return crashed, nil
+				default:
+					return running, nil
+				}
+			}
+
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+			Expect(fakeLogger.UpgradeDegradedCallCount()).To(Equal(1))
+			_, err := fakeLogger.UpgradeDegradedArgsForCall(0)
+			Expect(err).To(MatchError(`bound apps have crashed process instances: "app-1" web/1`))
+		})
+	})
+
+	It("logs a failure to look up the bound apps without degrading the upgrade", func() {
+		fakeCFClient.GetServiceInstanceBindingsStub = nil
+		fakeCFClient.GetServiceInstanceBindingsReturns(nil, fmt.Errorf("boom"))
+
+		Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		Expect(fakeLogger.UpgradeDegradedCallCount()).To(BeZero())
+		Expect(messages()).To(ContainElement(`failed to check health of apps bound to instance: "fake-instance-1" guid: "fake-instance-guid-1": boom`))
+	})
+
+	It("logs a failure to get the process instances without degrading the upgrade", func() {
+		fakeCFClient.GetAppProcessInstancesReturns(nil, fmt.Errorf("bang"))
+
+		Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		Expect(fakeLogger.UpgradeDegradedCallCount()).To(BeZero())
+		Expect(messages()).To(ContainElement(`failed to check health of apps bound to instance: "fake-instance-2" guid: "fake-instance-guid-2": bang`))
+	})
+})
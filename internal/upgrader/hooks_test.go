@@ -0,0 +1,121 @@
+package upgrader_test
+
+import (
+	"fmt"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("--pre-hook and --post-hook", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		fakePreHook  *upgraderfakes.FakeHookRunner
+		fakePostHook *upgraderfakes.FakeHookRunner
+		instance     ccapi.ServiceInstance
+	)
+
+	BeforeEach(func() {
+		instance = ccapi.ServiceInstance{Name: "fake-instance", GUID: "fake-instance-guid", UpgradeAvailable: true, ServicePlanMaintenanceInfoVersion: "1.2.3"}
+
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{{GUID: "fake-plan-guid", MaintenanceInfoVersion: "1.2.3"}}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{instance}, nil)
+		fakeCFClient.GetServiceInstanceReturns(ccapi.ServiceInstance{GUID: "fake-instance-guid", MaintenanceInfoVersion: "1.2.3"}, nil)
+
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		fakeLogger.HasUpgradeSucceededReturns(true)
+
+		fakePreHook = &upgraderfakes.FakeHookRunner{}
+		fakePostHook = &upgraderfakes.FakeHookRunner{}
+	})
+
+	upgrade := func() error {
+		return upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+			BrokerName:       fakeBrokerName,
+			ParallelUpgrades: 1,
+			Attempts:         3,
+			PreHook:          fakePreHook,
+			PostHook:         fakePostHook,
+		})
+	}
+
+	loggedMessages := func() (result []string) {
+		for i := range fakeLogger.PrintfCallCount() {
+			format, args := fakeLogger.PrintfArgsForCall(i)
+			result = append(result, fmt.Sprintf(format, args...))
+		}
+		return result
+	}
+
+	It("runs the hooks before and after the upgrade", func() {
+		fakePreHook.RunReturns("backup complete\n", nil)
+		fakePostHook.RunReturns("", nil)
+
+		Expect(upgrade()).To(Succeed())
+
+		Expect(fakePreHook.RunCallCount()).To(Equal(1))
+		Expect(fakePreHook.RunArgsForCall(0)).To(Equal(instance))
+		Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(Equal(1))
+		Expect(fakePostHook.RunCallCount()).To(Equal(1))
+		Expect(fakePostHook.RunArgsForCall(0)).To(Equal(instance))
+
+		By("logging the output of the hooks")
+		Expect(loggedMessages()).To(ContainElement("pre-hook output for instance: \"fake-instance\" guid: \"fake-instance-guid\":\nbackup complete"))
+		Expect(loggedMessages()).NotTo(ContainElement(HavePrefix("post-hook output")))
+	})
+
+	It("runs the pre-hook once when the upgrade is retried", func() {
+		fakeCFClient.UpgradeServiceInstanceReturnsOnCall(0, fmt.Errorf("boom"))
+
+		Expect(upgrade()).To(Succeed())
+
+		Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(Equal(2))
+		Expect(fakePreHook.RunCallCount()).To(Equal(1))
+		Expect(fakePostHook.RunCallCount()).To(Equal(1))
+	})
+
+	It("skips the instance when the pre-hook fails", func() {
+		fakePreHook.RunReturns("no space left on device", fmt.Errorf("exit status 1"))
+		fakeLogger.HasUpgradeSucceededReturns(false)
+
+		Expect(upgrade()).To(MatchError("there were failures upgrading one or more instances. Review the logs for more information"))
+
+		Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(BeZero())
+		Expect(fakePostHook.RunCallCount()).To(BeZero())
+		Expect(fakeLogger.UpgradeStartingCallCount()).To(BeZero())
+		Expect(fakeLogger.PreHookFailedCallCount()).To(Equal(1))
+		loggedInstance, err := fakeLogger.PreHookFailedArgsForCall(0)
+		Expect(loggedInstance).To(Equal(instance))
+		Expect(err).To(MatchError("exit status 1"))
+		Expect(loggedMessages()).To(ContainElement("pre-hook output for instance: \"fake-instance\" guid: \"fake-instance-guid\":\nno space left on device"))
+	})
+
+	It("reports a post-hook failure separately from the upgrade", func() {
+		fakePostHook.RunReturns("", fmt.Errorf("timed out after 5m0s"))
+
+		Expect(upgrade()).To(Succeed())
+
+		Expect(fakeLogger.UpgradeSucceededCallCount()).To(Equal(1))
+		Expect(fakeLogger.UpgradeFailedCallCount()).To(BeZero())
+		Expect(fakeLogger.PostHookFailedCallCount()).To(Equal(1))
+		loggedInstance, err := fakeLogger.PostHookFailedArgsForCall(0)
+		Expect(loggedInstance).To(Equal(instance))
+		Expect(err).To(MatchError("timed out after 5m0s"))
+	})
+
+	It("does not run the post-hook when the upgrade fails", func() {
+		fakeCFClient.UpgradeServiceInstanceReturns(fmt.Errorf("boom"))
+
+		_ = upgrade()
+
+		Expect(fakePreHook.RunCallCount()).To(Equal(1))
+		Expect(fakePostHook.RunCallCount()).To(BeZero())
+	})
+})
@@ -0,0 +1,181 @@
+package upgrader
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/tabular"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
+)
+
+// histogramWidth is the length of the bar for the most common version of a plan
+const histogramWidth = 30
+
+// inventoryColumns are the columns of the list of service instances in the text output
+var inventoryColumns = []config.Column{
+	config.StatusColumn, config.OrgColumn, config.SpaceColumn, config.NameColumn,
+	config.OfferingColumn, config.PlanColumn, config.VersionColumn, config.PlanVersionColumn,
+}
+
+// performInventory lists every service instance with its version, and the distribution of versions for each plan.
+// Unlike the checks, it is not a pass/fail check, so it never returns an InstanceError.
+func performInventory(api CFClient, cfg UpgradeConfig) error {
+	instances, err := getGroupedServiceInstances(api, cfg.BrokerName, 0)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cfg.JSONOutput:
+		return outputInventoryJSON(instances.all, cfg.VersionComparison)
+	case cfg.Template != nil:
+		return outputTemplate(cfg.Template, newTemplateData(cfg.BrokerName, instances))
+	case cfg.Output.IsTabular():
+		return outputTabular(cfg.Output, cfg.Columns, inventoryRows(instances.all))
+	default:
+		return outputInventoryText(instances.all, cfg.BrokerName, cfg.VersionComparison)
+	}
+}
+
+// planVersions is the histogram of the versions of the service instances of a plan
+type planVersions struct {
+	Offering    string          `json:"offering"`
+	Plan        string          `json:"plan"`
+	PlanVersion string          `json:"plan_version"`
+	Total       int             `json:"total"`
+	Versions    []summary.Group `json:"versions"`
+}
+
+// versionHistograms groups the service instances by plan, ordered by service offering and plan name.
+// The versions of each plan are listed newest first.
+func versionHistograms(instances []ccapi.ServiceInstance, comparison versionchecker.Comparison) []planVersions {
+	byPlan := make(map[string][]ccapi.ServiceInstance)
+	for _, instance := range instances {
+		byPlan[instance.ServicePlanGUID] = append(byPlan[instance.ServicePlanGUID], instance)
+	}
+
+	histograms := make([]planVersions, 0, len(byPlan))
+	for _, planInstances := range byPlan {
+		versions := summary.New(planInstances).ByVersion
+		slices.SortFunc(versions, func(a, b summary.Group) int { return compareVersions(comparison, b.Name, a.Name) })
+
+		histograms = append(histograms, planVersions{
+			Offering:    planInstances[0].ServiceOfferingName,
+			Plan:        planInstances[0].ServicePlanName,
+			PlanVersion: planInstances[0].ServicePlanMaintenanceInfoVersion,
+			Total:       len(planInstances),
+			Versions:    versions,
+		})
+	}
+	slices.SortFunc(histograms, func(a, b planVersions) int {
+		return cmp.Or(cmp.Compare(a.Offering, b.Offering), cmp.Compare(a.Plan, b.Plan))
+	})
+	return histograms
+}
+
+// compareVersions orders versions semantically. Versions that cannot be parsed come first, so that they
+// are last when listing newest first. Versions that the comparison treats as equal are ordered by name.
+func compareVersions(comparison versionchecker.Comparison, a, b string) int {
+	va, errA := comparison.Parse(a)
+	vb, errB := comparison.Parse(b)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Or(va.Compare(vb), cmp.Compare(a, b))
+	case errA == nil:
+		return 1
+	case errB == nil:
+		return -1
+	default:
+		return cmp.Compare(a, b)
+	}
+}
+
+// inventoryStatus says whether the service instance is up to date, or why not
+func inventoryStatus(instance ccapi.ServiceInstance) string {
+	switch {
+	case instance.ServicePlanDeactivated:
+		return statusPlanDeactivated
+	case instance.UpgradeAvailable && ccapi.HasInstanceCreateFailedStatus(instance):
+		return statusCreateFailed
+	case instance.UpgradeAvailable:
+		return statusUpgradePending
+	default:
+		return statusUpToDate
+	}
+}
+
+func inventoryRows(instances []ccapi.ServiceInstance) []tabularRow {
+	return slicex.Map(instances, func(instance ccapi.ServiceInstance) tabularRow {
+		return tabularRow{status: inventoryStatus(instance), instance: instance}
+	})
+}
+
+func outputInventoryText(instances []ccapi.ServiceInstance, brokerName string, comparison versionchecker.Comparison) error {
+	fmt.Printf("Inventory of service instances for broker: %s\n", brokerName)
+	fmt.Printf("Total number of service instances: %d\n", len(instances))
+	fmt.Println()
+
+	for _, h := range versionHistograms(instances, comparison) {
+		fmt.Printf("Versions of service offering %q plan %q (plan version %q): %d\n", h.Offering, h.Plan, h.PlanVersion, h.Total)
+
+		largest := slices.MaxFunc(h.Versions, func(a, b summary.Group) int { return cmp.Compare(a.Count, b.Count) }).Count
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, v := range h.Versions {
+			fmt.Fprintf(tw, "  %s\t%s\t%d\t%.1f%%\n", v.Name, histogramBar(v.Count, largest), v.Count, v.Percent)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	if len(instances) == 0 {
+		fmt.Println("No service instances found")
+		return nil
+	}
+
+	fmt.Println("Service instances:")
+	table := tabular.Table{Header: slicex.Map(inventoryColumns, func(c config.Column) string { return string(c) })}
+	for _, row := range inventoryRows(instances) {
+		table.Rows = append(table.Rows, slicex.Map(inventoryColumns, row.value))
+	}
+	return tabular.WriteTable(os.Stdout, table, tabular.TerminalWidth())
+}
+
+// histogramBar is scaled so that the most common version of the plan has the longest bar
+func histogramBar(count, largest int) string {
+	return strings.Repeat("#", max(1, count*histogramWidth/largest))
+}
+
+func outputInventoryJSON(instances []ccapi.ServiceInstance, comparison versionchecker.Comparison) error {
+	type formatter struct {
+		Instances []jsonOutputServiceInstance `json:"instances"`
+		Versions  []planVersions              `json:"versions"`
+	}
+
+	data := formatter{
+		Instances: slicex.Map(instances, func(instance ccapi.ServiceInstance) jsonOutputServiceInstance {
+			i := newJSONOutputServiceInstance(instance)
+			i.PlanVersion = instance.ServicePlanMaintenanceInfoVersion
+			i.Status = inventoryStatus(instance)
+			return i
+		}),
+		Versions: versionHistograms(instances, comparison),
+	}
+
+	output, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(output))
+	return nil
+}
@@ -0,0 +1,160 @@
+package upgrader_test
+
+import (
+	"encoding/json"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("--inventory", func() {
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		cfg = upgrader.UpgradeConfig{BrokerName: "fake-broker-name", Action: config.InventoryAction}
+
+		instance := func(name, plan, version, planVersion string) ccapi.ServiceInstance {
+			return ccapi.ServiceInstance{
+				Name:                              name,
+				GUID:                              name + "-guid",
+				OrganizationName:                  "org",
+				SpaceName:                         "space",
+				ServiceOfferingName:               "mysql",
+				ServicePlanGUID:                   plan + "-guid",
+				ServicePlanName:                   plan,
+				MaintenanceInfoVersion:            version,
+				ServicePlanMaintenanceInfoVersion: planVersion,
+				UpgradeAvailable:                  version != planVersion,
+			}
+		}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{{GUID: "small-guid"}, {GUID: "large-guid"}}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			instance("a", "small", "1.10.0", "1.10.0"),
+			instance("b", "small", "1.9.0", "1.10.0"),
+			instance("c", "small", "1.10.0", "1.10.0"),
+			instance("d", "large", "1.10.0", "1.10.0"),
+		}, nil)
+	})
+
+	It("lists the versions of each plan and every instance as text", func() {
+		GinkgoT().Setenv("COLUMNS", "")
+
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(Equal(`Inventory of service instances for broker: fake-broker-name
+Total number of service instances: 4
+
+Versions of service offering "mysql" plan "large" (plan version "1.10.0"): 1
+  1.10.0  ##############################  1  100.0%
+
+Versions of service offering "mysql" plan "small" (plan version "1.10.0"): 3
+  1.10.0  ##############################  2  66.7%
+  1.9.0   ###############                 1  33.3%
+
+Service instances:
+status           org  space  name  offering  plan   version  plan_version
+up_to_date       org  space  a     mysql     small  1.10.0   1.10.0
+upgrade_pending  org  space  b     mysql     small  1.9.0    1.10.0
+up_to_date       org  space  c     mysql     small  1.10.0   1.10.0
+up_to_date       org  space  d     mysql     large  1.10.0   1.10.0
+`))
+	})
+
+	It("writes JSON", func() {
+		cfg.JSONOutput = true
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		var receiver struct {
+			Instances []struct {
+				Name        string `json:"name"`
+				Status      string `json:"status"`
+				ServicePlan struct {
+					MaintenanceInfo struct {
+						Version string `json:"version"`
+					} `json:"maintenance_info"`
+				} `json:"service_plan"`
+			} `json:"instances"`
+			Versions []struct {
+				Plan     string `json:"plan"`
+				Total    int    `json:"total"`
+				Versions []struct {
+					Name    string  `json:"name"`
+					Count   int     `json:"count"`
+					Percent float64 `json:"percent"`
+				} `json:"versions"`
+			} `json:"versions"`
+		}
+		Expect(json.Unmarshal([]byte(output), &receiver)).To(Succeed())
+		Expect(receiver.Instances).To(HaveLen(4))
+		Expect(receiver.Instances[1].Name).To(Equal("b"))
+		Expect(receiver.Instances[1].Status).To(Equal("upgrade_pending"))
+		Expect(receiver.Instances[1].ServicePlan.MaintenanceInfo.Version).To(Equal("1.10.0"))
+		Expect(receiver.Versions).To(HaveLen(2))
+		Expect(receiver.Versions[1].Plan).To(Equal("small"))
+		Expect(receiver.Versions[1].Total).To(Equal(3))
+		Expect(receiver.Versions[1].Versions[0].Name).To(Equal("1.10.0"))
+		Expect(receiver.Versions[1].Versions[0].Count).To(Equal(2))
+		Expect(receiver.Versions[1].Versions[1].Percent).To(Equal(33.3))
+	})
+
+	It("orders the versions using the version comparison", func() {
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "a-guid", ServicePlanGUID: "small-guid", MaintenanceInfoVersion: "2.10.14"},
+			{GUID: "b-guid", ServicePlanGUID: "small-guid", MaintenanceInfoVersion: "2.10.14-build.3"},
+			{GUID: "c-guid", ServicePlanGUID: "small-guid", MaintenanceInfoVersion: "2.10.14-build.12"},
+		}, nil)
+		cfg.JSONOutput = true
+
+		versions := func(comparison versionchecker.Comparison) []string {
+			cfg.VersionComparison = comparison
+			output := captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+			})
+
+			var receiver struct {
+				Versions []struct {
+					Versions []struct {
+						Name string `json:"name"`
+					} `json:"versions"`
+				} `json:"versions"`
+			}
+			Expect(json.Unmarshal([]byte(output), &receiver)).To(Succeed())
+			Expect(receiver.Versions).To(HaveLen(1))
+
+			var names []string
+			for _, v := range receiver.Versions[0].Versions {
+				names = append(names, v.Name)
+			}
+			return names
+		}
+
+		Expect(versions(versionchecker.SemverComparison)).To(Equal([]string{"2.10.14", "2.10.14-build.12", "2.10.14-build.3"}))
+		Expect(versions(versionchecker.PostReleaseComparison)).To(Equal([]string{"2.10.14-build.12", "2.10.14-build.3", "2.10.14"}))
+	})
+
+	It("writes CSV", func() {
+		cfg.Output = config.CSVOutput
+		cfg.Columns = []config.Column{config.NameColumn, config.StatusColumn, config.VersionColumn}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(Equal("name,status,version\na,up_to_date,1.10.0\nb,upgrade_pending,1.9.0\nc,up_to_date,1.10.0\nd,up_to_date,1.10.0\n"))
+	})
+})
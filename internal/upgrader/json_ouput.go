@@ -1,12 +1,25 @@
 package upgrader
 
 import (
+	"encoding/json"
+	"fmt"
 	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/slicex"
 
 	"code.cloudfoundry.org/jsonry"
 )
 
 func newJSONOutputServiceInstance(instance ccapi.ServiceInstance) jsonOutputServiceInstance {
+	var lastUpgrade *jsonOutputProvenance
+	if p, ok := instance.Provenance(); ok {
+		lastUpgrade = &jsonOutputProvenance{
+			UpgradedBy:      p.UpgradedBy,
+			UpgradedAt:      p.UpgradedAt,
+			PreviousVersion: p.PreviousVersion,
+			RunID:           p.RunID,
+		}
+	}
+
 	return jsonOutputServiceInstance{
 		Name:         instance.Name,
 		GUID:         instance.GUID,
@@ -19,23 +32,118 @@ func newJSONOutputServiceInstance(instance ccapi.ServiceInstance) jsonOutputServ
 		PlanGUID:     instance.ServicePlanGUID,
 		OfferingName: instance.ServiceOfferingName,
 		OfferingGUID: instance.ServiceOfferingGUID,
+		LastUpgrade:  lastUpgrade,
 	}
 }
 
 type jsonOutputServiceInstance struct {
-	Name         string `json:"name"`
-	GUID         string `json:"guid"`
-	Version      string `jsonry:"maintenance_info.version"`
-	SpaceName    string `jsonry:"space.name"`
-	SpaceGUID    string `jsonry:"space.guid"`
-	OrgName      string `jsonry:"organization.name"`
-	OrgGUID      string `jsonry:"organization.guid"`
-	PlanName     string `jsonry:"service_plan.name"`
-	PlanGUID     string `jsonry:"service_plan.guid"`
-	OfferingName string `jsonry:"service_offering.name"`
-	OfferingGUID string `jsonry:"service_offering.guid"`
+	Name         string                `json:"name"`
+	GUID         string                `json:"guid"`
+	Version      string                `jsonry:"maintenance_info.version"`
+	SpaceName    string                `jsonry:"space.name"`
+	SpaceGUID    string                `jsonry:"space.guid"`
+	OrgName      string                `jsonry:"organization.name"`
+	OrgGUID      string                `jsonry:"organization.guid"`
+	PlanName     string                `jsonry:"service_plan.name"`
+	PlanGUID     string                `jsonry:"service_plan.guid"`
+	OfferingName string                `jsonry:"service_offering.name"`
+	OfferingGUID string                `jsonry:"service_offering.guid"`
+	LastUpgrade  *jsonOutputProvenance `json:"last_upgrade,omitempty"`
+
+	// These are only set by the inventory, and PlanVersion when checking for version anomalies
+	PlanVersion string `jsonry:"service_plan.maintenance_info.version,omitempty"`
+	Status      string `json:"status,omitempty"`
+
+	// This is only set when checking against a minimum version policy
+	Rule string `json:"rule,omitempty"`
+
+	// These are only set when checking the age of pending upgrades
+	PendingSince string `json:"pending_since,omitempty"`
+	PendingDays  int    `json:"pending_days,omitempty"`
+
+	// This is only set when checking for failed operations
+	LastOperation *jsonOutputLastOperation `json:"last_operation,omitempty"`
+
+	// This is only set when checking for deactivated plans
+	Replacement *jsonOutputReplacement `json:"replacement,omitempty"`
+
+	// This is only set by a dry run with --blast-radius
+	Bindings *jsonOutputBindings `json:"bindings,omitempty"`
+}
+
+type jsonOutputBindings struct {
+	AppCount      int                  `json:"app_count"`
+	Apps          []jsonOutputBoundApp `json:"apps"`
+	RouteBindings int                  `json:"route_bindings"`
+	ServiceKeys   int                  `json:"service_keys"`
+}
+
+type jsonOutputBoundApp struct {
+	GUID string `json:"guid"`
+	Name string `json:"name"`
+}
+
+type jsonOutputBlastRadius struct {
+	OrgName   string `json:"org"`
+	OrgGUID   string `json:"org_guid"`
+	Instances int    `json:"instances"`
+	Apps      int    `json:"apps"`
+}
+
+type jsonOutputReplacement struct {
+	Candidates []string `json:"candidates"`
+	Plan       string   `json:"plan,omitempty"`
+	Preferred  bool     `json:"preferred,omitempty"`
+	Command    string   `json:"command,omitempty"`
+	Migration  string   `json:"migration,omitempty"`
+}
+
+type jsonOutputLastOperation struct {
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	UpdatedAt   string `json:"updated_at,omitempty"`
+	AgeDays     int    `json:"age_days"`
+}
+
+type jsonOutputProvenance struct {
+	UpgradedBy      string `json:"upgraded_by"`
+	UpgradedAt      string `json:"upgraded_at"`
+	PreviousVersion string `json:"previous_version"`
+	RunID           string `json:"run_id"`
 }
 
 func (m jsonOutputServiceInstance) MarshalJSON() ([]byte, error) {
 	return jsonry.Marshal(m)
 }
+
+// outputCheckJSON writes the service instances in each section of a check result, and the summaries when they
+// were requested. The output is an object keyed by section, or for a check with listOutput, a list of the service
+// instances in the first section. To make room for the summaries or for the other sections with service instances,
+// the list is moved into an object, under the instances key.
+func outputCheckJSON(result checkResult, withSummary bool) error {
+	data := make(map[string]any)
+	for i, section := range result.sections {
+		switch {
+		case result.listOutput && i == 0:
+			data["instances"] = slicex.Map(section.Instances, result.jsonInstance)
+		case !result.listOutput || len(section.Instances) > 0:
+			data[section.Key] = slicex.Map(section.Instances, result.jsonInstance)
+		}
+	}
+	if summaries := summaryJSON(withSummary, result.sections...); summaries != nil {
+		data["summary"] = summaries
+	}
+
+	var value any = data
+	if result.listOutput && len(data) == 1 {
+		value = data["instances"]
+	}
+
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(output))
+	return nil
+}
@@ -0,0 +1,66 @@
+package upgrader
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/junit"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+)
+
+// writeJUnitReport writes a test suite for each check, with a test case for each service instance, when a JUnit
+// report has been requested. A check without test cases, such as the dry run, has no test suite.
+func writeJUnitReport(w io.Writer, instances []ccapi.ServiceInstance, results ...checkResult) error {
+	if w == nil {
+		return nil
+	}
+
+	var suites []junit.TestSuite
+	for _, r := range results {
+		if r.details != nil {
+			suites = append(suites, junit.NewSuite(r.name, slicex.Map(instances, r.details.testCase)))
+		}
+	}
+
+	if err := junit.Write(w, suites...); err != nil {
+		return fmt.Errorf("error writing JUnit report: %w", err)
+	}
+	return nil
+}
+
+// upToDateTestCase fails for the same reasons as upToDateCheck. Instances that failed to create
+// are reported but do not fail the check, so they are skipped.
+func upToDateTestCase(instance ccapi.ServiceInstance) junit.TestCase {
+	var kinds, messages []string
+	if instance.ServicePlanDeactivated {
+		kinds = append(kinds, "DeactivatedPlan")
+		messages = append(messages, deactivatedPlanMessage(instance))
+	}
+
+	createFailed := ccapi.HasInstanceCreateFailedStatus(instance)
+	if instance.UpgradeAvailable && !createFailed {
+		kinds = append(kinds, "UpgradeAvailable")
+		messages = append(messages, fmt.Sprintf("upgrade available from version %q to %q", instance.MaintenanceInfoVersion, instance.ServicePlanMaintenanceInfoVersion))
+	}
+
+	switch {
+	case len(messages) > 0:
+		return junit.InstanceCase(instance).WithFailure(strings.Join(kinds, ","), strings.Join(messages, "; "))
+	case instance.UpgradeAvailable && createFailed:
+		return junit.InstanceCase(instance).WithSkipped("upgrade available, but the service instance failed to create")
+	default:
+		return junit.InstanceCase(instance)
+	}
+}
+
+func deactivatedPlanTestCase(instance ccapi.ServiceInstance) junit.TestCase {
+	if instance.ServicePlanDeactivated {
+		return junit.InstanceCase(instance).WithFailure("DeactivatedPlan", deactivatedPlanMessage(instance))
+	}
+	return junit.InstanceCase(instance)
+}
+
+func deactivatedPlanMessage(instance ccapi.ServiceInstance) string {
+	return fmt.Sprintf("service plan %q of service offering %q is deactivated", instance.ServicePlanName, instance.ServiceOfferingName)
+}
@@ -0,0 +1,108 @@
+package upgrader_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JUnit report", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	type testCase struct {
+		Name    string `xml:"name,attr"`
+		Failure *struct {
+			Message string `xml:"message,attr"`
+			Type    string `xml:"type,attr"`
+		} `xml:"failure"`
+		Skipped *struct {
+			Message string `xml:"message,attr"`
+		} `xml:"skipped"`
+	}
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		buffer       *bytes.Buffer
+	)
+
+	run := func(cfg upgrader.UpgradeConfig) (string, []testCase) {
+		cfg.BrokerName = fakeBrokerName
+		cfg.JUnitReport = buffer
+		_ = captureStdout(func() {
+			_ = upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+		})
+
+		var receiver struct {
+			Suites []struct {
+				Name  string     `xml:"name,attr"`
+				Cases []testCase `xml:"testcase"`
+			} `xml:"testsuite"`
+		}
+		Expect(xml.Unmarshal(buffer.Bytes(), &receiver)).To(Succeed())
+		Expect(receiver.Suites).To(HaveLen(1))
+		return receiver.Suites[0].Name, receiver.Suites[0].Cases
+	}
+
+	BeforeEach(func() {
+		buffer = &bytes.Buffer{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{{GUID: "fake-plan-guid"}}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "guid-1", Name: "up-to-date", OrganizationName: "org", SpaceName: "space", MaintenanceInfoVersion: "1.2.3", ServicePlanMaintenanceInfoVersion: "1.2.3"},
+			{GUID: "guid-2", Name: "upgradeable", OrganizationName: "org", SpaceName: "space", ServicePlanName: "large", ServiceOfferingName: "postgres", UpgradeAvailable: true, MaintenanceInfoVersion: "1.2.2", ServicePlanMaintenanceInfoVersion: "1.2.3"},
+			{GUID: "guid-3", Name: "create-failed", OrganizationName: "org", SpaceName: "space", UpgradeAvailable: true, LastOperationType: "create", LastOperationState: "failed", MaintenanceInfoVersion: "1.2.2"},
+			{GUID: "guid-4", Name: "deactivated", OrganizationName: "org", SpaceName: "space", ServicePlanDeactivated: true, ServicePlanName: "small", ServiceOfferingName: "postgres", MaintenanceInfoVersion: "1.2.3"},
+		}, nil)
+	})
+
+	It("reports a test case for each instance of the up-to-date check", func() {
+		name, cases := run(upgrader.UpgradeConfig{Action: config.CheckUpToDateAction})
+		Expect(name).To(Equal("check-up-to-date"))
+		Expect(cases).To(HaveLen(4))
+		Expect(cases[0].Name).To(Equal("org/space/up-to-date"))
+		Expect(cases[0].Failure).To(BeNil())
+		Expect(cases[0].Skipped).To(BeNil())
+		Expect(cases[1].Failure.Type).To(Equal("UpgradeAvailable"))
+		Expect(cases[1].Failure.Message).To(Equal(`service instance "upgradeable" (org "org", space "space", offering "postgres", plan "large"): upgrade available from version "1.2.2" to "1.2.3"`))
+		Expect(cases[2].Failure).To(BeNil())
+		Expect(cases[2].Skipped.Message).To(Equal("upgrade available, but the service instance failed to create"))
+		Expect(cases[3].Failure.Type).To(Equal("DeactivatedPlan"))
+		Expect(cases[3].Failure.Message).To(Equal(`service instance "deactivated" (org "org", space "space", offering "postgres", plan "small"): service plan "small" of service offering "postgres" is deactivated`))
+	})
+
+	It("reports a test case for each instance of the deactivated plans check", func() {
+		name, cases := run(upgrader.UpgradeConfig{Action: config.CheckDeactivatedPlansAction})
+		Expect(name).To(Equal("check-deactivated-plans"))
+		Expect(cases).To(HaveLen(4))
+		Expect(cases[1].Failure).To(BeNil())
+		Expect(cases[3].Failure.Type).To(Equal("DeactivatedPlan"))
+	})
+
+	It("reports a test case for each instance of the minimum version check", func() {
+		name, cases := run(upgrader.UpgradeConfig{Action: config.MinVersionCheckAction, MinVersion: version.Must(version.NewVersion("1.2.3"))})
+		Expect(name).To(Equal("min-version-required"))
+		Expect(cases).To(HaveLen(4))
+		Expect(cases[0].Failure).To(BeNil())
+		Expect(cases[1].Failure.Type).To(Equal("BelowMinimumVersion"))
+		Expect(cases[1].Failure.Message).To(HaveSuffix(`: version "1.2.2" is lower than the minimum required "1.2.3"`))
+		Expect(cases[2].Failure.Type).To(Equal("BelowMinimumVersion"))
+		Expect(cases[3].Failure).To(BeNil())
+	})
+
+	It("does not write a report unless requested", func() {
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{BrokerName: fakeBrokerName, Action: config.CheckUpToDateAction, JSONOutput: true})
+			Expect(err).To(HaveOccurred())
+		})
+		Expect(output).NotTo(ContainSubstring("<testsuites"))
+	})
+})
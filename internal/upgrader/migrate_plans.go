@@ -0,0 +1,111 @@
+package upgrader
+
+import (
+	"errors"
+	"fmt"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+)
+
+// performPlanMigration moves service instances from one plan to another, as specified by the plan mappings.
+// It uses the same worker pool, retries and reporting as an upgrade.
+func performPlanMigration(api CFClient, log Logger, cfg UpgradeConfig) error {
+	servicePlans, err := api.GetServicePlans(cfg.BrokerName)
+	if err != nil {
+		return err
+	}
+
+	if len(servicePlans) == 0 {
+		return fmt.Errorf("no service plans available for broker: %s", cfg.BrokerName)
+	}
+
+	targets, err := resolvePlanMappings(servicePlans, cfg.PlanMappings)
+	if err != nil {
+		return err
+	}
+
+	sourcePlans := slicex.Filter(servicePlans, func(plan ccapi.ServicePlan) bool {
+		_, ok := targets[plan.GUID]
+		return ok
+	})
+
+	log.Printf("discovering service instances for broker: %s", cfg.BrokerName)
+	instances, err := api.GetServiceInstancesForServicePlans(sourcePlans)
+	if err != nil {
+		return err
+	}
+
+	createFailed, migratable := slicex.Partition(instances, ccapi.HasInstanceCreateFailedStatus)
+	if cfg.Limit > 0 && len(migratable) > cfg.Limit {
+		migratable = migratable[:cfg.Limit]
+	}
+
+	log.InitialTotals(len(instances), len(migratable))
+	defer log.FinalTotals()
+	for _, instance := range createFailed {
+		log.SkippingInstance(instance)
+	}
+	if len(migratable) == 0 {
+		log.Printf("no instances available to migrate")
+		return nil
+	}
+
+	runOperations(migratable, cfg.ParallelUpgrades, cfg.Attempts, cfg.RetryInterval, log, operation{
+		perform: func(instance ccapi.ServiceInstance) error {
+			return api.UpdateServiceInstancePlan(instance.GUID, targets[instance.ServicePlanGUID].GUID)
+		},
+	})
+
+	if !log.HasUpgradeSucceeded() {
+		return errors.New("there were failures migrating one or more instances. Review the logs for more information")
+	}
+	return nil
+}
+
+// resolvePlanMappings validates the plan mappings against the plans of the broker. It returns a lookup
+// from the GUID of each source plan to the target plan.
+func resolvePlanMappings(plans []ccapi.ServicePlan, mappings []config.PlanMapping) (map[string]ccapi.ServicePlan, error) {
+	targets := make(map[string]ccapi.ServicePlan, len(mappings))
+	for _, m := range mappings {
+		from, err := findPlan(plans, m.ServiceOfferingName, m.FromPlanName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plan mapping %q: %w", m, err)
+		}
+
+		to, err := findPlan(plans, from.ServiceOfferingName, m.ToPlanName)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plan mapping %q: %w", m, err)
+		}
+
+		if !to.Available {
+			return nil, fmt.Errorf("invalid plan mapping %q: plan %q of service offering %q is not active", m, to.Name, to.ServiceOfferingName)
+		}
+
+		if _, ok := targets[from.GUID]; ok {
+			return nil, fmt.Errorf("invalid plan mapping %q: plan %q of service offering %q is mapped more than once", m, from.Name, from.ServiceOfferingName)
+		}
+
+		targets[from.GUID] = to
+	}
+
+	return targets, nil
+}
+
+// findPlan finds a plan by name, optionally restricted to a service offering
+func findPlan(plans []ccapi.ServicePlan, offeringName, planName string) (ccapi.ServicePlan, error) {
+	matches := slicex.Filter(plans, func(plan ccapi.ServicePlan) bool {
+		return plan.Name == planName && (offeringName == "" || plan.ServiceOfferingName == offeringName)
+	})
+
+	switch {
+	case len(matches) == 0 && offeringName == "":
+		return ccapi.ServicePlan{}, fmt.Errorf("plan %q not found", planName)
+	case len(matches) == 0:
+		return ccapi.ServicePlan{}, fmt.Errorf("plan %q not found for service offering %q", planName, offeringName)
+	case len(matches) > 1:
+		return ccapi.ServicePlan{}, fmt.Errorf("plan name %q is used by more than one service offering, specify the service offering", planName)
+	default:
+		return matches[0], nil
+	}
+}
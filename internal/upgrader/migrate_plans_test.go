@@ -0,0 +1,106 @@
+package upgrader_test
+
+import (
+	"fmt"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("--migrate-plans", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		oldPlan      ccapi.ServicePlan
+		newPlan      ccapi.ServicePlan
+		otherPlan    ccapi.ServicePlan
+	)
+
+	BeforeEach(func() {
+		oldPlan = ccapi.ServicePlan{GUID: "old-plan-guid", Name: "small", Available: false, ServiceOfferingName: "postgres"}
+		newPlan = ccapi.ServicePlan{GUID: "new-plan-guid", Name: "medium", Available: true, ServiceOfferingName: "postgres"}
+		otherPlan = ccapi.ServicePlan{GUID: "other-plan-guid", Name: "small", Available: true, ServiceOfferingName: "redis"}
+
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{oldPlan, newPlan, otherPlan}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "instance-guid-1", ServicePlanGUID: oldPlan.GUID},
+			{GUID: "instance-guid-2", ServicePlanGUID: oldPlan.GUID},
+			{GUID: "instance-guid-3", ServicePlanGUID: oldPlan.GUID, LastOperationType: "create", LastOperationState: "failed"},
+		}, nil)
+
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		fakeLogger.HasUpgradeSucceededReturns(true)
+	})
+
+	It("moves the instances onto the new plan", func() {
+		err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+			BrokerName:       fakeBrokerName,
+			ParallelUpgrades: 1,
+			Action:           config.MigratePlansAction,
+			PlanMappings:     []config.PlanMapping{{ServiceOfferingName: "postgres", FromPlanName: "small", ToPlanName: "medium"}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("only getting the instances of the source plans")
+		Expect(fakeCFClient.GetServiceInstancesForServicePlansCallCount()).To(Equal(1))
+		Expect(fakeCFClient.GetServiceInstancesForServicePlansArgsForCall(0)).To(Equal([]ccapi.ServicePlan{oldPlan}))
+
+		By("updating the plan of each instance that did not fail to create")
+		Expect(fakeCFClient.UpgradeServiceInstanceCallCount()).To(BeZero())
+		Expect(fakeCFClient.UpdateServiceInstancePlanCallCount()).To(Equal(2))
+		guid1, plan1 := fakeCFClient.UpdateServiceInstancePlanArgsForCall(0)
+		guid2, plan2 := fakeCFClient.UpdateServiceInstancePlanArgsForCall(1)
+		Expect([]string{guid1, guid2}).To(ConsistOf("instance-guid-1", "instance-guid-2"))
+		Expect(plan1).To(Equal(newPlan.GUID))
+		Expect(plan2).To(Equal(newPlan.GUID))
+
+		By("reporting to the logger")
+		Expect(fakeLogger.InitialTotalsCallCount()).To(Equal(1))
+		total, migratable := fakeLogger.InitialTotalsArgsForCall(0)
+		Expect(total).To(Equal(3))
+		Expect(migratable).To(Equal(2))
+		Expect(fakeLogger.SkippingInstanceCallCount()).To(Equal(1))
+		Expect(fakeLogger.UpgradeSucceededCallCount()).To(Equal(2))
+		Expect(fakeLogger.FinalTotalsCallCount()).To(Equal(1))
+	})
+
+	It("retries failed migrations", func() {
+		fakeCFClient.UpdateServiceInstancePlanReturnsOnCall(0, fmt.Errorf("boom"))
+		fakeLogger.HasUpgradeSucceededReturns(false)
+
+		err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+			BrokerName:       fakeBrokerName,
+			ParallelUpgrades: 1,
+			Attempts:         2,
+			Action:           config.MigratePlansAction,
+			PlanMappings:     []config.PlanMapping{{ServiceOfferingName: "postgres", FromPlanName: "small", ToPlanName: "medium"}},
+		})
+		Expect(err).To(MatchError("there were failures migrating one or more instances. Review the logs for more information"))
+		Expect(fakeCFClient.UpdateServiceInstancePlanCallCount()).To(Equal(3))
+		Expect(fakeLogger.UpgradeFailedCallCount()).To(Equal(1))
+	})
+
+	DescribeTable("invalid mappings",
+		func(mapping config.PlanMapping, message string) {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+				BrokerName:   fakeBrokerName,
+				Action:       config.MigratePlansAction,
+				PlanMappings: []config.PlanMapping{mapping},
+			})
+			Expect(err).To(MatchError(message))
+			Expect(fakeCFClient.GetServiceInstancesForServicePlansCallCount()).To(BeZero())
+		},
+		Entry("unknown source plan", config.PlanMapping{FromPlanName: "tiny", ToPlanName: "medium"}, `invalid plan mapping "tiny=medium": plan "tiny" not found`),
+		Entry("unknown offering", config.PlanMapping{ServiceOfferingName: "mysql", FromPlanName: "small", ToPlanName: "medium"}, `invalid plan mapping "mysql:small=medium": plan "small" not found for service offering "mysql"`),
+		Entry("ambiguous source plan", config.PlanMapping{FromPlanName: "small", ToPlanName: "medium"}, `invalid plan mapping "small=medium": plan name "small" is used by more than one service offering, specify the service offering`),
+		Entry("target in another offering", config.PlanMapping{ServiceOfferingName: "redis", FromPlanName: "small", ToPlanName: "medium"}, `invalid plan mapping "redis:small=medium": plan "medium" not found for service offering "redis"`),
+		Entry("target not active", config.PlanMapping{ServiceOfferingName: "postgres", FromPlanName: "medium", ToPlanName: "small"}, `invalid plan mapping "postgres:medium=small": plan "small" of service offering "postgres" is not active`),
+	)
+})
@@ -1,14 +1,18 @@
 package upgrader_test
 
 import (
+	"encoding/json"
+
 	"upgrade-all-services-cli-plugin/internal/ccapi"
 	"upgrade-all-services-cli-plugin/internal/config"
 	"upgrade-all-services-cli-plugin/internal/upgrader"
 	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
 
 	"github.com/hashicorp/go-version"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 )
 
 var _ = Describe("--min-version-required", func() {
@@ -114,13 +118,190 @@ var _ = Describe("--min-version-required", func() {
 			}, nil)
 		})
 
-		It("returns an error", func() {
-			err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
-				BrokerName: fakeBrokerName,
-				Action:     config.MinVersionCheckAction,
-				MinVersion: version.Must(version.NewVersion("1.2.3")),
+		It("lists it with an unknown version, and succeeds", func() {
+			output := captureStdout(func() {
+				err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName: fakeBrokerName,
+					Action:     config.MinVersionCheckAction,
+					MinVersion: version.Must(version.NewVersion("1.2.3")),
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			Expect(output).To(ContainSubstring(`No instances found with version lower than "1.2.3"`))
+			Expect(output).To(ContainSubstring("Number of service instances with an unknown version: 1"))
+			Expect(output).To(ContainSubstring(fakeInstanceGUID))
+		})
+
+		It("returns an error when unknown versions count as violations", func() {
+			captureStdout(func() {
+				err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName:           fakeBrokerName,
+					Action:               config.MinVersionCheckAction,
+					MinVersion:           version.Must(version.NewVersion("1.2.3")),
+					FailOnUnknownVersion: true,
+				})
+				Expect(err).To(MatchError("found 1 service instances with an unknown version"))
+				Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+			})
+		})
+
+		It("lists it separately in the JSON output", func() {
+			output := captureStdout(func() {
+				err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName: fakeBrokerName,
+					Action:     config.MinVersionCheckAction,
+					MinVersion: version.Must(version.NewVersion("1.2.3")),
+					JSONOutput: true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			var data struct {
+				Instances      []map[string]any `json:"instances"`
+				UnknownVersion []map[string]any `json:"unknown_version"`
+			}
+			Expect(json.Unmarshal([]byte(output), &data)).To(Succeed())
+			Expect(data.Instances).To(BeEmpty())
+			Expect(data.UnknownVersion).To(HaveLen(1))
+			Expect(data.UnknownVersion[0]).To(HaveKeyWithValue("guid", fakeInstanceGUID))
+		})
+
+		It("skips it in the JUnit report", func() {
+			junitReport := gbytes.NewBuffer()
+			captureStdout(func() {
+				_ = upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName:  fakeBrokerName,
+					Action:      config.MinVersionCheckAction,
+					MinVersion:  version.Must(version.NewVersion("1.2.3")),
+					JUnitReport: junitReport,
+				})
+			})
+
+			Expect(string(junitReport.Contents())).To(ContainSubstring(`<skipped message="version &#34;malformed&#34; is not a semantic version">`))
+		})
+	})
+
+	When("there are versions below the minimum and unknown versions", func() {
+		BeforeEach(func() {
+			fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+				{GUID: fakePlanGUID, Available: true, MaintenanceInfoVersion: "1.2.3"},
+			}, nil)
+			fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+				{GUID: "old-guid", ServicePlanGUID: fakePlanGUID, MaintenanceInfoVersion: "1.2.2"},
+				{GUID: "legacy-guid", ServicePlanGUID: fakePlanGUID, MaintenanceInfoVersion: ""},
+			}, nil)
+		})
+
+		It("reports both", func() {
+			output := captureStdout(func() {
+				err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName:           fakeBrokerName,
+					Action:               config.MinVersionCheckAction,
+					MinVersion:           version.Must(version.NewVersion("1.2.3")),
+					FailOnUnknownVersion: true,
+				})
+				Expect(err).To(MatchError("found 1 service instances with a version less than the minimum required, and found 1 service instances with an unknown version"))
 			})
-			Expect(err).To(MatchError("incorrect instance version: Malformed version: malformed"))
+
+			Expect(output).To(ContainSubstring(`Number of service instances with a version lower than "1.2.3": 1`))
+			Expect(output).To(ContainSubstring("Number of service instances with an unknown version: 1"))
+		})
+	})
+
+	When("there is a minimum version policy", func() {
+		var policy []versionchecker.Rule
+
+		BeforeEach(func() {
+			fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+				{GUID: fakePlanGUID, Available: true, MaintenanceInfoVersion: "1.6.0"},
+			}, nil)
+			fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+				{
+					GUID:                   "postgres-large-guid",
+					ServicePlanGUID:        fakePlanGUID,
+					ServicePlanName:        "large",
+					ServiceOfferingName:    "postgres",
+					LastOperationType:      "create",
+					LastOperationState:     "succeeded",
+					MaintenanceInfoVersion: "1.5.0",
+				},
+				{
+					GUID:                   "postgres-small-guid",
+					ServicePlanGUID:        fakePlanGUID,
+					ServicePlanName:        "small",
+					ServiceOfferingName:    "postgres",
+					LastOperationType:      "create",
+					LastOperationState:     "succeeded",
+					MaintenanceInfoVersion: "1.5.0",
+				},
+				{
+					GUID:                   "mysql-guid",
+					ServicePlanGUID:        fakePlanGUID,
+					ServicePlanName:        "small",
+					ServiceOfferingName:    "mysql",
+					LastOperationType:      "create",
+					LastOperationState:     "succeeded",
+					MaintenanceInfoVersion: "0.9.0",
+				},
+			}, nil)
+
+			policy = nil
+			for _, entry := range []string{"postgres:large=>= 1.6", "postgres=1.2.3"} {
+				r, err := versionchecker.ParseRule(entry)
+				Expect(err).NotTo(HaveOccurred())
+				policy = append(policy, r)
+			}
+		})
+
+		It("reports the rule that each service instance violates", func() {
+			output := captureStdout(func() {
+				err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName:       fakeBrokerName,
+					Action:           config.MinVersionCheckAction,
+					MinVersionPolicy: policy,
+				})
+				Expect(err).To(MatchError("found 1 service instances that violate the minimum version policy"))
+				Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+			})
+
+			Expect(output).To(ContainSubstring("Number of service instances that violate the minimum version policy: 1"))
+			Expect(output).To(ContainSubstring(`Service Instance GUID: "postgres-large-guid"`))
+			Expect(output).To(ContainSubstring(`Minimum Version Rule: "postgres:large >= 1.6"`))
+			Expect(output).NotTo(ContainSubstring("postgres-small-guid"))
+			Expect(output).NotTo(ContainSubstring("mysql-guid"))
+		})
+
+		It("includes the rule in the JSON output", func() {
+			output := captureStdout(func() {
+				err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName:       fakeBrokerName,
+					Action:           config.MinVersionCheckAction,
+					MinVersionPolicy: policy,
+					JSONOutput:       true,
+				})
+				Expect(err).To(HaveOccurred())
+			})
+
+			var instances []map[string]any
+			Expect(json.Unmarshal([]byte(output), &instances)).To(Succeed())
+			Expect(instances).To(HaveLen(1))
+			Expect(instances[0]).To(HaveKeyWithValue("guid", "postgres-large-guid"))
+			Expect(instances[0]).To(HaveKeyWithValue("rule", "postgres:large >= 1.6"))
+		})
+
+		It("names the rule in the JUnit report", func() {
+			junitReport := gbytes.NewBuffer()
+			captureStdout(func() {
+				_ = upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+					BrokerName:       fakeBrokerName,
+					Action:           config.MinVersionCheckAction,
+					MinVersionPolicy: policy,
+					JUnitReport:      junitReport,
+				})
+			})
+
+			Expect(string(junitReport.Contents())).To(ContainSubstring(`version &#34;1.5.0&#34; does not satisfy the minimum version rule &#34;postgres:large &gt;= 1.6&#34;`))
 		})
 	})
 })
@@ -0,0 +1,176 @@
+package upgrader
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/firstseen"
+	"upgrade-all-services-cli-plugin/internal/junit"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/templates"
+)
+
+// pendingUpgrade is how long a service instance has had an upgrade available
+type pendingUpgrade struct {
+	since time.Time
+	age   time.Duration
+}
+
+// pendingAgeCheck fails for service instances that have had an upgrade available for longer than the maximum age.
+// The upgrade has been pending since the plan was last updated, or since the service instance was first seen with
+// an upgrade available in the pending since file, whichever is earlier. When there is a pending since file, it is
+// updated each time the check runs.
+type pendingAgeCheck struct {
+	maxAge           time.Duration
+	pendingSinceFile string
+	now              time.Time
+}
+
+func newPendingAgeCheck(cfg UpgradeConfig) pendingAgeCheck {
+	return pendingAgeCheck{
+		maxAge:           cfg.MaxPendingAge,
+		pendingSinceFile: cfg.PendingSinceFile,
+		now:              time.Now(),
+	}
+}
+
+func (pendingAgeCheck) Name() string {
+	return "max-pending-age"
+}
+
+func (c pendingAgeCheck) Run(instances groupedServiceInstances) (checkResult, error) {
+	pendingSince, err := upgradesPendingSince(instances.upgradeable, c.pendingSinceFile, c.now)
+	if err != nil {
+		return checkResult{}, err
+	}
+
+	pending := pendingUpgrades{maxAge: c.maxAge, upgrades: make(map[string]pendingUpgrade), overdue: make(map[string]bool)}
+	var overdue []ccapi.ServiceInstance
+	for _, instance := range instances.upgradeable {
+		since, ok := pendingSince[instance.GUID]
+		if !ok {
+			continue
+		}
+
+		pending.upgrades[instance.GUID] = pendingUpgrade{since: since, age: c.now.Sub(since)}
+		if pending.upgrades[instance.GUID].age > c.maxAge {
+			overdue = append(overdue, instance)
+			pending.overdue[instance.GUID] = true
+		}
+	}
+
+	slices.SortStableFunc(overdue, func(a, b ccapi.ServiceInstance) int {
+		return cmp.Or(pending.upgrades[a.GUID].since.Compare(pending.upgrades[b.GUID].since), cmp.Compare(a.Name, b.Name))
+	})
+
+	r := checkResult{
+		name:         c.Name(),
+		violations:   overdue,
+		reason:       fmt.Sprintf("found %d service instances with an upgrade pending for longer than %s", len(overdue), formatMaxAge(c.maxAge)),
+		sections:     []summary.Set{upgradeOverdueSet(overdue)},
+		headings:     map[string]string{statusUpgradeOverdue: "service instances with an upgrade pending for longer than " + formatMaxAge(c.maxAge)},
+		listOutput:   true,
+		details:      pending,
+		pendingSince: pendingSince,
+	}
+	if len(overdue) == 0 {
+		r.noneFound = "No instances found with an upgrade pending for longer than " + formatMaxAge(c.maxAge)
+	}
+	return r, nil
+}
+
+// upgradesPendingSince is when the upgrade of each service instance became available, keyed by service instance
+// GUID. It is when the plan was last updated, or when the service instance was first seen with an upgrade available
+// in the pending since file, whichever is earlier. When there is a pending since file, it is updated with the service
+// instances that have an upgrade available. Service instances without either time are left out.
+func upgradesPendingSince(upgradeable []ccapi.ServiceInstance, pendingSinceFile string, now time.Time) (map[string]time.Time, error) {
+	var firstSeen firstseen.Record
+	if pendingSinceFile != "" {
+		record, err := firstseen.Load(pendingSinceFile)
+		if err != nil {
+			return nil, err
+		}
+
+		firstSeen = record.Observe(slicex.Map(upgradeable, func(instance ccapi.ServiceInstance) string { return instance.GUID }), now)
+		if err := firstSeen.Save(pendingSinceFile); err != nil {
+			return nil, err
+		}
+	}
+
+	pendingSince := make(map[string]time.Time, len(upgradeable))
+	for _, instance := range upgradeable {
+		if since := earliest(instance.ServicePlanUpdatedAt, firstSeen[instance.GUID]); !since.IsZero() {
+			pendingSince[instance.GUID] = since
+		}
+	}
+	return pendingSince, nil
+}
+
+func earliest(a, b time.Time) time.Time {
+	switch {
+	case a.IsZero():
+		return b
+	case b.IsZero(), a.Before(b):
+		return a
+	default:
+		return b
+	}
+}
+
+const day = 24 * time.Hour
+
+// formatAge shows an age of a day or more in whole days, because pending upgrades are usually measured in days
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 2*day:
+		return fmt.Sprintf("%d days", d/day)
+	case d >= day:
+		return "1 day"
+	default:
+		return d.Round(time.Minute).String()
+	}
+}
+
+// formatMaxAge shows the maximum age as it was specified, either in days or as a duration
+func formatMaxAge(d time.Duration) string {
+	if d%day != 0 {
+		return d.String()
+	}
+	return formatAge(d)
+}
+
+// pendingUpgrades are the upgrades that have been pending for the service instances, keyed by service instance GUID,
+// along with the service instances whose upgrade is overdue
+type pendingUpgrades struct {
+	maxAge   time.Duration
+	upgrades map[string]pendingUpgrade
+	overdue  map[string]bool
+}
+
+func (p pendingUpgrades) text(instance ccapi.ServiceInstance) {
+	if u, ok := p.upgrades[instance.GUID]; ok {
+		fmt.Printf("  Upgrade Pending Since: %q\n", u.since.UTC().Format(time.RFC3339))
+		fmt.Printf("  Upgrade Pending For: %q\n", formatAge(u.age))
+	}
+}
+
+func (p pendingUpgrades) json(i jsonOutputServiceInstance, instance ccapi.ServiceInstance) jsonOutputServiceInstance {
+	if u, ok := p.upgrades[instance.GUID]; ok {
+		i.PendingSince = u.since.UTC().Format(time.RFC3339)
+		i.PendingDays = int(u.age / day)
+	}
+	return i
+}
+
+func (pendingUpgrades) template(*templates.Data) {}
+
+func (p pendingUpgrades) testCase(instance ccapi.ServiceInstance) junit.TestCase {
+	if !p.overdue[instance.GUID] {
+		return junit.InstanceCase(instance)
+	}
+	message := fmt.Sprintf("upgrade to version %q has been pending for %s, longer than %s", instance.ServicePlanMaintenanceInfoVersion, formatAge(p.upgrades[instance.GUID].age), formatMaxAge(p.maxAge))
+	return junit.InstanceCase(instance).WithFailure("UpgradeOverdue", message)
+}
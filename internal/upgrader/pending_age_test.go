@@ -0,0 +1,145 @@
+package upgrader_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/firstseen"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("--max-pending-age", func() {
+	const (
+		fakeBrokerName = "fake-broker-name"
+		day            = 24 * time.Hour
+	)
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+			{GUID: "fake-plan-guid", Available: true, MaintenanceInfoVersion: "1.6.0"},
+		}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "current-guid", Name: "current", MaintenanceInfoVersion: "1.6.0", ServicePlanUpdatedAt: time.Now().Add(-90 * day)},
+			{GUID: "recent-guid", Name: "recent", MaintenanceInfoVersion: "1.5.0", UpgradeAvailable: true, ServicePlanUpdatedAt: time.Now().Add(-10 * day)},
+			{GUID: "old-guid", Name: "old", MaintenanceInfoVersion: "1.4.0", UpgradeAvailable: true, ServicePlanUpdatedAt: time.Now().Add(-45 * day), OrganizationName: "fake-org", SpaceName: "fake-space"},
+			{GUID: "oldest-guid", Name: "oldest", MaintenanceInfoVersion: "1.3.0", UpgradeAvailable: true, ServicePlanUpdatedAt: time.Now().Add(-90 * day)},
+			{GUID: "failed-guid", Name: "failed", MaintenanceInfoVersion: "1.3.0", UpgradeAvailable: true, ServicePlanUpdatedAt: time.Now().Add(-90 * day), LastOperationType: "create", LastOperationState: "failed"},
+		}, nil)
+
+		cfg = upgrader.UpgradeConfig{
+			BrokerName:    fakeBrokerName,
+			Action:        config.PendingAgeAction,
+			MaxPendingAge: 30 * day,
+		}
+	})
+
+	It("lists the service instances with an upgrade pending for too long, oldest first", func() {
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+			Expect(err).To(MatchError("found 2 service instances with an upgrade pending for longer than 30 days"))
+			Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(ContainSubstring("Number of service instances with an upgrade pending for longer than 30 days: 2"))
+		Expect(output).To(MatchRegexp(`(?s)"oldest".*Upgrade Pending For: "90 days".*"old".*Space Name: "fake-space".*Organization Name: "fake-org".*Upgrade Pending For: "45 days"`))
+		Expect(output).NotTo(ContainSubstring("recent-guid"))
+		Expect(output).NotTo(ContainSubstring("failed-guid"))
+	})
+
+	It("succeeds when no upgrade has been pending for too long", func() {
+		cfg.MaxPendingAge = 100 * day
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(ContainSubstring("No instances found with an upgrade pending for longer than 100 days"))
+	})
+
+	It("lists the service instances as JSON", func() {
+		cfg.JSONOutput = true
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		var instances []map[string]any
+		Expect(json.Unmarshal([]byte(output), &instances)).To(Succeed())
+		Expect(instances).To(HaveLen(2))
+		Expect(instances[0]).To(HaveKeyWithValue("guid", "oldest-guid"))
+		Expect(instances[0]).To(HaveKeyWithValue("pending_days", BeNumerically("==", 90)))
+		Expect(instances[0]).To(HaveKey("pending_since"))
+	})
+
+	It("writes the status column", func() {
+		cfg.Output = config.CSVOutput
+		cfg.Columns = []config.Column{config.StatusColumn, config.GUIDColumn}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(output).To(Equal("status,guid\nupgrade_overdue,oldest-guid\nupgrade_overdue,old-guid\n"))
+	})
+
+	It("fails the test cases in the JUnit report", func() {
+		junitReport := gbytes.NewBuffer()
+		cfg.JUnitReport = junitReport
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`name="max-pending-age"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`type="UpgradeOverdue"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`has been pending for 45 days, longer than 30 days`))
+	})
+
+	Describe("pending since file", func() {
+		var path string
+
+		BeforeEach(func() {
+			path = filepath.Join(GinkgoT().TempDir(), "pending-since.json")
+			cfg.PendingSinceFile = path
+		})
+
+		It("uses the time a service instance was first seen when it is earlier than the plan update", func() {
+			Expect(firstseen.Record{"recent-guid": time.Now().Add(-60 * day), "upgraded-guid": time.Now().Add(-60 * day)}.Save(path)).To(Succeed())
+
+			captureStdout(func() {
+				Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError("found 3 service instances with an upgrade pending for longer than 30 days"))
+			})
+
+			record, err := firstseen.Load(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(record).To(HaveKey("recent-guid"))
+			Expect(record).To(HaveKey("old-guid"))
+			Expect(record).NotTo(HaveKey("upgraded-guid"))
+			Expect(record).NotTo(HaveKey("current-guid"))
+		})
+
+		It("creates the file", func() {
+			captureStdout(func() {
+				_ = upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+			})
+
+			Expect(path).To(BeAnExistingFile())
+			data, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring("oldest-guid"))
+		})
+	})
+})
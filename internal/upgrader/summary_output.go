@@ -0,0 +1,95 @@
+package upgrader
+
+import (
+	"os"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/summary"
+)
+
+// The summary sets use the same keys as the JSON output and the status column
+func planDeactivatedSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusPlanDeactivated, Title: "service instances associated with deactivated plans", Instances: instances}
+}
+
+func upgradePendingSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusUpgradePending, Title: "service instances with an upgrade available", Instances: instances}
+}
+
+func createFailedSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusCreateFailed, Title: "service instances which failed to create", Instances: instances}
+}
+
+func upgradeSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusUpgrade, Title: "service instances that would be upgraded", Instances: instances}
+}
+
+func skipSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusSkip, Title: "service instances that would be skipped", Instances: instances}
+}
+
+func belowMinVersionSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusBelowMinVersion, Title: "service instances with a version lower than the minimum required", Instances: instances}
+}
+
+func outsideVersionConstraintSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusOutsideVersionConstraint, Title: "service instances outside the version constraint", Instances: instances}
+}
+
+func unknownVersionSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusUnknownVersion, Title: "service instances with an unknown version", Instances: instances}
+}
+
+func versionAheadSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusVersionAhead, Title: "service instances with a version ahead of their plan", Instances: instances}
+}
+
+func upgradeNotFlaggedSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusUpgradeNotFlagged, Title: "service instances behind their plan without an upgrade available", Instances: instances}
+}
+
+func missingMaintenanceInfoSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusMissingMaintenanceInfo, Title: "service instances missing maintenance_info", Instances: instances}
+}
+
+// operationFailedSet is keyed by the type of operation, such as create_failed
+func operationFailedSet(operationType string, instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: operationType + "_failed", Title: "service instances which failed to " + operationType, Instances: instances}
+}
+
+func upgradeOverdueSet(instances []ccapi.ServiceInstance) summary.Set {
+	return summary.Set{Key: statusUpgradeOverdue, Title: "service instances with an upgrade pending for longer than the maximum age", Instances: instances}
+}
+
+// outputSummaryText writes the summaries after the text output, when they were requested
+func outputSummaryText(enabled bool, sets ...summary.Set) error {
+	if !enabled {
+		return nil
+	}
+
+	return summary.WriteText(os.Stdout, sets...)
+}
+
+// withSummaryJSON is for the JSON outputs that are a list of service instances. To make room for the summaries,
+// the list is moved into an object when they were requested.
+func withSummaryJSON(instances []jsonOutputServiceInstance, summaries map[string]summary.Summary) any {
+	if summaries == nil {
+		return instances
+	}
+
+	return struct {
+		Instances []jsonOutputServiceInstance `json:"instances"`
+		Summary   map[string]summary.Summary  `json:"summary"`
+	}{
+		Instances: instances,
+		Summary:   summaries,
+	}
+}
+
+// summaryJSON returns the summaries to add to the JSON output, or nil so that they are omitted
+func summaryJSON(enabled bool, sets ...summary.Set) map[string]summary.Summary {
+	if !enabled {
+		return nil
+	}
+
+	return summary.ByKey(sets...)
+}
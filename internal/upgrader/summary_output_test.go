@@ -0,0 +1,120 @@
+package upgrader_test
+
+import (
+	"encoding/json"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("summary output", func() {
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		cfg = upgrader.UpgradeConfig{BrokerName: "fake-broker-name", Summary: true}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+			{GUID: "active-plan-guid", Available: true, MaintenanceInfoVersion: "1.2.3"},
+			{GUID: "deactivated-plan-guid", Available: false, MaintenanceInfoVersion: "1.2.3"},
+		}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "outdated-guid", OrganizationName: "org-x", UpgradeAvailable: true, ServicePlanGUID: "active-plan-guid", MaintenanceInfoVersion: "1.2.2"},
+			{GUID: "create-failed-guid", OrganizationName: "org-y", UpgradeAvailable: true, ServicePlanGUID: "active-plan-guid", LastOperationType: "create", LastOperationState: "failed", MaintenanceInfoVersion: "1.2.2"},
+			{GUID: "deactivated-guid", OrganizationName: "org-x", ServicePlanGUID: "deactivated-plan-guid", MaintenanceInfoVersion: "1.2.3", ServicePlanDeactivated: true},
+		}, nil)
+	})
+
+	It("adds summaries to the text output of the up to date check", func() {
+		cfg.Action = config.CheckUpToDateAction
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(ContainSubstring("Summary of service instances associated with deactivated plans: 1\n  By org:\n    org-x  1  100.0%\n"))
+		Expect(output).To(ContainSubstring("Summary of service instances with an upgrade available: 1\n"))
+		Expect(output).To(ContainSubstring("Summary of service instances which failed to create: 1\n"))
+	})
+
+	It("adds summaries to the text output of the minimum version check", func() {
+		cfg.Action = config.MinVersionCheckAction
+		cfg.MinVersion = version.Must(version.NewVersion("1.2.3"))
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(output).To(ContainSubstring("Summary of service instances with a version lower than the minimum required: 2\n"))
+	})
+
+	It("adds summaries to the text output of a dry run", func() {
+		cfg.Action = config.DryRunAction
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(ContainSubstring("Summary of service instances that would be upgraded: 1\n"))
+		Expect(output).To(ContainSubstring("Summary of service instances that would be skipped: 1\n"))
+	})
+
+	It("adds summaries to the JSON output of a dry run", func() {
+		cfg.Action = config.DryRunAction
+		cfg.JSONOutput = true
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		var receiver struct {
+			Upgrade []any `json:"upgrade"`
+			Summary map[string]struct {
+				Total int `json:"total"`
+			} `json:"summary"`
+		}
+		Expect(json.Unmarshal([]byte(output), &receiver)).To(Succeed())
+		Expect(receiver.Upgrade).To(HaveLen(1))
+		Expect(receiver.Summary).To(HaveKeyWithValue("upgrade", HaveField("Total", 1)))
+		Expect(receiver.Summary).To(HaveKeyWithValue("skip", HaveField("Total", 1)))
+	})
+
+	It("moves the list of instances into an object for the JSON output of the deactivated plans check", func() {
+		cfg.Action = config.CheckDeactivatedPlansAction
+		cfg.JSONOutput = true
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		var receiver struct {
+			Instances []struct {
+				GUID string `json:"guid"`
+			} `json:"instances"`
+			Summary map[string]struct {
+				Total int `json:"total"`
+			} `json:"summary"`
+		}
+		Expect(json.Unmarshal([]byte(output), &receiver)).To(Succeed())
+		Expect(receiver.Instances).To(HaveExactElements(HaveField("GUID", "deactivated-guid")))
+		Expect(receiver.Summary).To(HaveKeyWithValue("plan_deactivated", HaveField("Total", 1)))
+	})
+
+	It("does not change the JSON output when summaries are not requested", func() {
+		cfg.Action = config.CheckDeactivatedPlansAction
+		cfg.JSONOutput = true
+		cfg.Summary = false
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		var receiver []any
+		Expect(json.Unmarshal([]byte(output), &receiver)).To(Succeed())
+		Expect(receiver).To(HaveLen(1))
+	})
+})
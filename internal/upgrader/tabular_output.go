@@ -0,0 +1,89 @@
+package upgrader
+
+import (
+	"fmt"
+	"os"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/tabular"
+)
+
+// The status column uses the same names as the keys of the JSON output
+const (
+	statusPlanDeactivated = "plan_deactivated"
+	statusUpgradePending  = "upgrade_pending"
+	statusCreateFailed    = "create_failed"
+	statusUpgrade         = "upgrade"
+	statusSkip            = "skip"
+	statusBelowMinVersion = "below_min_version"
+	statusUpToDate        = "up_to_date"
+
+	statusOutsideVersionConstraint = "outside_version_constraint"
+	statusUnknownVersion           = "unknown_version"
+	statusUpgradeOverdue           = "upgrade_overdue"
+	statusVersionAhead             = "version_ahead"
+	statusUpgradeNotFlagged        = "upgrade_not_flagged"
+	statusMissingMaintenanceInfo   = "missing_maintenance_info"
+)
+
+type tabularRow struct {
+	status   string
+	instance ccapi.ServiceInstance
+	details  instanceDetails
+}
+
+// columnDetails is implemented by the instanceDetails of checks that fill in columns of their own
+type columnDetails interface {
+	// column returns the value of the column for the service instance, and whether the check sets it
+	column(column config.Column, instance ccapi.ServiceInstance) (string, bool)
+}
+
+func tabularRows(status string, instances []ccapi.ServiceInstance) []tabularRow {
+	return slicex.Map(instances, func(instance ccapi.ServiceInstance) tabularRow {
+		return tabularRow{status: status, instance: instance}
+	})
+}
+
+// sectionRows has the rows of each section of a check result, with the key of the section as the status
+func sectionRows(result checkResult) [][]tabularRow {
+	return slicex.Map(result.sections, func(s summary.Set) []tabularRow {
+		return slicex.Map(s.Instances, func(instance ccapi.ServiceInstance) tabularRow {
+			return tabularRow{status: s.Key, instance: instance, details: result.details}
+		})
+	})
+}
+
+func outputTabular(format config.OutputFormat, columns []config.Column, rows ...[]tabularRow) error {
+	table := tabular.Table{Header: slicex.Map(columns, func(c config.Column) string { return string(c) })}
+	for _, group := range rows {
+		for _, row := range group {
+			table.Rows = append(table.Rows, slicex.Map(columns, row.value))
+		}
+	}
+
+	var err error
+	switch format {
+	case config.CSVOutput:
+		err = tabular.WriteCSV(os.Stdout, table)
+	default:
+		err = tabular.WriteTable(os.Stdout, table, tabular.TerminalWidth())
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s output: %w", format, err)
+	}
+	return nil
+}
+
+func (r tabularRow) value(column config.Column) string {
+	if column == config.StatusColumn {
+		return r.status
+	}
+	if d, ok := r.details.(columnDetails); ok {
+		if value, ok := d.column(column, r.instance); ok {
+			return value
+		}
+	}
+	return column.Value(r.instance)
+}
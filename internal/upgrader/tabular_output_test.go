@@ -0,0 +1,134 @@
+package upgrader_test
+
+import (
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("table and CSV output", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		columns      []config.Column
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+		columns = []config.Column{config.StatusColumn, config.NameColumn, config.GUIDColumn, config.VersionColumn, config.PlanVersionColumn}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+			{GUID: "active-plan-guid", Available: true, MaintenanceInfoVersion: "1.2.3"},
+			{GUID: "deactivated-plan-guid", Available: false, MaintenanceInfoVersion: "1.2.3"},
+		}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{
+				Name:                              "outdated",
+				GUID:                              "outdated-guid",
+				UpgradeAvailable:                  true,
+				ServicePlanGUID:                   "active-plan-guid",
+				LastOperationType:                 "update",
+				LastOperationState:                "succeeded",
+				MaintenanceInfoVersion:            "1.2.2",
+				ServicePlanMaintenanceInfoVersion: "1.2.3",
+			},
+			{
+				Name:                              "create-failed",
+				GUID:                              "create-failed-guid",
+				UpgradeAvailable:                  true,
+				ServicePlanGUID:                   "active-plan-guid",
+				LastOperationType:                 "create",
+				LastOperationState:                "failed",
+				MaintenanceInfoVersion:            "1.2.2",
+				ServicePlanMaintenanceInfoVersion: "1.2.3",
+			},
+			{
+				Name:                              "deactivated",
+				GUID:                              "deactivated-guid",
+				ServicePlanGUID:                   "deactivated-plan-guid",
+				LastOperationType:                 "create",
+				LastOperationState:                "succeeded",
+				MaintenanceInfoVersion:            "1.2.3",
+				ServicePlanMaintenanceInfoVersion: "1.2.3",
+				ServicePlanDeactivated:            true,
+			},
+		}, nil)
+	})
+
+	It("writes a CSV row for each instance that is not up to date", func() {
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+				BrokerName: fakeBrokerName,
+				Action:     config.CheckUpToDateAction,
+				Output:     config.CSVOutput,
+				Columns:    columns,
+			})
+			Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(Equal(
+			"status,name,guid,version,plan_version\n" +
+				"plan_deactivated,deactivated,deactivated-guid,1.2.3,1.2.3\n" +
+				"upgrade_pending,outdated,outdated-guid,1.2.2,1.2.3\n" +
+				"create_failed,create-failed,create-failed-guid,1.2.2,1.2.3\n",
+		))
+	})
+
+	It("writes a CSV row for each instance with a deactivated plan", func() {
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+				BrokerName: fakeBrokerName,
+				Action:     config.CheckDeactivatedPlansAction,
+				Output:     config.CSVOutput,
+				Columns:    []config.Column{config.GUIDColumn},
+			})
+			Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(Equal("guid\ndeactivated-guid\n"))
+	})
+
+	It("writes a CSV row for each instance below the minimum version", func() {
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+				BrokerName: fakeBrokerName,
+				Action:     config.MinVersionCheckAction,
+				MinVersion: version.Must(version.NewVersion("1.2.3")),
+				Output:     config.CSVOutput,
+				Columns:    []config.Column{config.StatusColumn, config.GUIDColumn},
+			})
+			Expect(err).To(MatchError("found 2 service instances with a version less than the minimum required"))
+		})
+
+		Expect(output).To(Equal("status,guid\nbelow_min_version,outdated-guid\nbelow_min_version,create-failed-guid\n"))
+	})
+
+	It("writes a table row for each instance in a dry run", func() {
+		GinkgoT().Setenv("COLUMNS", "")
+
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, upgrader.UpgradeConfig{
+				BrokerName: fakeBrokerName,
+				Action:     config.DryRunAction,
+				Output:     config.TableOutput,
+				Columns:    columns,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Expect(output).To(Equal(
+			"status   name           guid                version  plan_version\n" +
+				"upgrade  outdated       outdated-guid       1.2.2    1.2.3\n" +
+				"skip     create-failed  create-failed-guid  1.2.2    1.2.3\n",
+		))
+		Expect(fakeLogger.InitialTotalsCallCount()).To(BeZero())
+	})
+})
@@ -0,0 +1,24 @@
+package upgrader
+
+import (
+	"os"
+	"text/template"
+	"upgrade-all-services-cli-plugin/internal/templates"
+)
+
+// newTemplateData groups the service instances for a template. The instances that fail a version check, and
+// the requirement that they fail, are only known for the version checks, which add them.
+func newTemplateData(brokerName string, instances groupedServiceInstances) templates.Data {
+	return templates.Data{
+		BrokerName:      brokerName,
+		All:             instances.all,
+		Upgradeable:     instances.upgradeable,
+		DeactivatedPlan: instances.deactivatedPlan,
+		CreateFailed:    instances.createFailed,
+	}
+}
+
+// outputTemplate renders the data through a user-supplied template
+func outputTemplate(t *template.Template, data templates.Data) error {
+	return templates.Render(os.Stdout, t, data)
+}
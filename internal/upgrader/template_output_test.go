@@ -0,0 +1,104 @@
+package upgrader_test
+
+import (
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/templates"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("template output", func() {
+	const text = `{{.BrokerName}} {{.MinVersion}}: all={{.Totals.All}} upgradeable={{.Totals.Upgradeable}} deactivated={{.Totals.DeactivatedPlan}} create-failed={{.Totals.CreateFailed}} below-min={{.Totals.BelowMinVersion}}`
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+			{GUID: "active-plan-guid", Available: true, MaintenanceInfoVersion: "1.2.3"},
+			{GUID: "deactivated-plan-guid", Available: false, MaintenanceInfoVersion: "1.2.3"},
+		}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "outdated-guid", UpgradeAvailable: true, ServicePlanGUID: "active-plan-guid", MaintenanceInfoVersion: "1.2.2"},
+			{GUID: "create-failed-guid", UpgradeAvailable: true, ServicePlanGUID: "active-plan-guid", LastOperationType: "create", LastOperationState: "failed", MaintenanceInfoVersion: "1.2.2"},
+			{GUID: "deactivated-guid", ServicePlanGUID: "deactivated-plan-guid", MaintenanceInfoVersion: "1.2.3", ServicePlanDeactivated: true},
+		}, nil)
+
+		t, err := templates.Parse("test", text)
+		Expect(err).NotTo(HaveOccurred())
+		cfg = upgrader.UpgradeConfig{BrokerName: "fake-broker-name", Template: t}
+	})
+
+	It("renders a dry run", func() {
+		cfg.Action = config.DryRunAction
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(Equal("fake-broker-name : all=3 upgradeable=1 deactivated=1 create-failed=1 below-min=0"))
+		Expect(fakeLogger.InitialTotalsCallCount()).To(BeZero())
+	})
+
+	It("renders the up to date check", func() {
+		cfg.Action = config.CheckUpToDateAction
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(Equal("fake-broker-name : all=3 upgradeable=1 deactivated=1 create-failed=1 below-min=0"))
+	})
+
+	It("renders the deactivated plans check", func() {
+		cfg.Action = config.CheckDeactivatedPlansAction
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(Equal("fake-broker-name : all=3 upgradeable=1 deactivated=1 create-failed=1 below-min=0"))
+	})
+
+	It("renders the minimum version check", func() {
+		cfg.Action = config.MinVersionCheckAction
+		cfg.MinVersion = version.Must(version.NewVersion("1.2.3"))
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError("found 2 service instances with a version less than the minimum required"))
+		})
+
+		Expect(output).To(Equal("fake-broker-name 1.2.3: all=3 upgradeable=1 deactivated=1 create-failed=1 below-min=2"))
+	})
+
+	It("renders the version constraint check", func() {
+		t, err := templates.Parse("test", `{{.VersionConstraint}}: {{.Totals.OutsideVersionConstraint}} {{pluck "guid" .OutsideVersionConstraint | join ","}}`)
+		Expect(err).NotTo(HaveOccurred())
+		cfg.Template = t
+		cfg.Action = config.VersionConstraintAction
+		cfg.VersionConstraint = version.MustConstraints(version.NewConstraint("!= 1.2.3"))
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError("found 1 service instances outside the version constraint"))
+		})
+
+		Expect(output).To(Equal("!= 1.2.3: 1 deactivated-guid"))
+	})
+
+	It("returns an error when the template fails", func() {
+		t, err := templates.Parse("test", `{{pluck "colour" .All}}`)
+		Expect(err).NotTo(HaveOccurred())
+		cfg.Template = t
+		cfg.Action = config.DryRunAction
+
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError(ContainSubstring("error rendering template")))
+		})
+	})
+})
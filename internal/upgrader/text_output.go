@@ -3,22 +3,56 @@ package upgrader
 import (
 	"fmt"
 	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/summary"
 )
 
-func logServiceInstances(instances []ccapi.ServiceInstance) {
-	for _, instance := range instances {
-		fmt.Printf("  Service Instance Name: %q\n", instance.Name)
-		fmt.Printf("  Service Instance GUID: %q\n", instance.GUID)
-		fmt.Printf("  Service Instance Version: %q\n", instance.MaintenanceInfoVersion)
-		fmt.Printf("  Service Plan Name: %q\n", instance.ServicePlanName)
-		fmt.Printf("  Service Plan GUID: %q\n", instance.ServicePlanGUID)
-		fmt.Printf("  Service Plan Version: %q\n", instance.ServicePlanMaintenanceInfoVersion)
-		fmt.Printf("  Service Offering Name: %q\n", instance.ServiceOfferingName)
-		fmt.Printf("  Service Offering GUID: %q\n", instance.ServiceOfferingGUID)
-		fmt.Printf("  Space Name: %q\n", instance.SpaceName)
-		fmt.Printf("  Space GUID: %q\n", instance.SpaceGUID)
-		fmt.Printf("  Organization Name: %q\n", instance.OrganizationName)
-		fmt.Printf("  Organization GUID: %q\n", instance.OrganizationGUID)
+func logServiceInstance(instance ccapi.ServiceInstance) {
+	fmt.Printf("  Service Instance Name: %q\n", instance.Name)
+	fmt.Printf("  Service Instance GUID: %q\n", instance.GUID)
+	fmt.Printf("  Service Instance Version: %q\n", instance.MaintenanceInfoVersion)
+	fmt.Printf("  Service Plan Name: %q\n", instance.ServicePlanName)
+	fmt.Printf("  Service Plan GUID: %q\n", instance.ServicePlanGUID)
+	fmt.Printf("  Service Plan Version: %q\n", instance.ServicePlanMaintenanceInfoVersion)
+	fmt.Printf("  Service Offering Name: %q\n", instance.ServiceOfferingName)
+	fmt.Printf("  Service Offering GUID: %q\n", instance.ServiceOfferingGUID)
+	fmt.Printf("  Space Name: %q\n", instance.SpaceName)
+	fmt.Printf("  Space GUID: %q\n", instance.SpaceGUID)
+	fmt.Printf("  Organization Name: %q\n", instance.OrganizationName)
+	fmt.Printf("  Organization GUID: %q\n", instance.OrganizationGUID)
+	if p, ok := instance.Provenance(); ok {
+		fmt.Printf("  Last Upgraded By: %q\n", p.UpgradedBy)
+		fmt.Printf("  Last Upgraded At: %q\n", p.UpgradedAt)
+		fmt.Printf("  Last Upgraded From Version: %q\n", p.PreviousVersion)
+		fmt.Printf("  Last Upgrade Run ID: %q\n", p.RunID)
+	}
+}
+
+func logDiscovery(brokerName string, totalServiceInstances int) {
+	fmt.Printf("Discovering service instances for broker: %s\n", brokerName)
+	fmt.Printf("Total number of service instances: %d\n", totalServiceInstances)
+}
+
+// outputCheckText lists the service instances in each section of the check result. When the check found
+// nothing, it says so, and only the sections with service instances are listed.
+func outputCheckText(result checkResult, totalServiceInstances int, brokerName string) {
+	logDiscovery(brokerName, totalServiceInstances)
+	if result.noneFound != "" {
+		fmt.Println(result.noneFound)
+	}
+
+	for _, section := range result.sections {
+		if result.noneFound == "" || len(section.Instances) > 0 {
+			logCheckSection(result, section)
+		}
+	}
+}
+
+func logCheckSection(result checkResult, section summary.Set) {
+	fmt.Printf("Number of %s: %d\n", result.heading(section), len(section.Instances))
+	fmt.Println()
+	for _, instance := range section.Instances {
+		logServiceInstance(instance)
+		result.logDetails(instance)
 		fmt.Println()
 	}
 }
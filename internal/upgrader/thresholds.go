@@ -0,0 +1,49 @@
+package upgrader
+
+import (
+	"fmt"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/slicex"
+)
+
+// runCheck runs a check, and then applies the thresholds to decide whether it fails
+func runCheck(check Check, instances groupedServiceInstances, thresholds config.Thresholds) (checkResult, error) {
+	result, err := check.Run(instances)
+	if err != nil {
+		return checkResult{}, err
+	}
+
+	return applyThresholds(result, thresholds, len(instances.all), time.Now()), nil
+}
+
+// applyThresholds decides whether the violations are enough for the check to fail. For the checks of upgrades that
+// are available, service instances whose upgrade became available within the grace period do not count, because
+// there has not yet been time to upgrade them. The grace period does not apply to the other checks. The check then
+// fails when the violations that count are above either of the number and the percentage thresholds that are set,
+// or when neither is set, for any violation that counts. The violations are still listed in the output when the check
+// passes.
+func applyThresholds(result checkResult, thresholds config.Thresholds, total int, now time.Time) checkResult {
+	counted := slicex.Filter(result.violations, func(instance ccapi.ServiceInstance) bool {
+		since, ok := result.pendingSince[instance.GUID]
+		return !ok || now.Sub(since) > thresholds.GracePeriod
+	})
+
+	var percent float64
+	if total > 0 {
+		percent = 100 * float64(len(counted)) / float64(total)
+	}
+
+	switch {
+	case thresholds.MaxInstances == 0 && thresholds.MaxPercent == 0:
+		result.withinThresholds = len(counted) == 0
+	default:
+		result.withinThresholds = (thresholds.MaxInstances == 0 || len(counted) <= thresholds.MaxInstances) &&
+			(thresholds.MaxPercent == 0 || percent <= thresholds.MaxPercent)
+	}
+	if !result.withinThresholds && thresholds != (config.Thresholds{}) {
+		result.reason = fmt.Sprintf("%s: %d of %d service instances (%.1f%%) count towards the thresholds", result.reason, len(counted), total, percent)
+	}
+	return result
+}
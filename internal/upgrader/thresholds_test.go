@@ -0,0 +1,144 @@
+package upgrader_test
+
+import (
+	"path/filepath"
+	"time"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/firstseen"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("thresholds", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+			{GUID: "old-plan-guid", Available: true, MaintenanceInfoVersion: "1.6.0"},
+			{GUID: "new-plan-guid", Available: true, MaintenanceInfoVersion: "1.6.0"},
+		}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "current-guid-1", ServicePlanGUID: "old-plan-guid", MaintenanceInfoVersion: "1.6.0"},
+			{GUID: "current-guid-2", ServicePlanGUID: "old-plan-guid", MaintenanceInfoVersion: "1.6.0"},
+			{GUID: "long-outdated-guid", ServicePlanGUID: "old-plan-guid", MaintenanceInfoVersion: "1.5.0", UpgradeAvailable: true, ServicePlanUpdatedAt: time.Now().Add(-30 * 24 * time.Hour)},
+			{GUID: "recently-outdated-guid", ServicePlanGUID: "new-plan-guid", MaintenanceInfoVersion: "1.5.0", UpgradeAvailable: true, ServicePlanUpdatedAt: time.Now().Add(-2 * 24 * time.Hour)},
+		}, nil)
+
+		cfg = upgrader.UpgradeConfig{
+			BrokerName: fakeBrokerName,
+			Action:     config.CheckUpToDateAction,
+		}
+	})
+
+	It("fails for any violation by default", func() {
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError("discovered service instances associated with deactivated plans or with an upgrade available"))
+		})
+	})
+
+	It("passes when the number of violations is not above the threshold", func() {
+		cfg.Thresholds = config.Thresholds{MaxInstances: 2}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(ContainSubstring(`Service Instance GUID: "long-outdated-guid"`))
+	})
+
+	It("passes when the percentage of violations is not above the threshold", func() {
+		cfg.Thresholds = config.Thresholds{MaxPercent: 50}
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+	})
+
+	It("fails when the number of violations is above the threshold, even though the percentage is not", func() {
+		cfg.Thresholds = config.Thresholds{MaxInstances: 1, MaxPercent: 75}
+		captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+			Expect(err).To(MatchError("discovered service instances associated with deactivated plans or with an upgrade available: 2 of 4 service instances (50.0%) count towards the thresholds"))
+			Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+	})
+
+	It("fails when the percentage of violations is above the threshold, even though the number is not", func() {
+		cfg.Thresholds = config.Thresholds{MaxInstances: 5, MaxPercent: 25}
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError(ContainSubstring("2 of 4 service instances (50.0%) count towards the thresholds")))
+		})
+	})
+
+	It("passes when the violations are within both thresholds", func() {
+		cfg.Thresholds = config.Thresholds{MaxInstances: 2, MaxPercent: 50}
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+	})
+
+	It("does not count service instances whose upgrade became available within the grace period", func() {
+		cfg.Thresholds = config.Thresholds{GracePeriod: 7 * 24 * time.Hour}
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError(ContainSubstring("1 of 4 service instances (25.0%) count towards the thresholds")))
+		})
+
+		cfg.Thresholds = config.Thresholds{MaxInstances: 1, GracePeriod: 7 * 24 * time.Hour}
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+	})
+
+	It("counts the grace period from when a service instance was first seen with an upgrade available", func() {
+		cfg.PendingSinceFile = filepath.Join(GinkgoT().TempDir(), "pending-since.json")
+		Expect(firstseen.Record{"recently-outdated-guid": time.Now().Add(-10 * 24 * time.Hour)}.Save(cfg.PendingSinceFile)).To(Succeed())
+		cfg.Thresholds = config.Thresholds{MaxInstances: 1, GracePeriod: 7 * 24 * time.Hour}
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError(ContainSubstring("2 of 4 service instances (50.0%) count towards the thresholds")))
+		})
+	})
+
+	It("does not apply the grace period to checks other than those of upgrades that are available", func() {
+		cfg.Action = config.CombinedChecksAction
+		cfg.Checks = []config.Action{config.CheckUpToDateAction, config.VersionConstraintAction}
+		cfg.VersionConstraint = version.MustConstraints(version.NewConstraint(">= 1.6.0"))
+		cfg.Thresholds = config.Thresholds{MaxInstances: 1, GracePeriod: 7 * 24 * time.Hour}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(MatchError("1 of 2 checks failed: version-constraint"))
+		})
+
+		Expect(output).To(ContainSubstring("2 of 4 service instances (50.0%) count towards the thresholds"))
+	})
+
+	It("applies to the version checks", func() {
+		cfg.Action = config.VersionConstraintAction
+		cfg.VersionConstraint = version.MustConstraints(version.NewConstraint(">= 1.6.0"))
+		cfg.Thresholds = config.Thresholds{MaxPercent: 50}
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+	})
+
+	It("applies to each of the combined checks", func() {
+		cfg.Action = config.CombinedChecksAction
+		cfg.Checks = []config.Action{config.CheckUpToDateAction, config.CheckDeactivatedPlansAction}
+		cfg.Thresholds = config.Thresholds{MaxInstances: 2}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(ContainSubstring("Check check-up-to-date: passed, 2 service instances are within the thresholds"))
+	})
+})
@@ -4,10 +4,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
 	"time"
 	"upgrade-all-services-cli-plugin/internal/ccapi"
 	"upgrade-all-services-cli-plugin/internal/config"
 	"upgrade-all-services-cli-plugin/internal/slicex"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
 	"upgrade-all-services-cli-plugin/internal/workers"
 
 	"github.com/hashicorp/go-version"
@@ -18,7 +25,12 @@ import (
 type CFClient interface {
 	GetServiceInstancesForServicePlans([]ccapi.ServicePlan) ([]ccapi.ServiceInstance, error)
 	GetServicePlans(string) ([]ccapi.ServicePlan, error)
+	GetServiceInstance(string) (ccapi.ServiceInstance, error)
 	UpgradeServiceInstance(string, string) error
+	UpdateServiceInstancePlan(string, string) error
+	AnnotateServiceInstance(string, ccapi.Provenance) error
+	GetServiceInstanceBindings([]string) (map[string]ccapi.Bindings, error)
+	GetAppProcessInstances(string) ([]ccapi.ProcessInstance, error)
 }
 
 //counterfeiter:generate . Logger
@@ -28,30 +40,71 @@ type Logger interface {
 	UpgradeStarting(instance ccapi.ServiceInstance, attempt, of int)
 	UpgradeSucceeded(instance ccapi.ServiceInstance, attempt, of int, duration time.Duration)
 	UpgradeFailed(instance ccapi.ServiceInstance, attempt, of int, duration time.Duration, err error)
+	PreHookFailed(instance ccapi.ServiceInstance, err error)
+	PostHookFailed(instance ccapi.ServiceInstance, err error)
+	UpgradeNotAtTargetVersion(instance ccapi.ServiceInstance, version string, err error)
+	UpgradeDegraded(instance ccapi.ServiceInstance, err error)
+	InstanceBindings(instance ccapi.ServiceInstance, bindings ccapi.Bindings)
 	InitialTotals(totalServiceInstances, totalUpgradableServiceInstances int)
 	HasUpgradeSucceeded() bool
 	FinalTotals()
 }
 
+//counterfeiter:generate . HookRunner
+type HookRunner interface {
+	Run(ccapi.ServiceInstance) (string, error)
+}
+
 type UpgradeConfig struct {
-	BrokerName       string
-	ParallelUpgrades int
-	Action           config.Action
-	MinVersion       *version.Version
-	JSONOutput       bool
-	Limit            int
-	Attempts         int
-	RetryInterval    time.Duration
+	BrokerName           string
+	ParallelUpgrades     int
+	Action               config.Action
+	Checks               []config.Action
+	MinVersion           *version.Version
+	MinVersionPolicy     []versionchecker.Rule
+	VersionConstraint    version.Constraints
+	FailOnUnknownVersion bool
+	VersionComparison    versionchecker.Comparison
+	Thresholds           config.Thresholds
+	MaxPendingAge        time.Duration
+	PendingSinceFile     string
+	PlanMappings         []config.PlanMapping
+	ReplacementPlans     []config.PlanMapping
+	JSONOutput           bool
+	Output               config.OutputFormat
+	Columns              []config.Column
+	Template             *template.Template
+	Summary              bool
+	BlastRadius          bool
+	Limit                int
+	Attempts             int
+	RetryInterval        time.Duration
+	Annotate             bool
+	Username             string
+	RunID                string
+	PreHook              HookRunner
+	PostHook             HookRunner
+	HealthCheckWindow    time.Duration
+	HealthCheckInterval  time.Duration
+	StopOnDegraded       bool
+	JUnitReport          io.Writer
 }
 
 func Upgrade(api CFClient, log Logger, cfg UpgradeConfig) error {
 	switch cfg.Action {
-	case config.MinVersionCheckAction:
-		return performMinimumVersionRequiredCheck(api, cfg)
-	case config.CheckDeactivatedPlansAction:
-		return performDeactivatedPlansCheck(api, cfg)
-	case config.CheckUpToDateAction:
-		return performUpToDateCheck(api, cfg)
+	case config.MinVersionCheckAction, config.VersionConstraintAction, config.CheckUpToDateAction,
+		config.CheckDeactivatedPlansAction, config.PendingAgeAction, config.CheckFailedOperationsAction, config.CheckVersionAnomaliesAction:
+		check, err := newCheck(cfg.Action, cfg)
+		if err != nil {
+			return err
+		}
+		return performCheck(api, cfg, check)
+	case config.MigratePlansAction:
+		return performPlanMigration(api, log, cfg)
+	case config.InventoryAction:
+		return performInventory(api, cfg)
+	case config.CombinedChecksAction:
+		return performCombinedChecks(api, cfg)
 	default: // continue function
 	}
 
@@ -62,16 +115,23 @@ func Upgrade(api CFClient, log Logger, cfg UpgradeConfig) error {
 
 	switch {
 	case cfg.Action == config.DryRunAction && cfg.JSONOutput:
-		return outputDryRunJSON(instances.upgradeable, instances.createFailed)
+		return outputDryRunJSON(api, instances.upgradeable, instances.createFailed, cfg.Summary, cfg.BlastRadius)
+	case cfg.Action == config.DryRunAction && cfg.Template != nil:
+		return outputTemplate(cfg.Template, newTemplateData(cfg.BrokerName, instances))
+	case cfg.Action == config.DryRunAction && cfg.Output.IsTabular():
+		return outputTabular(cfg.Output, cfg.Columns, tabularRows(statusUpgrade, instances.upgradeable), tabularRows(statusSkip, instances.createFailed))
 	case cfg.Action == config.DryRunAction && !cfg.JSONOutput:
-		return outputDryRunText(instances, log, cfg.BrokerName)
+		if err := outputDryRunText(api, instances, log, cfg.BrokerName, cfg.BlastRadius); err != nil {
+			return err
+		}
+		return outputSummaryText(cfg.Summary, upgradeSet(instances.upgradeable), skipSet(instances.createFailed))
 	default:
-		return performUpgrade(api, instances, cfg.ParallelUpgrades, cfg.Attempts, cfg.RetryInterval, cfg.BrokerName, log)
+		return performUpgrade(api, log, instances, cfg)
 	}
 }
 
-func performUpgrade(api CFClient, instances groupedServiceInstances, parallelUpgrades, attempts int, retryInterval time.Duration, brokerName string, log Logger) error {
-	log.Printf("discovering service instances for broker: %s", brokerName)
+func performUpgrade(api CFClient, log Logger, instances groupedServiceInstances, cfg UpgradeConfig) error {
+	log.Printf("discovering service instances for broker: %s", cfg.BrokerName)
 	log.InitialTotals(len(instances.all), len(instances.upgradeable))
 	defer log.FinalTotals()
 	for _, instance := range instances.createFailed {
@@ -81,60 +141,173 @@ func performUpgrade(api CFClient, instances groupedServiceInstances, parallelUpg
 		log.Printf("no instances available to upgrade")
 		return nil
 	}
+
+	if cfg.BlastRadius {
+		if _, err := logBlastRadius(api, log, instances.upgradeable); err != nil {
+			return err
+		}
+	}
+
+	var (
+		stopped   atomic.Bool
+		baselines sync.Map
+	)
+	runOperations(instances.upgradeable, cfg.ParallelUpgrades, cfg.Attempts, cfg.RetryInterval, log, operation{
+		before: func(instance ccapi.ServiceInstance) bool {
+			if stopped.Load() {
+				log.Printf("not upgrading instance: %q guid: %q as the upgrade was stopped", instance.Name, instance.GUID)
+				return false
+			}
+			if cfg.PreHook != nil {
+				if err := runHook(cfg.PreHook, "pre-hook", log, instance); err != nil {
+					log.PreHookFailed(instance, err)
+					return false
+				}
+			}
+			if cfg.HealthCheckWindow > 0 {
+				if baseline, ok := healthBeforeUpgrade(api, log, instance); ok {
+					baselines.Store(instance.GUID, baseline)
+				}
+			}
+			return true
+		},
+		perform: func(instance ccapi.ServiceInstance) error {
+			return api.UpgradeServiceInstance(instance.GUID, instance.ServicePlanMaintenanceInfoVersion)
+		},
+		after: func(instance ccapi.ServiceInstance) {
+			baseline, checked := baselines.LoadAndDelete(instance.GUID)
+			if !verifyUpgrade(api, log, instance) {
+				return
+			}
+			if cfg.Annotate {
+				annotateServiceInstance(api, log, instance, cfg)
+			}
+			if checked && !checkHealth(api, log, instance, baseline.(healthBaseline), cfg.HealthCheckWindow, cfg.HealthCheckInterval) {
+				if cfg.StopOnDegraded {
+					stopped.Store(true)
+				}
+				return
+			}
+			if cfg.PostHook == nil {
+				return
+			}
+			if err := runHook(cfg.PostHook, "post-hook", log, instance); err != nil {
+				log.PostHookFailed(instance, err)
+			}
+		},
+	})
+
+	if stopped.Load() {
+		return errors.New("the upgrade was stopped because apps bound to an upgraded instance crashed. Review the logs for more information")
+	}
+	if !log.HasUpgradeSucceeded() {
+		return errors.New("there were failures upgrading one or more instances. Review the logs for more information")
+	}
+	return nil
+}
+
+// verifyUpgrade re-reads a service instance once the upgrade operation has completed, because the operation
+// completing does not mean that the broker moved the service instance to the version of its plan. A service
+// instance that is not at the target version, or still has an upgrade available, is logged as its own outcome.
+// A service instance that cannot be re-read is logged in the same way, because its upgrade has not been verified.
+func verifyUpgrade(api CFClient, log Logger, instance ccapi.ServiceInstance) bool {
+	current, err := api.GetServiceInstance(instance.GUID)
+	switch {
+	case err != nil:
+		log.UpgradeNotAtTargetVersion(instance, "", fmt.Errorf("the version could not be verified: %s", err))
+		return false
+	case current.MaintenanceInfoVersion != instance.ServicePlanMaintenanceInfoVersion:
+		log.UpgradeNotAtTargetVersion(instance, current.MaintenanceInfoVersion, fmt.Errorf("version is %q rather than the target version %q", current.MaintenanceInfoVersion, instance.ServicePlanMaintenanceInfoVersion))
+		return false
+	case current.UpgradeAvailable:
+		log.UpgradeNotAtTargetVersion(instance, current.MaintenanceInfoVersion, fmt.Errorf("version is %q, but an upgrade is still available", current.MaintenanceInfoVersion))
+		return false
+	default:
+		return true
+	}
+}
+
+// annotateServiceInstance records the provenance of an upgrade on the service instance. The upgrade has already
+// succeeded at this point, so a failure is logged rather than causing the upgrade to be retried.
+func annotateServiceInstance(api CFClient, log Logger, instance ccapi.ServiceInstance, cfg UpgradeConfig) {
+	err := api.AnnotateServiceInstance(instance.GUID, ccapi.Provenance{
+		UpgradedBy:      cfg.Username,
+		UpgradedAt:      time.Now().UTC().Format(time.RFC3339),
+		PreviousVersion: instance.MaintenanceInfoVersion,
+		RunID:           cfg.RunID,
+	})
+	if err != nil {
+		log.Printf("failed to annotate instance: %q guid: %q: %s", instance.Name, instance.GUID, err)
+	}
+}
+
+// runHook runs a hook command for a service instance, logging any output from the command
+func runHook(hook HookRunner, name string, log Logger, instance ccapi.ServiceInstance) error {
+	output, err := hook.Run(instance)
+	if output = strings.TrimSpace(output); output != "" {
+		log.Printf("%s output for instance: %q guid: %q:\n%s", name, instance.Name, instance.GUID, output)
+	}
+	return err
+}
+
+// operation describes the work that runOperations does for each service instance
+type operation struct {
+	// before is optional, and is called once before the first attempt. Returning false skips the instance.
+	before func(ccapi.ServiceInstance) bool
+	// perform is called for each attempt until it succeeds or the attempts are exhausted
+	perform func(ccapi.ServiceInstance) error
+	// after is optional, and is called once perform has succeeded
+	after func(ccapi.ServiceInstance)
+}
+
+// runOperations performs an operation on each of the service instances using a pool of workers,
+// retrying failed operations and reporting progress to the logger
+func runOperations(instances []ccapi.ServiceInstance, parallel, attempts int, retryInterval time.Duration, log Logger, op operation) {
 	// Must have at least one attempt. Mostly this is here to make simplify writing tests.
 	if attempts < 1 {
 		attempts = 1
 	}
 
-	type upgradeTask struct {
-		UpgradeableIndex       int
-		ServiceInstanceName    string
-		ServiceInstanceGUID    string
-		MaintenanceInfoVersion string
-	}
-
-	upgradeQueue := make(chan upgradeTask)
+	queue := make(chan ccapi.ServiceInstance)
 	go func() {
-		for i, instance := range instances.upgradeable {
-			upgradeQueue <- upgradeTask{
-				UpgradeableIndex:       i,
-				ServiceInstanceName:    instance.Name,
-				ServiceInstanceGUID:    instance.GUID,
-				MaintenanceInfoVersion: instance.ServicePlanMaintenanceInfoVersion,
-			}
+		for _, instance := range instances {
+			queue <- instance
 		}
-		close(upgradeQueue)
+		close(queue)
 	}()
 
-	workers.Run(parallelUpgrades, func() {
-		for instance := range upgradeQueue {
+	workers.Run(parallel, func() {
+		for instance := range queue {
+			if op.before != nil && !op.before(instance) {
+				continue
+			}
+
 			succeeded := false
 			for attempt := 1; attempt <= attempts && !succeeded; attempt++ {
 				start := time.Now()
-				log.UpgradeStarting(instances.upgradeable[instance.UpgradeableIndex], attempt, attempts)
-				err := api.UpgradeServiceInstance(instance.ServiceInstanceGUID, instance.MaintenanceInfoVersion)
+				log.UpgradeStarting(instance, attempt, attempts)
+				err := op.perform(instance)
 				switch err {
 				case nil:
-					log.UpgradeSucceeded(instances.upgradeable[instance.UpgradeableIndex], attempt, attempts, time.Since(start))
+					log.UpgradeSucceeded(instance, attempt, attempts, time.Since(start))
 					succeeded = true
 				default:
-					log.UpgradeFailed(instances.upgradeable[instance.UpgradeableIndex], attempt, attempts, time.Since(start), err)
+					log.UpgradeFailed(instance, attempt, attempts, time.Since(start), err)
 				}
 
 				if !succeeded && attempt < attempts {
 					time.Sleep(retryInterval)
 				}
 			}
+
+			if succeeded && op.after != nil {
+				op.after(instance)
+			}
 		}
 	})
-
-	if !log.HasUpgradeSucceeded() {
-		return errors.New("there were failures upgrading one or more instances. Review the logs for more information")
-	}
-	return nil
 }
 
-func outputDryRunText(instances groupedServiceInstances, log Logger, brokerName string) error {
+func outputDryRunText(api CFClient, instances groupedServiceInstances, log Logger, brokerName string, blastRadius bool) error {
 	log.Printf("discovering service instances for broker: %s", brokerName)
 	for _, instance := range instances.createFailed {
 		log.SkippingInstance(instance)
@@ -148,6 +321,12 @@ func outputDryRunText(instances groupedServiceInstances, log Logger, brokerName
 	log.InitialTotals(len(instances.all), len(instances.upgradeable))
 	defer log.FinalTotals()
 
+	if blastRadius {
+		if _, err := logBlastRadius(api, log, instances.upgradeable); err != nil {
+			return err
+		}
+	}
+
 	for _, i := range instances.upgradeable {
 		dryRunErr := fmt.Errorf("dry-run prevented upgrade instance guid %s", i.GUID)
 		log.UpgradeFailed(i, 1, 1, time.Duration(0), dryRunErr)
@@ -158,15 +337,29 @@ func outputDryRunText(instances groupedServiceInstances, log Logger, brokerName
 
 // outputDryRunJSON produces a JSON version of the dry run output. Unlike --check-up-to-date we do not
 // output deactivated plans. This is to match existing behavior.
-func outputDryRunJSON(upgradableInstances, createFailedInstances []ccapi.ServiceInstance) error {
+func outputDryRunJSON(api CFClient, upgradableInstances, createFailedInstances []ccapi.ServiceInstance, withSummary, blastRadius bool) error {
 	type formatter struct {
 		UpgradePending []jsonOutputServiceInstance `json:"upgrade"`
 		CreateFailed   []jsonOutputServiceInstance `json:"skip"`
+		BlastRadius    []jsonOutputBlastRadius     `json:"blast_radius,omitempty"`
+		Summary        map[string]summary.Summary  `json:"summary,omitempty"`
 	}
 
 	data := formatter{
 		UpgradePending: slicex.Map(upgradableInstances, newJSONOutputServiceInstance),
 		CreateFailed:   slicex.Map(createFailedInstances, newJSONOutputServiceInstance),
+		Summary:        summaryJSON(withSummary, upgradeSet(upgradableInstances), skipSet(createFailedInstances)),
+	}
+
+	if blastRadius && len(upgradableInstances) > 0 {
+		bindings, err := api.GetServiceInstanceBindings(slicex.Map(upgradableInstances, func(i ccapi.ServiceInstance) string { return i.GUID }))
+		if err != nil {
+			return err
+		}
+		for i := range data.UpgradePending {
+			data.UpgradePending[i] = withBindings(data.UpgradePending[i], bindings[data.UpgradePending[i].GUID])
+		}
+		data.BlastRadius = slicex.Map(blastRadiusByOrg(upgradableInstances, bindings), newJSONOutputBlastRadius)
 	}
 
 	output, err := json.MarshalIndent(data, "", "  ")
@@ -179,21 +372,23 @@ func outputDryRunJSON(upgradableInstances, createFailedInstances []ccapi.Service
 	return nil
 }
 
-func getAllServiceInstances(api CFClient, brokerName string) ([]ccapi.ServiceInstance, error) {
+func getAllServiceInstances(api CFClient, brokerName string) ([]ccapi.ServicePlan, []ccapi.ServiceInstance, error) {
 	servicePlans, err := api.GetServicePlans(brokerName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	if len(servicePlans) == 0 {
-		return nil, fmt.Errorf("no service plans available for broker: %s", brokerName)
+		return nil, nil, fmt.Errorf("no service plans available for broker: %s", brokerName)
 	}
 
-	return api.GetServiceInstancesForServicePlans(servicePlans)
+	instances, err := api.GetServiceInstancesForServicePlans(servicePlans)
+	return servicePlans, instances, err
 }
 
 type groupedServiceInstances struct {
 	all, upgradeable, deactivatedPlan, createFailed []ccapi.ServiceInstance
+	plans                                           []ccapi.ServicePlan
 }
 
 // getGroupedServiceInstances will fetch all the service instances for a broker and group them into the following categories:
@@ -201,12 +396,19 @@ type groupedServiceInstances struct {
 // - deactivatedPlan - all service instances associated with a deactivated plan
 // - createFailed - all service instances for which the UpgradeAvailable flag is set, but the instance failed to create
 // - upgradeable - all service instances for which the UpgradeAvailable flag is set, bit the instance has been created successfully
+// The plans of the broker are kept alongside them.
 func getGroupedServiceInstances(api CFClient, brokerName string, limit int) (groupedServiceInstances, error) {
-	instances, err := getAllServiceInstances(api, brokerName)
+	plans, instances, err := getAllServiceInstances(api, brokerName)
 	if err != nil {
 		return groupedServiceInstances{}, err
 	}
 
+	grouped := groupServiceInstances(instances, limit)
+	grouped.plans = plans
+	return grouped, nil
+}
+
+func groupServiceInstances(instances []ccapi.ServiceInstance, limit int) groupedServiceInstances {
 	deactivatedPlan := slicex.Filter(instances, func(instance ccapi.ServiceInstance) bool { return instance.ServicePlanDeactivated })
 	upgradeAvailable := slicex.Filter(instances, func(instance ccapi.ServiceInstance) bool { return instance.UpgradeAvailable })
 	createFailed, upgradeable := slicex.Partition(upgradeAvailable, ccapi.HasInstanceCreateFailedStatus)
@@ -221,5 +423,5 @@ func getGroupedServiceInstances(api CFClient, brokerName string, limit int) (gro
 		deactivatedPlan: deactivatedPlan,
 		createFailed:    createFailed,
 		upgradeable:     upgradeable,
-	}, nil
+	}
 }
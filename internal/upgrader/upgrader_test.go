@@ -319,6 +319,71 @@ var _ = Describe("Upgrade", func() {
 			Expect(err).To(MatchError("there were failures upgrading one or more instances. Review the logs for more information"))
 		})
 	})
+
+	When("verifying the upgrade", func() {
+		var cfg upgrader.UpgradeConfig
+
+		BeforeEach(func() {
+			fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+				{Name: "fake-instance", GUID: "fake-instance-guid", UpgradeAvailable: true, MaintenanceInfoVersion: "1.2.2", ServicePlanMaintenanceInfoVersion: "1.2.3"},
+			}, nil)
+			cfg = upgrader.UpgradeConfig{BrokerName: fakeBrokerName, ParallelUpgrades: 1, Annotate: true}
+		})
+
+		It("re-reads the service instance after the upgrade", func() {
+			fakeCFClient.GetServiceInstanceReturns(ccapi.ServiceInstance{GUID: "fake-instance-guid", MaintenanceInfoVersion: "1.2.3"}, nil)
+
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLog, cfg)).To(Succeed())
+			Expect(fakeCFClient.GetServiceInstanceCallCount()).To(Equal(1))
+			Expect(fakeCFClient.GetServiceInstanceArgsForCall(0)).To(Equal("fake-instance-guid"))
+			Expect(fakeLog.UpgradeNotAtTargetVersionCallCount()).To(BeZero())
+			Expect(fakeCFClient.AnnotateServiceInstanceCallCount()).To(Equal(1))
+		})
+
+		It("logs a service instance that is not at the target version", func() {
+			fakeCFClient.GetServiceInstanceReturns(ccapi.ServiceInstance{GUID: "fake-instance-guid", MaintenanceInfoVersion: "1.2.2"}, nil)
+
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLog, cfg)).To(Succeed())
+			Expect(fakeLog.UpgradeNotAtTargetVersionCallCount()).To(Equal(1))
+			instance, version, err := fakeLog.UpgradeNotAtTargetVersionArgsForCall(0)
+			Expect(instance.GUID).To(Equal("fake-instance-guid"))
+			Expect(version).To(Equal("1.2.2"))
+			Expect(err).To(MatchError(`version is "1.2.2" rather than the target version "1.2.3"`))
+
+			By("not annotating the service instance")
+			Expect(fakeCFClient.AnnotateServiceInstanceCallCount()).To(BeZero())
+		})
+
+		It("logs a service instance that still has an upgrade available", func() {
+			fakeCFClient.GetServiceInstanceReturns(ccapi.ServiceInstance{GUID: "fake-instance-guid", MaintenanceInfoVersion: "1.2.3", UpgradeAvailable: true}, nil)
+
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLog, cfg)).To(Succeed())
+			Expect(fakeLog.UpgradeNotAtTargetVersionCallCount()).To(Equal(1))
+			_, _, err := fakeLog.UpgradeNotAtTargetVersionArgsForCall(0)
+			Expect(err).To(MatchError(`version is "1.2.3", but an upgrade is still available`))
+		})
+
+		It("fails the run when the logger has recorded an instance that is not at the target version", func() {
+			fakeCFClient.GetServiceInstanceReturns(ccapi.ServiceInstance{GUID: "fake-instance-guid", MaintenanceInfoVersion: "1.2.2"}, nil)
+			fakeLog.HasUpgradeSucceededReturns(false)
+
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLog, cfg)).To(MatchError("there were failures upgrading one or more instances. Review the logs for more information"))
+		})
+
+		It("logs a service instance that could not be re-read as not verified", func() {
+			fakeCFClient.GetServiceInstanceReturns(ccapi.ServiceInstance{}, fmt.Errorf("boom"))
+
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLog, cfg)).To(Succeed())
+			Expect(fakeLog.UpgradeNotAtTargetVersionCallCount()).To(Equal(1))
+			instance, version, err := fakeLog.UpgradeNotAtTargetVersionArgsForCall(0)
+			Expect(instance.GUID).To(Equal("fake-instance-guid"))
+			Expect(version).To(BeEmpty())
+			Expect(err).To(MatchError("the version could not be verified: boom"))
+
+			By("not annotating the service instance")
+			Expect(fakeCFClient.AnnotateServiceInstanceCallCount()).To(BeZero())
+		})
+	})
 })
 
 var captureStdoutLock sync.Mutex
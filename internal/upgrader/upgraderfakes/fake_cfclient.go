@@ -8,6 +8,57 @@ import (
 )
 
 type FakeCFClient struct {
+	AnnotateServiceInstanceStub        func(string, ccapi.Provenance) error
+	annotateServiceInstanceMutex       sync.RWMutex
+	annotateServiceInstanceArgsForCall []struct {
+		arg1 string
+		arg2 ccapi.Provenance
+	}
+	annotateServiceInstanceReturns struct {
+		result1 error
+	}
+	annotateServiceInstanceReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetAppProcessInstancesStub        func(string) ([]ccapi.ProcessInstance, error)
+	getAppProcessInstancesMutex       sync.RWMutex
+	getAppProcessInstancesArgsForCall []struct {
+		arg1 string
+	}
+	getAppProcessInstancesReturns struct {
+		result1 []ccapi.ProcessInstance
+		result2 error
+	}
+	getAppProcessInstancesReturnsOnCall map[int]struct {
+		result1 []ccapi.ProcessInstance
+		result2 error
+	}
+	GetServiceInstanceStub        func(string) (ccapi.ServiceInstance, error)
+	getServiceInstanceMutex       sync.RWMutex
+	getServiceInstanceArgsForCall []struct {
+		arg1 string
+	}
+	getServiceInstanceReturns struct {
+		result1 ccapi.ServiceInstance
+		result2 error
+	}
+	getServiceInstanceReturnsOnCall map[int]struct {
+		result1 ccapi.ServiceInstance
+		result2 error
+	}
+	GetServiceInstanceBindingsStub        func([]string) (map[string]ccapi.Bindings, error)
+	getServiceInstanceBindingsMutex       sync.RWMutex
+	getServiceInstanceBindingsArgsForCall []struct {
+		arg1 []string
+	}
+	getServiceInstanceBindingsReturns struct {
+		result1 map[string]ccapi.Bindings
+		result2 error
+	}
+	getServiceInstanceBindingsReturnsOnCall map[int]struct {
+		result1 map[string]ccapi.Bindings
+		result2 error
+	}
 	GetServiceInstancesForServicePlansStub        func([]ccapi.ServicePlan) ([]ccapi.ServiceInstance, error)
 	getServiceInstancesForServicePlansMutex       sync.RWMutex
 	getServiceInstancesForServicePlansArgsForCall []struct {
@@ -34,6 +85,18 @@ type FakeCFClient struct {
 		result1 []ccapi.ServicePlan
 		result2 error
 	}
+	UpdateServiceInstancePlanStub        func(string, string) error
+	updateServiceInstancePlanMutex       sync.RWMutex
+	updateServiceInstancePlanArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	updateServiceInstancePlanReturns struct {
+		result1 error
+	}
+	updateServiceInstancePlanReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UpgradeServiceInstanceStub        func(string, string) error
 	upgradeServiceInstanceMutex       sync.RWMutex
 	upgradeServiceInstanceArgsForCall []struct {
@@ -50,6 +113,265 @@ type FakeCFClient struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeCFClient) AnnotateServiceInstance(arg1 string, arg2 ccapi.Provenance) error {
+	fake.annotateServiceInstanceMutex.Lock()
+	ret, specificReturn := fake.annotateServiceInstanceReturnsOnCall[len(fake.annotateServiceInstanceArgsForCall)]
+	fake.annotateServiceInstanceArgsForCall = append(fake.annotateServiceInstanceArgsForCall, struct {
+		arg1 string
+		arg2 ccapi.Provenance
+	}{arg1, arg2})
+	stub := fake.AnnotateServiceInstanceStub
+	fakeReturns := fake.annotateServiceInstanceReturns
+	fake.recordInvocation("AnnotateServiceInstance", []interface{}{arg1, arg2})
+	fake.annotateServiceInstanceMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCFClient) AnnotateServiceInstanceCallCount() int {
+	fake.annotateServiceInstanceMutex.RLock()
+	defer fake.annotateServiceInstanceMutex.RUnlock()
+	return len(fake.annotateServiceInstanceArgsForCall)
+}
+
+func (fake *FakeCFClient) AnnotateServiceInstanceCalls(stub func(string, ccapi.Provenance) error) {
+	fake.annotateServiceInstanceMutex.Lock()
+	defer fake.annotateServiceInstanceMutex.Unlock()
+	fake.AnnotateServiceInstanceStub = stub
+}
+
+func (fake *FakeCFClient) AnnotateServiceInstanceArgsForCall(i int) (string, ccapi.Provenance) {
+	fake.annotateServiceInstanceMutex.RLock()
+	defer fake.annotateServiceInstanceMutex.RUnlock()
+	argsForCall := fake.annotateServiceInstanceArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeCFClient) AnnotateServiceInstanceReturns(result1 error) {
+	fake.annotateServiceInstanceMutex.Lock()
+	defer fake.annotateServiceInstanceMutex.Unlock()
+	fake.AnnotateServiceInstanceStub = nil
+	fake.annotateServiceInstanceReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCFClient) AnnotateServiceInstanceReturnsOnCall(i int, result1 error) {
+	fake.annotateServiceInstanceMutex.Lock()
+	defer fake.annotateServiceInstanceMutex.Unlock()
+	fake.AnnotateServiceInstanceStub = nil
+	if fake.annotateServiceInstanceReturnsOnCall == nil {
+		fake.annotateServiceInstanceReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.annotateServiceInstanceReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCFClient) GetAppProcessInstances(arg1 string) ([]ccapi.ProcessInstance, error) {
+	fake.getAppProcessInstancesMutex.Lock()
+	ret, specificReturn := fake.getAppProcessInstancesReturnsOnCall[len(fake.getAppProcessInstancesArgsForCall)]
+	fake.getAppProcessInstancesArgsForCall = append(fake.getAppProcessInstancesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetAppProcessInstancesStub
+	fakeReturns := fake.getAppProcessInstancesReturns
+	fake.recordInvocation("GetAppProcessInstances", []interface{}{arg1})
+	fake.getAppProcessInstancesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCFClient) GetAppProcessInstancesCallCount() int {
+	fake.getAppProcessInstancesMutex.RLock()
+	defer fake.getAppProcessInstancesMutex.RUnlock()
+	return len(fake.getAppProcessInstancesArgsForCall)
+}
+
+func (fake *FakeCFClient) GetAppProcessInstancesCalls(stub func(string) ([]ccapi.ProcessInstance, error)) {
+	fake.getAppProcessInstancesMutex.Lock()
+	defer fake.getAppProcessInstancesMutex.Unlock()
+	fake.GetAppProcessInstancesStub = stub
+}
+
+func (fake *FakeCFClient) GetAppProcessInstancesArgsForCall(i int) string {
+	fake.getAppProcessInstancesMutex.RLock()
+	defer fake.getAppProcessInstancesMutex.RUnlock()
+	argsForCall := fake.getAppProcessInstancesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCFClient) GetAppProcessInstancesReturns(result1 []ccapi.ProcessInstance, result2 error) {
+	fake.getAppProcessInstancesMutex.Lock()
+	defer fake.getAppProcessInstancesMutex.Unlock()
+	fake.GetAppProcessInstancesStub = nil
+	fake.getAppProcessInstancesReturns = struct {
+		result1 []ccapi.ProcessInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFClient) GetAppProcessInstancesReturnsOnCall(i int, result1 []ccapi.ProcessInstance, result2 error) {
+	fake.getAppProcessInstancesMutex.Lock()
+	defer fake.getAppProcessInstancesMutex.Unlock()
+	fake.GetAppProcessInstancesStub = nil
+	if fake.getAppProcessInstancesReturnsOnCall == nil {
+		fake.getAppProcessInstancesReturnsOnCall = make(map[int]struct {
+			result1 []ccapi.ProcessInstance
+			result2 error
+		})
+	}
+	fake.getAppProcessInstancesReturnsOnCall[i] = struct {
+		result1 []ccapi.ProcessInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFClient) GetServiceInstance(arg1 string) (ccapi.ServiceInstance, error) {
+	fake.getServiceInstanceMutex.Lock()
+	ret, specificReturn := fake.getServiceInstanceReturnsOnCall[len(fake.getServiceInstanceArgsForCall)]
+	fake.getServiceInstanceArgsForCall = append(fake.getServiceInstanceArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetServiceInstanceStub
+	fakeReturns := fake.getServiceInstanceReturns
+	fake.recordInvocation("GetServiceInstance", []interface{}{arg1})
+	fake.getServiceInstanceMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCFClient) GetServiceInstanceCallCount() int {
+	fake.getServiceInstanceMutex.RLock()
+	defer fake.getServiceInstanceMutex.RUnlock()
+	return len(fake.getServiceInstanceArgsForCall)
+}
+
+func (fake *FakeCFClient) GetServiceInstanceCalls(stub func(string) (ccapi.ServiceInstance, error)) {
+	fake.getServiceInstanceMutex.Lock()
+	defer fake.getServiceInstanceMutex.Unlock()
+	fake.GetServiceInstanceStub = stub
+}
+
+func (fake *FakeCFClient) GetServiceInstanceArgsForCall(i int) string {
+	fake.getServiceInstanceMutex.RLock()
+	defer fake.getServiceInstanceMutex.RUnlock()
+	argsForCall := fake.getServiceInstanceArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCFClient) GetServiceInstanceReturns(result1 ccapi.ServiceInstance, result2 error) {
+	fake.getServiceInstanceMutex.Lock()
+	defer fake.getServiceInstanceMutex.Unlock()
+	fake.GetServiceInstanceStub = nil
+	fake.getServiceInstanceReturns = struct {
+		result1 ccapi.ServiceInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFClient) GetServiceInstanceReturnsOnCall(i int, result1 ccapi.ServiceInstance, result2 error) {
+	fake.getServiceInstanceMutex.Lock()
+	defer fake.getServiceInstanceMutex.Unlock()
+	fake.GetServiceInstanceStub = nil
+	if fake.getServiceInstanceReturnsOnCall == nil {
+		fake.getServiceInstanceReturnsOnCall = make(map[int]struct {
+			result1 ccapi.ServiceInstance
+			result2 error
+		})
+	}
+	fake.getServiceInstanceReturnsOnCall[i] = struct {
+		result1 ccapi.ServiceInstance
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFClient) GetServiceInstanceBindings(arg1 []string) (map[string]ccapi.Bindings, error) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.getServiceInstanceBindingsMutex.Lock()
+	ret, specificReturn := fake.getServiceInstanceBindingsReturnsOnCall[len(fake.getServiceInstanceBindingsArgsForCall)]
+	fake.getServiceInstanceBindingsArgsForCall = append(fake.getServiceInstanceBindingsArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	stub := fake.GetServiceInstanceBindingsStub
+	fakeReturns := fake.getServiceInstanceBindingsReturns
+	fake.recordInvocation("GetServiceInstanceBindings", []interface{}{arg1Copy})
+	fake.getServiceInstanceBindingsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeCFClient) GetServiceInstanceBindingsCallCount() int {
+	fake.getServiceInstanceBindingsMutex.RLock()
+	defer fake.getServiceInstanceBindingsMutex.RUnlock()
+	return len(fake.getServiceInstanceBindingsArgsForCall)
+}
+
+func (fake *FakeCFClient) GetServiceInstanceBindingsCalls(stub func([]string) (map[string]ccapi.Bindings, error)) {
+	fake.getServiceInstanceBindingsMutex.Lock()
+	defer fake.getServiceInstanceBindingsMutex.Unlock()
+	fake.GetServiceInstanceBindingsStub = stub
+}
+
+func (fake *FakeCFClient) GetServiceInstanceBindingsArgsForCall(i int) []string {
+	fake.getServiceInstanceBindingsMutex.RLock()
+	defer fake.getServiceInstanceBindingsMutex.RUnlock()
+	argsForCall := fake.getServiceInstanceBindingsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeCFClient) GetServiceInstanceBindingsReturns(result1 map[string]ccapi.Bindings, result2 error) {
+	fake.getServiceInstanceBindingsMutex.Lock()
+	defer fake.getServiceInstanceBindingsMutex.Unlock()
+	fake.GetServiceInstanceBindingsStub = nil
+	fake.getServiceInstanceBindingsReturns = struct {
+		result1 map[string]ccapi.Bindings
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeCFClient) GetServiceInstanceBindingsReturnsOnCall(i int, result1 map[string]ccapi.Bindings, result2 error) {
+	fake.getServiceInstanceBindingsMutex.Lock()
+	defer fake.getServiceInstanceBindingsMutex.Unlock()
+	fake.GetServiceInstanceBindingsStub = nil
+	if fake.getServiceInstanceBindingsReturnsOnCall == nil {
+		fake.getServiceInstanceBindingsReturnsOnCall = make(map[int]struct {
+			result1 map[string]ccapi.Bindings
+			result2 error
+		})
+	}
+	fake.getServiceInstanceBindingsReturnsOnCall[i] = struct {
+		result1 map[string]ccapi.Bindings
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeCFClient) GetServiceInstancesForServicePlans(arg1 []ccapi.ServicePlan) ([]ccapi.ServiceInstance, error) {
 	var arg1Copy []ccapi.ServicePlan
 	if arg1 != nil {
@@ -183,6 +505,68 @@ func (fake *FakeCFClient) GetServicePlansReturnsOnCall(i int, result1 []ccapi.Se
 	}{result1, result2}
 }
 
+func (fake *FakeCFClient) UpdateServiceInstancePlan(arg1 string, arg2 string) error {
+	fake.updateServiceInstancePlanMutex.Lock()
+	ret, specificReturn := fake.updateServiceInstancePlanReturnsOnCall[len(fake.updateServiceInstancePlanArgsForCall)]
+	fake.updateServiceInstancePlanArgsForCall = append(fake.updateServiceInstancePlanArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.UpdateServiceInstancePlanStub
+	fakeReturns := fake.updateServiceInstancePlanReturns
+	fake.recordInvocation("UpdateServiceInstancePlan", []interface{}{arg1, arg2})
+	fake.updateServiceInstancePlanMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCFClient) UpdateServiceInstancePlanCallCount() int {
+	fake.updateServiceInstancePlanMutex.RLock()
+	defer fake.updateServiceInstancePlanMutex.RUnlock()
+	return len(fake.updateServiceInstancePlanArgsForCall)
+}
+
+func (fake *FakeCFClient) UpdateServiceInstancePlanCalls(stub func(string, string) error) {
+	fake.updateServiceInstancePlanMutex.Lock()
+	defer fake.updateServiceInstancePlanMutex.Unlock()
+	fake.UpdateServiceInstancePlanStub = stub
+}
+
+func (fake *FakeCFClient) UpdateServiceInstancePlanArgsForCall(i int) (string, string) {
+	fake.updateServiceInstancePlanMutex.RLock()
+	defer fake.updateServiceInstancePlanMutex.RUnlock()
+	argsForCall := fake.updateServiceInstancePlanArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeCFClient) UpdateServiceInstancePlanReturns(result1 error) {
+	fake.updateServiceInstancePlanMutex.Lock()
+	defer fake.updateServiceInstancePlanMutex.Unlock()
+	fake.UpdateServiceInstancePlanStub = nil
+	fake.updateServiceInstancePlanReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCFClient) UpdateServiceInstancePlanReturnsOnCall(i int, result1 error) {
+	fake.updateServiceInstancePlanMutex.Lock()
+	defer fake.updateServiceInstancePlanMutex.Unlock()
+	fake.UpdateServiceInstancePlanStub = nil
+	if fake.updateServiceInstancePlanReturnsOnCall == nil {
+		fake.updateServiceInstancePlanReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.updateServiceInstancePlanReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeCFClient) UpgradeServiceInstance(arg1 string, arg2 string) error {
 	fake.upgradeServiceInstanceMutex.Lock()
 	ret, specificReturn := fake.upgradeServiceInstanceReturnsOnCall[len(fake.upgradeServiceInstanceArgsForCall)]
@@ -248,12 +632,6 @@ func (fake *FakeCFClient) UpgradeServiceInstanceReturnsOnCall(i int, result1 err
 func (fake *FakeCFClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.getServiceInstancesForServicePlansMutex.RLock()
-	defer fake.getServiceInstancesForServicePlansMutex.RUnlock()
-	fake.getServicePlansMutex.RLock()
-	defer fake.getServicePlansMutex.RUnlock()
-	fake.upgradeServiceInstanceMutex.RLock()
-	defer fake.upgradeServiceInstanceMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
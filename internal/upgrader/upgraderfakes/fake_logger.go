@@ -29,6 +29,24 @@ type FakeLogger struct {
 		arg1 int
 		arg2 int
 	}
+	InstanceBindingsStub        func(ccapi.ServiceInstance, ccapi.Bindings)
+	instanceBindingsMutex       sync.RWMutex
+	instanceBindingsArgsForCall []struct {
+		arg1 ccapi.ServiceInstance
+		arg2 ccapi.Bindings
+	}
+	PostHookFailedStub        func(ccapi.ServiceInstance, error)
+	postHookFailedMutex       sync.RWMutex
+	postHookFailedArgsForCall []struct {
+		arg1 ccapi.ServiceInstance
+		arg2 error
+	}
+	PreHookFailedStub        func(ccapi.ServiceInstance, error)
+	preHookFailedMutex       sync.RWMutex
+	preHookFailedArgsForCall []struct {
+		arg1 ccapi.ServiceInstance
+		arg2 error
+	}
 	PrintfStub        func(string, ...any)
 	printfMutex       sync.RWMutex
 	printfArgsForCall []struct {
@@ -40,6 +58,12 @@ type FakeLogger struct {
 	skippingInstanceArgsForCall []struct {
 		arg1 ccapi.ServiceInstance
 	}
+	UpgradeDegradedStub        func(ccapi.ServiceInstance, error)
+	upgradeDegradedMutex       sync.RWMutex
+	upgradeDegradedArgsForCall []struct {
+		arg1 ccapi.ServiceInstance
+		arg2 error
+	}
 	UpgradeFailedStub        func(ccapi.ServiceInstance, int, int, time.Duration, error)
 	upgradeFailedMutex       sync.RWMutex
 	upgradeFailedArgsForCall []struct {
@@ -49,6 +73,13 @@ type FakeLogger struct {
 		arg4 time.Duration
 		arg5 error
 	}
+	UpgradeNotAtTargetVersionStub        func(ccapi.ServiceInstance, string, error)
+	upgradeNotAtTargetVersionMutex       sync.RWMutex
+	upgradeNotAtTargetVersionArgsForCall []struct {
+		arg1 ccapi.ServiceInstance
+		arg2 string
+		arg3 error
+	}
 	UpgradeStartingStub        func(ccapi.ServiceInstance, int, int)
 	upgradeStartingMutex       sync.RWMutex
 	upgradeStartingArgsForCall []struct {
@@ -178,6 +209,105 @@ func (fake *FakeLogger) InitialTotalsArgsForCall(i int) (int, int) {
 	return argsForCall.arg1, argsForCall.arg2
 }
 
+func (fake *FakeLogger) InstanceBindings(arg1 ccapi.ServiceInstance, arg2 ccapi.Bindings) {
+	fake.instanceBindingsMutex.Lock()
+	fake.instanceBindingsArgsForCall = append(fake.instanceBindingsArgsForCall, struct {
+		arg1 ccapi.ServiceInstance
+		arg2 ccapi.Bindings
+	}{arg1, arg2})
+	stub := fake.InstanceBindingsStub
+	fake.recordInvocation("InstanceBindings", []interface{}{arg1, arg2})
+	fake.instanceBindingsMutex.Unlock()
+	if stub != nil {
+		fake.InstanceBindingsStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeLogger) InstanceBindingsCallCount() int {
+	fake.instanceBindingsMutex.RLock()
+	defer fake.instanceBindingsMutex.RUnlock()
+	return len(fake.instanceBindingsArgsForCall)
+}
+
+func (fake *FakeLogger) InstanceBindingsCalls(stub func(ccapi.ServiceInstance, ccapi.Bindings)) {
+	fake.instanceBindingsMutex.Lock()
+	defer fake.instanceBindingsMutex.Unlock()
+	fake.InstanceBindingsStub = stub
+}
+
+func (fake *FakeLogger) InstanceBindingsArgsForCall(i int) (ccapi.ServiceInstance, ccapi.Bindings) {
+	fake.instanceBindingsMutex.RLock()
+	defer fake.instanceBindingsMutex.RUnlock()
+	argsForCall := fake.instanceBindingsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLogger) PostHookFailed(arg1 ccapi.ServiceInstance, arg2 error) {
+	fake.postHookFailedMutex.Lock()
+	fake.postHookFailedArgsForCall = append(fake.postHookFailedArgsForCall, struct {
+		arg1 ccapi.ServiceInstance
+		arg2 error
+	}{arg1, arg2})
+	stub := fake.PostHookFailedStub
+	fake.recordInvocation("PostHookFailed", []interface{}{arg1, arg2})
+	fake.postHookFailedMutex.Unlock()
+	if stub != nil {
+		fake.PostHookFailedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeLogger) PostHookFailedCallCount() int {
+	fake.postHookFailedMutex.RLock()
+	defer fake.postHookFailedMutex.RUnlock()
+	return len(fake.postHookFailedArgsForCall)
+}
+
+func (fake *FakeLogger) PostHookFailedCalls(stub func(ccapi.ServiceInstance, error)) {
+	fake.postHookFailedMutex.Lock()
+	defer fake.postHookFailedMutex.Unlock()
+	fake.PostHookFailedStub = stub
+}
+
+func (fake *FakeLogger) PostHookFailedArgsForCall(i int) (ccapi.ServiceInstance, error) {
+	fake.postHookFailedMutex.RLock()
+	defer fake.postHookFailedMutex.RUnlock()
+	argsForCall := fake.postHookFailedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeLogger) PreHookFailed(arg1 ccapi.ServiceInstance, arg2 error) {
+	fake.preHookFailedMutex.Lock()
+	fake.preHookFailedArgsForCall = append(fake.preHookFailedArgsForCall, struct {
+		arg1 ccapi.ServiceInstance
+		arg2 error
+	}{arg1, arg2})
+	stub := fake.PreHookFailedStub
+	fake.recordInvocation("PreHookFailed", []interface{}{arg1, arg2})
+	fake.preHookFailedMutex.Unlock()
+	if stub != nil {
+		fake.PreHookFailedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeLogger) PreHookFailedCallCount() int {
+	fake.preHookFailedMutex.RLock()
+	defer fake.preHookFailedMutex.RUnlock()
+	return len(fake.preHookFailedArgsForCall)
+}
+
+func (fake *FakeLogger) PreHookFailedCalls(stub func(ccapi.ServiceInstance, error)) {
+	fake.preHookFailedMutex.Lock()
+	defer fake.preHookFailedMutex.Unlock()
+	fake.PreHookFailedStub = stub
+}
+
+func (fake *FakeLogger) PreHookFailedArgsForCall(i int) (ccapi.ServiceInstance, error) {
+	fake.preHookFailedMutex.RLock()
+	defer fake.preHookFailedMutex.RUnlock()
+	argsForCall := fake.preHookFailedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeLogger) Printf(arg1 string, arg2 ...any) {
 	fake.printfMutex.Lock()
 	fake.printfArgsForCall = append(fake.printfArgsForCall, struct {
@@ -243,6 +373,39 @@ func (fake *FakeLogger) SkippingInstanceArgsForCall(i int) ccapi.ServiceInstance
 	return argsForCall.arg1
 }
 
+func (fake *FakeLogger) UpgradeDegraded(arg1 ccapi.ServiceInstance, arg2 error) {
+	fake.upgradeDegradedMutex.Lock()
+	fake.upgradeDegradedArgsForCall = append(fake.upgradeDegradedArgsForCall, struct {
+		arg1 ccapi.ServiceInstance
+		arg2 error
+	}{arg1, arg2})
+	stub := fake.UpgradeDegradedStub
+	fake.recordInvocation("UpgradeDegraded", []interface{}{arg1, arg2})
+	fake.upgradeDegradedMutex.Unlock()
+	if stub != nil {
+		fake.UpgradeDegradedStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeLogger) UpgradeDegradedCallCount() int {
+	fake.upgradeDegradedMutex.RLock()
+	defer fake.upgradeDegradedMutex.RUnlock()
+	return len(fake.upgradeDegradedArgsForCall)
+}
+
+func (fake *FakeLogger) UpgradeDegradedCalls(stub func(ccapi.ServiceInstance, error)) {
+	fake.upgradeDegradedMutex.Lock()
+	defer fake.upgradeDegradedMutex.Unlock()
+	fake.UpgradeDegradedStub = stub
+}
+
+func (fake *FakeLogger) UpgradeDegradedArgsForCall(i int) (ccapi.ServiceInstance, error) {
+	fake.upgradeDegradedMutex.RLock()
+	defer fake.upgradeDegradedMutex.RUnlock()
+	argsForCall := fake.upgradeDegradedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
 func (fake *FakeLogger) UpgradeFailed(arg1 ccapi.ServiceInstance, arg2 int, arg3 int, arg4 time.Duration, arg5 error) {
 	fake.upgradeFailedMutex.Lock()
 	fake.upgradeFailedArgsForCall = append(fake.upgradeFailedArgsForCall, struct {
@@ -279,6 +442,40 @@ func (fake *FakeLogger) UpgradeFailedArgsForCall(i int) (ccapi.ServiceInstance,
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4, argsForCall.arg5
 }
 
+func (fake *FakeLogger) UpgradeNotAtTargetVersion(arg1 ccapi.ServiceInstance, arg2 string, arg3 error) {
+	fake.upgradeNotAtTargetVersionMutex.Lock()
+	fake.upgradeNotAtTargetVersionArgsForCall = append(fake.upgradeNotAtTargetVersionArgsForCall, struct {
+		arg1 ccapi.ServiceInstance
+		arg2 string
+		arg3 error
+	}{arg1, arg2, arg3})
+	stub := fake.UpgradeNotAtTargetVersionStub
+	fake.recordInvocation("UpgradeNotAtTargetVersion", []interface{}{arg1, arg2, arg3})
+	fake.upgradeNotAtTargetVersionMutex.Unlock()
+	if stub != nil {
+		fake.UpgradeNotAtTargetVersionStub(arg1, arg2, arg3)
+	}
+}
+
+func (fake *FakeLogger) UpgradeNotAtTargetVersionCallCount() int {
+	fake.upgradeNotAtTargetVersionMutex.RLock()
+	defer fake.upgradeNotAtTargetVersionMutex.RUnlock()
+	return len(fake.upgradeNotAtTargetVersionArgsForCall)
+}
+
+func (fake *FakeLogger) UpgradeNotAtTargetVersionCalls(stub func(ccapi.ServiceInstance, string, error)) {
+	fake.upgradeNotAtTargetVersionMutex.Lock()
+	defer fake.upgradeNotAtTargetVersionMutex.Unlock()
+	fake.UpgradeNotAtTargetVersionStub = stub
+}
+
+func (fake *FakeLogger) UpgradeNotAtTargetVersionArgsForCall(i int) (ccapi.ServiceInstance, string, error) {
+	fake.upgradeNotAtTargetVersionMutex.RLock()
+	defer fake.upgradeNotAtTargetVersionMutex.RUnlock()
+	argsForCall := fake.upgradeNotAtTargetVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
 func (fake *FakeLogger) UpgradeStarting(arg1 ccapi.ServiceInstance, arg2 int, arg3 int) {
 	fake.upgradeStartingMutex.Lock()
 	fake.upgradeStartingArgsForCall = append(fake.upgradeStartingArgsForCall, struct {
@@ -351,22 +548,6 @@ func (fake *FakeLogger) UpgradeSucceededArgsForCall(i int) (ccapi.ServiceInstanc
 func (fake *FakeLogger) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.finalTotalsMutex.RLock()
-	defer fake.finalTotalsMutex.RUnlock()
-	fake.hasUpgradeSucceededMutex.RLock()
-	defer fake.hasUpgradeSucceededMutex.RUnlock()
-	fake.initialTotalsMutex.RLock()
-	defer fake.initialTotalsMutex.RUnlock()
-	fake.printfMutex.RLock()
-	defer fake.printfMutex.RUnlock()
-	fake.skippingInstanceMutex.RLock()
-	defer fake.skippingInstanceMutex.RUnlock()
-	fake.upgradeFailedMutex.RLock()
-	defer fake.upgradeFailedMutex.RUnlock()
-	fake.upgradeStartingMutex.RLock()
-	defer fake.upgradeStartingMutex.RUnlock()
-	fake.upgradeSucceededMutex.RLock()
-	defer fake.upgradeSucceededMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,110 @@
+package upgrader
+
+import (
+	"fmt"
+	"slices"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/junit"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
+)
+
+// versionAnomaliesCheck fails for service instances whose version is inconsistent with the version of their plan,
+// which points to a bug in the broker or in the Cloud Controller. The version of a service instance should never be
+// ahead of its plan, and when it is behind, the Cloud Controller should say that an upgrade is available.
+// Brokers that do not use maintenance_info have no version for the plan or the service instances, which is not
+// an anomaly, but a version on only one of them is.
+type versionAnomaliesCheck struct {
+	comparison versionchecker.Comparison
+}
+
+func (versionAnomaliesCheck) Name() string {
+	return "check-version-anomalies"
+}
+
+func (c versionAnomaliesCheck) Run(instances groupedServiceInstances) (checkResult, error) {
+	var ahead, notFlagged, missing []ccapi.ServiceInstance
+	for _, instance := range instances.all {
+		switch c.anomaly(instance) {
+		case statusVersionAhead:
+			ahead = append(ahead, instance)
+		case statusUpgradeNotFlagged:
+			notFlagged = append(notFlagged, instance)
+		case statusMissingMaintenanceInfo:
+			missing = append(missing, instance)
+		}
+	}
+
+	r := checkResult{
+		name:       c.Name(),
+		violations: slices.Concat(ahead, notFlagged, missing),
+		reason:     "discovered service instances with a version that is inconsistent with their plan",
+		sections:   []summary.Set{versionAheadSet(ahead), upgradeNotFlaggedSet(notFlagged), missingMaintenanceInfoSet(missing)},
+		details:    versionAnomalies{check: c},
+	}
+	if len(r.violations) == 0 {
+		r.noneFound = "No instances found with a version that is inconsistent with their plan"
+	}
+	return r, nil
+}
+
+// anomaly classifies the version of the service instance against the version of its plan, using the same names
+// as the status column, or returns an empty string when they are consistent. Versions that cannot be compared are
+// only an anomaly when they differ and no upgrade is available. A missing version is not an anomaly when an upgrade
+// is available, for example for a service instance created before its plan had maintenance_info, as the upgrade
+// will set it.
+func (c versionAnomaliesCheck) anomaly(instance ccapi.ServiceInstance) string {
+	instanceVersion, planVersion := instance.MaintenanceInfoVersion, instance.ServicePlanMaintenanceInfoVersion
+	switch {
+	case instanceVersion == planVersion:
+		return ""
+	case instanceVersion == "" || planVersion == "":
+		if instance.UpgradeAvailable {
+			return ""
+		}
+		return statusMissingMaintenanceInfo
+	}
+
+	order, err := c.comparison.Compare(instanceVersion, planVersion)
+	switch {
+	case err == nil && order > 0:
+		return statusVersionAhead
+	case err == nil && order == 0, instance.UpgradeAvailable:
+		return ""
+	default:
+		return statusUpgradeNotFlagged
+	}
+}
+
+// versionAnomalies adds the version of the plan to each service instance in the JSON output, and the anomaly to
+// the JUnit report
+type versionAnomalies struct {
+	noDetails
+	check versionAnomaliesCheck
+}
+
+func (versionAnomalies) json(i jsonOutputServiceInstance, instance ccapi.ServiceInstance) jsonOutputServiceInstance {
+	i.PlanVersion = instance.ServicePlanMaintenanceInfoVersion
+	return i
+}
+
+func (a versionAnomalies) testCase(instance ccapi.ServiceInstance) junit.TestCase {
+	message := versionAnomalyMessage(a.check.anomaly(instance), instance)
+	if message == "" {
+		return junit.InstanceCase(instance)
+	}
+	return junit.InstanceCase(instance).WithFailure("VersionAnomaly", message)
+}
+
+func versionAnomalyMessage(anomaly string, instance ccapi.ServiceInstance) string {
+	switch anomaly {
+	case statusVersionAhead:
+		return fmt.Sprintf("version %q is ahead of the plan version %q", instance.MaintenanceInfoVersion, instance.ServicePlanMaintenanceInfoVersion)
+	case statusUpgradeNotFlagged:
+		return fmt.Sprintf("version %q differs from the plan version %q, but no upgrade is available", instance.MaintenanceInfoVersion, instance.ServicePlanMaintenanceInfoVersion)
+	case statusMissingMaintenanceInfo:
+		return fmt.Sprintf("maintenance_info is missing: the version is %q and the plan version is %q", instance.MaintenanceInfoVersion, instance.ServicePlanMaintenanceInfoVersion)
+	default:
+		return ""
+	}
+}
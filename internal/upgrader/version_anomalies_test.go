@@ -0,0 +1,130 @@
+package upgrader_test
+
+import (
+	"encoding/json"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("--check-version-anomalies", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+			{GUID: "fake-plan-guid", Available: true, MaintenanceInfoVersion: "1.6.0"},
+		}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "current-guid", Name: "current", MaintenanceInfoVersion: "1.6.0", ServicePlanMaintenanceInfoVersion: "1.6.0"},
+			{GUID: "pending-guid", Name: "pending", MaintenanceInfoVersion: "1.5.0", ServicePlanMaintenanceInfoVersion: "1.6.0", UpgradeAvailable: true},
+			{GUID: "ahead-guid", Name: "ahead", MaintenanceInfoVersion: "1.7.0", ServicePlanMaintenanceInfoVersion: "1.6.0"},
+			{GUID: "not-flagged-guid", Name: "not-flagged", MaintenanceInfoVersion: "1.5.0", ServicePlanMaintenanceInfoVersion: "1.6.0"},
+			{GUID: "missing-guid", Name: "missing", MaintenanceInfoVersion: "", ServicePlanMaintenanceInfoVersion: "1.6.0"},
+			{GUID: "suffix-guid", Name: "suffix", MaintenanceInfoVersion: "1.6.0-build.2", ServicePlanMaintenanceInfoVersion: "1.6.0"},
+		}, nil)
+
+		cfg = upgrader.UpgradeConfig{
+			BrokerName: fakeBrokerName,
+			Action:     config.CheckVersionAnomaliesAction,
+		}
+	})
+
+	It("lists the service instances with a version that is inconsistent with their plan", func() {
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+			Expect(err).To(MatchError("discovered service instances with a version that is inconsistent with their plan"))
+			Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(MatchRegexp(`(?s)Number of service instances with a version ahead of their plan: 1.*"ahead-guid"`))
+		Expect(output).To(MatchRegexp(`(?s)Number of service instances behind their plan without an upgrade available: 2.*"not-flagged-guid".*"suffix-guid"`))
+		Expect(output).To(MatchRegexp(`(?s)Number of service instances missing maintenance_info: 1.*"missing-guid"`))
+		Expect(output).NotTo(ContainSubstring("current-guid"))
+		Expect(output).NotTo(ContainSubstring("pending-guid"))
+	})
+
+	It("uses the version comparison", func() {
+		cfg.VersionComparison = versionchecker.IgnoreSuffixComparison
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(output).To(ContainSubstring("Number of service instances behind their plan without an upgrade available: 1"))
+		Expect(output).NotTo(ContainSubstring("suffix-guid"))
+	})
+
+	It("does not treat a broker without maintenance_info as an anomaly", func() {
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{{GUID: "fake-plan-guid", Available: true}}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{{GUID: "unversioned-guid", Name: "unversioned"}}, nil)
+
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(ContainSubstring("No instances found with a version that is inconsistent with their plan"))
+	})
+
+	It("does not treat a missing version as an anomaly when an upgrade is available", func() {
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "legacy-guid", Name: "legacy", MaintenanceInfoVersion: "", ServicePlanMaintenanceInfoVersion: "1.6.0", UpgradeAvailable: true},
+		}, nil)
+
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(ContainSubstring("No instances found with a version that is inconsistent with their plan"))
+		Expect(output).NotTo(ContainSubstring("legacy-guid"))
+	})
+
+	It("lists the service instances as JSON", func() {
+		cfg.JSONOutput = true
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		var data map[string][]map[string]any
+		Expect(json.Unmarshal([]byte(output), &data)).To(Succeed())
+		Expect(data).To(HaveKeyWithValue("version_ahead", HaveLen(1)))
+		Expect(data).To(HaveKeyWithValue("upgrade_not_flagged", HaveLen(2)))
+		Expect(data).To(HaveKeyWithValue("missing_maintenance_info", HaveLen(1)))
+		Expect(data["version_ahead"][0]).To(HaveKeyWithValue("service_plan", HaveKeyWithValue("maintenance_info", HaveKeyWithValue("version", "1.6.0"))))
+	})
+
+	It("writes the status column", func() {
+		cfg.Output = config.CSVOutput
+		cfg.Columns = []config.Column{config.StatusColumn, config.GUIDColumn}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(output).To(Equal("status,guid\nversion_ahead,ahead-guid\nupgrade_not_flagged,not-flagged-guid\nupgrade_not_flagged,suffix-guid\nmissing_maintenance_info,missing-guid\n"))
+	})
+
+	It("fails the test cases in the JUnit report", func() {
+		junitReport := gbytes.NewBuffer()
+		cfg.JUnitReport = junitReport
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`name="check-version-anomalies"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`type="VersionAnomaly"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`version &#34;1.7.0&#34; is ahead of the plan version &#34;1.6.0&#34;`))
+	})
+})
@@ -0,0 +1,238 @@
+package upgrader
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/junit"
+	"upgrade-all-services-cli-plugin/internal/summary"
+	"upgrade-all-services-cli-plugin/internal/templates"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
+)
+
+// versionCheckKind distinguishes the checks that compare the version of each service instance with a requirement.
+// They share the logic, and differ in how the results are described.
+type versionCheckKind int
+
+const (
+	minimumVersionCheck versionCheckKind = iota
+	minimumVersionPolicyCheck
+	versionConstraintCheck
+)
+
+// versionViolations are the service instances whose version does not meet the rule that applies to them,
+// along with the rule that each one broke, keyed by service instance GUID. Service instances whose version
+// cannot be parsed are kept apart, and only fail the check when failUnknown is set.
+type versionViolations struct {
+	kind        versionCheckKind
+	requirement string
+	instances   []ccapi.ServiceInstance
+	rules       map[string]versionchecker.Rule
+	unknown     []ccapi.ServiceInstance
+	failUnknown bool
+}
+
+// versionCheck is the Check for the minimum version, the minimum version policy, and the version constraint
+type versionCheck struct {
+	cfg UpgradeConfig
+}
+
+func (c versionCheck) Name() string {
+	return versionViolations{kind: versionCheckKindFor(c.cfg)}.name()
+}
+
+func (c versionCheck) Run(instances groupedServiceInstances) (checkResult, error) {
+	violations, err := findVersionViolations(instances.all, c.cfg)
+	if err != nil {
+		return checkResult{}, err
+	}
+	return violations.result(), nil
+}
+
+func (v versionViolations) text(instance ccapi.ServiceInstance) {
+	if rule, ok := v.rules[instance.GUID]; ok && v.kind == minimumVersionPolicyCheck {
+		fmt.Printf("  Minimum Version Rule: %q\n", rule)
+	}
+}
+
+func (v versionViolations) json(i jsonOutputServiceInstance, instance ccapi.ServiceInstance) jsonOutputServiceInstance {
+	if rule, ok := v.rules[instance.GUID]; ok && v.kind == minimumVersionPolicyCheck {
+		i.Rule = rule.String()
+	}
+	return i
+}
+
+func (v versionViolations) template(data *templates.Data) {
+	data.UnknownVersion = v.unknown
+	switch v.kind {
+	case versionConstraintCheck:
+		data.VersionConstraint = v.requirement
+		data.OutsideVersionConstraint = v.instances
+	default:
+		data.MinVersion = v.requirement
+		data.BelowMinVersion = v.instances
+	}
+}
+
+// findVersionViolations checks each service instance against the version constraint, the minimum version policy,
+// or the minimum version, depending on which was specified
+func findVersionViolations(instances []ccapi.ServiceInstance, cfg UpgradeConfig) (versionViolations, error) {
+	violations := versionViolations{
+		kind:        versionCheckKindFor(cfg),
+		rules:       make(map[string]versionchecker.Rule),
+		failUnknown: cfg.FailOnUnknownVersion,
+	}
+
+	var checker *versionchecker.Checker
+	switch violations.kind {
+	case versionConstraintCheck:
+		violations.requirement = cfg.VersionConstraint.String()
+		checker = versionchecker.New(cfg.VersionComparison, versionchecker.ConstraintRule(cfg.VersionConstraint))
+	case minimumVersionPolicyCheck:
+		checker = versionchecker.New(cfg.VersionComparison, cfg.MinVersionPolicy...)
+	default:
+		violations.requirement = cfg.MinVersion.String()
+		checker = versionchecker.New(cfg.VersionComparison, versionchecker.MinimumRule(cfg.MinVersion))
+	}
+
+	for _, instance := range instances {
+		rule, err := checker.Violation(instance)
+		switch {
+		case errors.Is(err, versionchecker.ErrUnknownVersion):
+			violations.unknown = append(violations.unknown, instance)
+		case err != nil:
+			return versionViolations{}, err
+		case rule != nil:
+			violations.instances = append(violations.instances, instance)
+			violations.rules[instance.GUID] = *rule
+		}
+	}
+	return violations, nil
+}
+
+func versionCheckKindFor(cfg UpgradeConfig) versionCheckKind {
+	switch {
+	case cfg.Action == config.VersionConstraintAction:
+		return versionConstraintCheck
+	case len(cfg.MinVersionPolicy) > 0:
+		return minimumVersionPolicyCheck
+	default:
+		return minimumVersionCheck
+	}
+}
+
+// result counts the service instances with an unknown version as violations only when failUnknown is set
+func (v versionViolations) result() checkResult {
+	r := checkResult{
+		name:       v.name(),
+		violations: v.instances,
+		reason:     v.reason(),
+		sections:   v.summarySets(),
+		headings:   map[string]string{v.status(): v.heading()},
+		listOutput: true,
+		details:    v,
+	}
+	if v.failUnknown {
+		r.violations = append(slices.Clone(v.instances), v.unknown...)
+	}
+	if len(v.instances) == 0 {
+		r.noneFound = v.noneFoundMessage()
+	}
+	return r
+}
+
+func (v versionViolations) noneFoundMessage() string {
+	switch v.kind {
+	case versionConstraintCheck:
+		return fmt.Sprintf("No instances found outside the version constraint %q", v.requirement)
+	case minimumVersionPolicyCheck:
+		return "No instances found that violate the minimum version policy"
+	default:
+		return fmt.Sprintf("No instances found with version lower than %q", v.requirement)
+	}
+}
+
+// heading is more specific than the title of the section, as it includes the requirement
+func (v versionViolations) heading() string {
+	switch v.kind {
+	case versionConstraintCheck:
+		return fmt.Sprintf("service instances outside the version constraint %q", v.requirement)
+	case minimumVersionPolicyCheck:
+		return "service instances that violate the minimum version policy"
+	default:
+		return fmt.Sprintf("service instances with a version lower than %q", v.requirement)
+	}
+}
+
+func (v versionViolations) reason() string {
+	var messages []string
+	switch {
+	case len(v.instances) == 0:
+	case v.kind == versionConstraintCheck:
+		messages = append(messages, fmt.Sprintf("found %d service instances outside the version constraint", len(v.instances)))
+	case v.kind == minimumVersionPolicyCheck:
+		messages = append(messages, fmt.Sprintf("found %d service instances that violate the minimum version policy", len(v.instances)))
+	default:
+		messages = append(messages, fmt.Sprintf("found %d service instances with a version less than the minimum required", len(v.instances)))
+	}
+
+	if v.failUnknown && len(v.unknown) > 0 {
+		messages = append(messages, fmt.Sprintf("found %d service instances with an unknown version", len(v.unknown)))
+	}
+
+	return strings.Join(messages, ", and ")
+}
+
+func (v versionViolations) status() string {
+	if v.kind == versionConstraintCheck {
+		return statusOutsideVersionConstraint
+	}
+	return statusBelowMinVersion
+}
+
+// summarySets only includes the service instances with an unknown version when there are some,
+// so that the summaries are unchanged for brokers whose service instances all have a version
+func (v versionViolations) summarySets() []summary.Set {
+	sets := []summary.Set{belowMinVersionSet(v.instances)}
+	if v.kind == versionConstraintCheck {
+		sets = []summary.Set{outsideVersionConstraintSet(v.instances)}
+	}
+
+	if len(v.unknown) > 0 {
+		sets = append(sets, unknownVersionSet(v.unknown))
+	}
+	return sets
+}
+
+// name is the name of the check, which is also the name of its JUnit test suite
+func (v versionViolations) name() string {
+	if v.kind == versionConstraintCheck {
+		return "version-constraint"
+	}
+	return "min-version-required"
+}
+
+// testCase fails the service instances that broke a rule. Service instances with an unknown version
+// fail when they count as violations, and are otherwise skipped.
+func (v versionViolations) testCase(instance ccapi.ServiceInstance) junit.TestCase {
+	rule, ok := v.rules[instance.GUID]
+	switch {
+	case slices.ContainsFunc(v.unknown, func(u ccapi.ServiceInstance) bool { return u.GUID == instance.GUID }):
+		message := fmt.Sprintf("version %q is not a semantic version", instance.MaintenanceInfoVersion)
+		if v.failUnknown {
+			return junit.InstanceCase(instance).WithFailure("UnknownVersion", message)
+		}
+		return junit.InstanceCase(instance).WithSkipped(message)
+	case !ok:
+		return junit.InstanceCase(instance)
+	case v.kind == versionConstraintCheck:
+		return junit.InstanceCase(instance).WithFailure("OutsideVersionConstraint", fmt.Sprintf("version %q does not satisfy the constraint %q", instance.MaintenanceInfoVersion, v.requirement))
+	case v.kind == minimumVersionPolicyCheck:
+		return junit.InstanceCase(instance).WithFailure("BelowMinimumVersion", fmt.Sprintf("version %q does not satisfy the minimum version rule %q", instance.MaintenanceInfoVersion, rule))
+	default:
+		return junit.InstanceCase(instance).WithFailure("BelowMinimumVersion", fmt.Sprintf("version %q is lower than the minimum required %q", instance.MaintenanceInfoVersion, v.requirement))
+	}
+}
@@ -0,0 +1,101 @@
+package upgrader_test
+
+import (
+	"encoding/json"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/upgrader"
+	"upgrade-all-services-cli-plugin/internal/upgrader/upgraderfakes"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("--version-constraint", func() {
+	const fakeBrokerName = "fake-broker-name"
+
+	var (
+		fakeCFClient *upgraderfakes.FakeCFClient
+		fakeLogger   *upgraderfakes.FakeLogger
+		cfg          upgrader.UpgradeConfig
+	)
+
+	BeforeEach(func() {
+		fakeCFClient = &upgraderfakes.FakeCFClient{}
+		fakeLogger = &upgraderfakes.FakeLogger{}
+
+		fakeCFClient.GetServicePlansReturns([]ccapi.ServicePlan{
+			{GUID: "fake-plan-guid", Available: true, MaintenanceInfoVersion: "1.6.0"},
+		}, nil)
+		fakeCFClient.GetServiceInstancesForServicePlansReturns([]ccapi.ServiceInstance{
+			{GUID: "good-guid", ServicePlanGUID: "fake-plan-guid", MaintenanceInfoVersion: "1.6.0"},
+			{GUID: "bad-release-guid", ServicePlanGUID: "fake-plan-guid", MaintenanceInfoVersion: "1.5.3"},
+			{GUID: "too-old-guid", ServicePlanGUID: "fake-plan-guid", MaintenanceInfoVersion: "1.3.0"},
+		}, nil)
+
+		cfg = upgrader.UpgradeConfig{
+			BrokerName:        fakeBrokerName,
+			Action:            config.VersionConstraintAction,
+			VersionConstraint: version.MustConstraints(version.NewConstraint(">= 1.4.0, != 1.5.3")),
+		}
+	})
+
+	It("reports service instances outside the constraint", func() {
+		output := captureStdout(func() {
+			err := upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)
+			Expect(err).To(MatchError("found 2 service instances outside the version constraint"))
+			Expect(err).To(BeAssignableToTypeOf(upgrader.InstanceError{}))
+		})
+
+		Expect(output).To(ContainSubstring(`Number of service instances outside the version constraint ">= 1.4.0, != 1.5.3": 2`))
+		Expect(output).To(ContainSubstring(`Service Instance GUID: "bad-release-guid"`))
+		Expect(output).To(ContainSubstring(`Service Instance GUID: "too-old-guid"`))
+		Expect(output).NotTo(ContainSubstring("good-guid"))
+		Expect(output).NotTo(ContainSubstring("Minimum Version Rule"))
+	})
+
+	It("succeeds when every service instance satisfies the constraint", func() {
+		cfg.VersionConstraint = version.MustConstraints(version.NewConstraint(">= 1.0.0"))
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(Succeed())
+		})
+
+		Expect(output).To(ContainSubstring(`No instances found outside the version constraint ">= 1.0.0"`))
+	})
+
+	It("lists the service instances as JSON", func() {
+		cfg.JSONOutput = true
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		var instances []map[string]any
+		Expect(json.Unmarshal([]byte(output), &instances)).To(Succeed())
+		Expect(instances).To(HaveLen(2))
+		Expect(instances[0]).NotTo(HaveKey("rule"))
+	})
+
+	It("writes the status column", func() {
+		cfg.Output = config.CSVOutput
+		cfg.Columns = []config.Column{config.StatusColumn, config.GUIDColumn}
+		output := captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(output).To(Equal("status,guid\noutside_version_constraint,bad-release-guid\noutside_version_constraint,too-old-guid\n"))
+	})
+
+	It("fails the test cases in the JUnit report", func() {
+		junitReport := gbytes.NewBuffer()
+		cfg.JUnitReport = junitReport
+		captureStdout(func() {
+			Expect(upgrader.Upgrade(fakeCFClient, fakeLogger, cfg)).To(HaveOccurred())
+		})
+
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`name="version-constraint"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`type="OutsideVersionConstraint"`))
+		Expect(string(junitReport.Contents())).To(ContainSubstring(`version &#34;1.5.3&#34; does not satisfy the constraint &#34;&gt;= 1.4.0, != 1.5.3&#34;`))
+	})
+})
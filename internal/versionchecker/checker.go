@@ -1,24 +1,176 @@
 package versionchecker
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"upgrade-all-services-cli-plugin/internal/ccapi"
 
 	"github.com/hashicorp/go-version"
 )
 
-type checker struct {
-	minimumVersionRequired *version.Version
+// ErrUnknownVersion is returned for a service instance whose version is empty or is not a semantic version
+var ErrUnknownVersion = errors.New("incorrect instance version")
+
+// AnyServiceOffering is the name used in a rule that applies to the service instances of every service offering
+const AnyServiceOffering = "*"
+
+// Rule is the version requirement for the service instances of a service offering, or of one plan of a
+// service offering. The requirement is either a minimum version, or a constraint expression such as "~> 1.2".
+type Rule struct {
+	ServiceOfferingName string
+	ServicePlanName     string
+	Minimum             *version.Version
+	Constraints         version.Constraints
+}
+
+// MinimumRule requires every service instance to have at least the minimum version
+func MinimumRule(minimum *version.Version) Rule {
+	return Rule{ServiceOfferingName: AnyServiceOffering, Minimum: minimum}
+}
+
+// ConstraintRule requires the version of every service instance to satisfy the constraints
+func ConstraintRule(constraints version.Constraints) Rule {
+	return Rule{ServiceOfferingName: AnyServiceOffering, Constraints: constraints}
+}
+
+// ParseRule parses a rule in the format: offering[:plan]=version-or-constraint
+func ParseRule(entry string) (Rule, error) {
+	selector, requirement, ok := strings.Cut(entry, "=")
+	if !ok || strings.TrimSpace(requirement) == "" {
+		return Rule{}, fmt.Errorf("invalid rule %q, expected format: offering[:plan]=version-or-constraint", entry)
+	}
+
+	var r Rule
+	offering, plan, hasPlan := strings.Cut(selector, ":")
+	r.ServiceOfferingName = strings.TrimSpace(offering)
+	r.ServicePlanName = strings.TrimSpace(plan)
+	switch {
+	case r.ServiceOfferingName == "", hasPlan && r.ServicePlanName == "":
+		return Rule{}, fmt.Errorf("invalid rule %q, expected format: offering[:plan]=version-or-constraint", entry)
+	case r.ServiceOfferingName == AnyServiceOffering && hasPlan:
+		return Rule{}, fmt.Errorf("invalid rule %q, a plan cannot be specified for any service offering", entry)
+	}
+
+	requirement = strings.TrimSpace(requirement)
+	if v, err := version.NewSemver(requirement); err == nil {
+		r.Minimum = v
+		return r, nil
+	}
+
+	constraints, err := version.NewConstraint(requirement)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid rule %q, %q is neither a version nor a constraint", entry, requirement)
+	}
+	r.Constraints = constraints
+	return r, nil
+}
+
+// Selector describes the service instances that the rule applies to
+func (r Rule) Selector() string {
+	if r.ServicePlanName == "" {
+		return r.ServiceOfferingName
+	}
+	return r.ServiceOfferingName + ":" + r.ServicePlanName
+}
+
+// Requirement describes the version that the rule requires
+func (r Rule) Requirement() string {
+	if r.Minimum != nil {
+		return ">= " + r.Minimum.String()
+	}
+	return r.Constraints.String()
 }
 
-func New(minimumRequiredVersion *version.Version) (*checker, error) {
-	return &checker{minimumVersionRequired: minimumRequiredVersion}, nil
+func (r Rule) String() string {
+	return fmt.Sprintf("%s %s", r.Selector(), r.Requirement())
 }
 
-func (c *checker) IsInstanceVersionLessThanMinimumRequired(instanceVersion string) (bool, error) {
-	iv, err := version.NewSemver(instanceVersion)
+func (r Rule) applies(instance ccapi.ServiceInstance) bool {
+	switch {
+	case r.ServiceOfferingName == AnyServiceOffering:
+		return true
+	case r.ServiceOfferingName != instance.ServiceOfferingName:
+		return false
+	default:
+		return r.ServicePlanName == "" || r.ServicePlanName == instance.ServicePlanName
+	}
+}
+
+// specificity ranks the rules, so that a rule for a plan is preferred to a rule for its service offering
+func (r Rule) specificity() int {
+	switch {
+	case r.ServiceOfferingName == AnyServiceOffering:
+		return 0
+	case r.ServicePlanName == "":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// satisfiedBy is true when the version meets the rule. A minimum version is compared directly, so that a
+// pre-release of a later version meets it, whereas constraints follow the usual pre-release rules.
+// The version has already been normalised for the comparison, which is applied to the minimum and to the
+// version in each constraint here.
+func (r Rule) satisfiedBy(v *version.Version, comparison Comparison) (bool, error) {
+	if r.Minimum == nil {
+		constraints, err := comparison.normalizeConstraints(r.Constraints)
+		if err != nil {
+			return false, fmt.Errorf("invalid constraint for rule %q: %w", r, err)
+		}
+		return constraints.Check(v), nil
+	}
+
+	minimum, err := comparison.normalize(r.Minimum)
+	if err != nil {
+		return false, fmt.Errorf("invalid minimum version for rule %q: %w", r, err)
+	}
+	return !v.LessThan(minimum), nil
+}
+
+type Checker struct {
+	comparison Comparison
+	rules      []Rule
+}
+
+func New(comparison Comparison, rules ...Rule) *Checker {
+	return &Checker{comparison: comparison, rules: rules}
+}
+
+// Violation returns the rule that the version of the service instance does not meet, or nil when
+// the version meets the most specific rule that applies, or when no rule applies. The error wraps
+// ErrUnknownVersion when a rule applies but the version cannot be parsed.
+func (c *Checker) Violation(instance ccapi.ServiceInstance) (*Rule, error) {
+	rule, ok := c.ruleFor(instance)
+	if !ok {
+		return nil, nil
+	}
+
+	iv, err := c.comparison.Parse(instance.MaintenanceInfoVersion)
 	if err != nil {
-		return false, fmt.Errorf("incorrect instance version: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrUnknownVersion, err)
 	}
 
-	return iv.LessThan(c.minimumVersionRequired), nil
+	switch ok, err := rule.satisfiedBy(iv, c.comparison); {
+	case err != nil:
+		return nil, err
+	case ok:
+		return nil, nil
+	default:
+		return &rule, nil
+	}
+}
+
+func (c *Checker) ruleFor(instance ccapi.ServiceInstance) (Rule, bool) {
+	var (
+		found Rule
+		ok    bool
+	)
+	for _, r := range c.rules {
+		if r.applies(instance) && (!ok || r.specificity() > found.specificity()) {
+			found, ok = r, true
+		}
+	}
+	return found, ok
 }
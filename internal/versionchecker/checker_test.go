@@ -0,0 +1,144 @@
+package versionchecker_test
+
+import (
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Rule", func() {
+	DescribeTable(
+		"parsing",
+		func(entry, expected string) {
+			r, err := versionchecker.ParseRule(entry)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.String()).To(Equal(expected))
+		},
+		Entry("minimum version for any offering", "*=1.0.0", "* >= 1.0.0"),
+		Entry("minimum version for an offering", "postgres=1.2.3", "postgres >= 1.2.3"),
+		Entry("constraint for a plan", "postgres:large=>= 1.6, < 2", "postgres:large >= 1.6, < 2"),
+		Entry("whitespace", " postgres : large = ~> 1.6 ", "postgres:large ~> 1.6"),
+	)
+
+	DescribeTable(
+		"invalid rules",
+		func(entry, expected string) {
+			_, err := versionchecker.ParseRule(entry)
+			Expect(err).To(MatchError(expected))
+		},
+		Entry("no version", "postgres", `invalid rule "postgres", expected format: offering[:plan]=version-or-constraint`),
+		Entry("empty version", "postgres=", `invalid rule "postgres=", expected format: offering[:plan]=version-or-constraint`),
+		Entry("no offering", "=1.2.3", `invalid rule "=1.2.3", expected format: offering[:plan]=version-or-constraint`),
+		Entry("empty plan", "postgres:=1.2.3", `invalid rule "postgres:=1.2.3", expected format: offering[:plan]=version-or-constraint`),
+		Entry("plan of any offering", "*:large=1.2.3", `invalid rule "*:large=1.2.3", a plan cannot be specified for any service offering`),
+		Entry("not a version", "postgres=latest", `invalid rule "postgres=latest", "latest" is neither a version nor a constraint`),
+	)
+})
+
+var _ = Describe("Checker", func() {
+	instance := func(offering, plan, v string) ccapi.ServiceInstance {
+		return ccapi.ServiceInstance{ServiceOfferingName: offering, ServicePlanName: plan, MaintenanceInfoVersion: v}
+	}
+
+	mustParse := func(entry string) versionchecker.Rule {
+		r, err := versionchecker.ParseRule(entry)
+		Expect(err).NotTo(HaveOccurred())
+		return r
+	}
+
+	When("there is a minimum version", func() {
+		var checker *versionchecker.Checker
+
+		BeforeEach(func() {
+			checker = versionchecker.New(versionchecker.SemverComparison, versionchecker.MinimumRule(version.Must(version.NewVersion("1.2.3"))))
+		})
+
+		It("reports versions lower than the minimum", func() {
+			rule, err := checker.Violation(instance("postgres", "small", "1.2.2"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rule).NotTo(BeNil())
+			Expect(rule.String()).To(Equal("* >= 1.2.3"))
+		})
+
+		It("accepts versions equal to or higher than the minimum", func() {
+			Expect(checker.Violation(instance("postgres", "small", "1.2.3"))).To(BeNil())
+			Expect(checker.Violation(instance("postgres", "small", "1.3.0-rc.1"))).To(BeNil())
+		})
+
+		It("fails for a version that cannot be parsed", func() {
+			_, err := checker.Violation(instance("postgres", "small", "not-a-version"))
+			Expect(err).To(MatchError(versionchecker.ErrUnknownVersion))
+			Expect(err).To(MatchError("incorrect instance version: Malformed version: not-a-version"))
+		})
+
+		It("fails for an empty version", func() {
+			_, err := checker.Violation(instance("postgres", "small", ""))
+			Expect(err).To(MatchError(versionchecker.ErrUnknownVersion))
+		})
+	})
+
+	When("there is a policy", func() {
+		var checker *versionchecker.Checker
+
+		BeforeEach(func() {
+			checker = versionchecker.New(
+				versionchecker.SemverComparison,
+				mustParse("postgres:large=>= 1.6, < 2"),
+				mustParse("*=1.0.0"),
+				mustParse("postgres=1.2.3"),
+			)
+		})
+
+		DescribeTable(
+			"applies the most specific rule",
+			func(offering, plan, v, expected string) {
+				rule, err := checker.Violation(instance(offering, plan, v))
+				Expect(err).NotTo(HaveOccurred())
+				if expected == "" {
+					Expect(rule).To(BeNil())
+				} else {
+					Expect(rule).NotTo(BeNil())
+					Expect(rule.String()).To(Equal(expected))
+				}
+			},
+			Entry("plan rule violated", "postgres", "large", "1.5.0", "postgres:large >= 1.6, < 2"),
+			Entry("plan rule violated by a later version", "postgres", "large", "2.0.0", "postgres:large >= 1.6, < 2"),
+			Entry("plan rule met", "postgres", "large", "1.6.1", ""),
+			Entry("offering rule violated", "postgres", "small", "1.2.0", "postgres >= 1.2.3"),
+			Entry("offering rule met", "postgres", "small", "1.2.3", ""),
+			Entry("wildcard rule violated", "mysql", "small", "0.9.0", "* >= 1.0.0"),
+			Entry("wildcard rule met", "mysql", "small", "1.0.0", ""),
+		)
+	})
+
+	When("there is a constraint", func() {
+		var checker *versionchecker.Checker
+
+		BeforeEach(func() {
+			checker = versionchecker.New(versionchecker.SemverComparison, versionchecker.ConstraintRule(version.MustConstraints(version.NewConstraint(">= 1.4.0, < 2.0.0, != 1.5.3"))))
+		})
+
+		DescribeTable(
+			"reports versions that do not satisfy it",
+			func(v string, violated bool) {
+				rule, err := checker.Violation(instance("postgres", "small", v))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(rule != nil).To(Equal(violated))
+			},
+			Entry("below the range", "1.3.9", true),
+			Entry("in the range", "1.4.0", false),
+			Entry("known bad release", "1.5.3", true),
+			Entry("above the range", "2.0.0", true),
+		)
+	})
+
+	When("no rule applies", func() {
+		It("does not check the version", func() {
+			checker := versionchecker.New(versionchecker.SemverComparison, mustParse("postgres=1.2.3"))
+			Expect(checker.Violation(instance("mysql", "small", "not-a-version"))).To(BeNil())
+		})
+	})
+})
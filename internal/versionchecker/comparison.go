@@ -0,0 +1,135 @@
+package versionchecker
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Comparison decides how a version suffix, such as "-build.3" in "2.10.14-build.3", affects the order of versions
+type Comparison string
+
+const (
+	// SemverComparison follows semantic versioning, so "2.10.14-build.3" is a pre-release that comes before "2.10.14"
+	SemverComparison Comparison = "semver"
+	// IgnoreSuffixComparison ignores the pre-release and build suffixes, so "2.10.14-build.3" equals "2.10.14"
+	IgnoreSuffixComparison Comparison = "ignore-suffix"
+	// PostReleaseComparison treats the number at the end of the suffix as a build of the release,
+	// so "2.10.14-build.3" comes after "2.10.14-build.2", which comes after "2.10.14". Build metadata
+	// such as "+build.3" is treated the same way when it ends with a number, and is ignored otherwise
+	PostReleaseComparison Comparison = "post-release"
+)
+
+var Comparisons = []Comparison{SemverComparison, IgnoreSuffixComparison, PostReleaseComparison}
+
+func ParseComparison(s string) (Comparison, error) {
+	for _, c := range Comparisons {
+		if string(c) == s {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown version comparison %q", s)
+}
+
+// Parse parses a version, and rewrites its suffix so that the usual semantic version ordering
+// and constraints give the order of the comparison
+func (c Comparison) Parse(s string) (*version.Version, error) {
+	v, err := version.NewSemver(s)
+	if err != nil {
+		return nil, err
+	}
+	return c.normalize(v)
+}
+
+// Compare returns -1, 0 or 1 depending on whether version a comes before, is equal to, or comes after version b
+func (c Comparison) Compare(a, b string) (int, error) {
+	va, err := c.Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := c.Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// normalizeConstraints applies the comparison to the version in each constraint, so that a constraint
+// such as "!= 2.10.14-build.3" is checked against the same order as a normalised instance version
+func (c Comparison) normalizeConstraints(constraints version.Constraints) (version.Constraints, error) {
+	if c == SemverComparison {
+		return constraints, nil
+	}
+
+	normalized := make([]string, 0, len(constraints))
+	for _, constraint := range constraints {
+		matches := constraintOperand.FindStringSubmatch(constraint.String())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid constraint %q", constraint)
+		}
+
+		operator, operand := matches[1], matches[2]
+		v, err := version.NewVersion(operand)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version in constraint %q: %w", constraint, err)
+		}
+
+		// A version without a suffix is kept as written, as "~> 2.10" and "~> 2.10.0" are different constraints
+		if v.Prerelease() != "" || v.Metadata() != "" {
+			n, err := c.normalize(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version in constraint %q: %w", constraint, err)
+			}
+			operand = n.String()
+		}
+		normalized = append(normalized, operator+" "+operand)
+	}
+	return version.NewConstraint(strings.Join(normalized, ","))
+}
+
+func (c Comparison) normalize(v *version.Version) (*version.Version, error) {
+	switch {
+	case c == IgnoreSuffixComparison:
+		return v.Core(), nil
+	case c == PostReleaseComparison && v.Prerelease() != "":
+		build, ok := buildNumber(v.Prerelease())
+		if !ok {
+			return nil, fmt.Errorf("suffix %q of version %q does not end with a build number", v.Prerelease(), v.Original())
+		}
+		return postRelease(v, build)
+	case c == PostReleaseComparison && v.Metadata() != "":
+		build, ok := buildNumber(v.Metadata())
+		if !ok {
+			return v, nil
+		}
+		return postRelease(v, build)
+	default:
+		return v, nil
+	}
+}
+
+var constraintOperand = regexp.MustCompile(`^\s*(!=|~>|>=|<=|=|>|<)?\s*(\S+)\s*$`)
+
+// buildNumber returns the number at the end of a suffix such as "build.3"
+func buildNumber(suffix string) (uint64, bool) {
+	identifiers := strings.Split(suffix, ".")
+	build, err := strconv.ParseUint(identifiers[len(identifiers)-1], 10, 64)
+	return build, err == nil
+}
+
+// postRelease appends the build number to the segments of the release, so that builds come after the release
+func postRelease(v *version.Version, build uint64) (*version.Version, error) {
+	segments := append(v.Core().Segments64(), int64(build))
+	return version.NewVersion(strings.Join(formatSegments(segments), "."))
+}
+
+func formatSegments(segments []int64) []string {
+	result := make([]string, 0, len(segments))
+	for _, s := range segments {
+		result = append(result, strconv.FormatInt(s, 10))
+	}
+	return result
+}
@@ -0,0 +1,102 @@
+package versionchecker_test
+
+import (
+	"upgrade-all-services-cli-plugin/internal/ccapi"
+	"upgrade-all-services-cli-plugin/internal/versionchecker"
+
+	"github.com/hashicorp/go-version"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Comparison", func() {
+	DescribeTable(
+		"comparing versions",
+		func(comparison versionchecker.Comparison, a, b string, expected int) {
+			Expect(comparison.Compare(a, b)).To(Equal(expected))
+		},
+		Entry("semver pre-release before release", versionchecker.SemverComparison, "2.10.14-build.3", "2.10.14", -1),
+		Entry("ignore-suffix pre-release equals release", versionchecker.IgnoreSuffixComparison, "2.10.14-build.3", "2.10.14", 0),
+		Entry("ignore-suffix before next release", versionchecker.IgnoreSuffixComparison, "2.10.14-build.3", "2.10.15", -1),
+		Entry("post-release build after release", versionchecker.PostReleaseComparison, "2.10.14-build.3", "2.10.14", 1),
+		Entry("post-release builds in order", versionchecker.PostReleaseComparison, "2.10.14-build.3", "2.10.14-build.12", -1),
+		Entry("post-release build before next release", versionchecker.PostReleaseComparison, "2.10.14-build.3", "2.10.15", -1),
+		Entry("post-release build metadata ignored", versionchecker.PostReleaseComparison, "2.10.14+abc", "2.10.14", 0),
+		Entry("post-release build metadata after release", versionchecker.PostReleaseComparison, "2.10.14+build.3", "2.10.14", 1),
+		Entry("post-release build metadata equals build suffix", versionchecker.PostReleaseComparison, "2.10.14+build.3", "2.10.14-build.3", 0),
+	)
+
+	It("fails for a post-release suffix without a build number", func() {
+		_, err := versionchecker.PostReleaseComparison.Compare("2.10.14-rc", "2.10.14")
+		Expect(err).To(MatchError(`suffix "rc" of version "2.10.14-rc" does not end with a build number`))
+	})
+
+	It("parses the names of the comparisons", func() {
+		Expect(versionchecker.ParseComparison("post-release")).To(Equal(versionchecker.PostReleaseComparison))
+		_, err := versionchecker.ParseComparison("loose")
+		Expect(err).To(MatchError(`unknown version comparison "loose"`))
+	})
+
+	Describe("checking versions", func() {
+		instance := ccapi.ServiceInstance{ServiceOfferingName: "postgres", MaintenanceInfoVersion: "2.10.14-build.3"}
+
+		DescribeTable(
+			"applies the comparison to minimum versions and constraints",
+			func(comparison versionchecker.Comparison, rule versionchecker.Rule, violated bool) {
+				found, err := versionchecker.New(comparison, rule).Violation(instance)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found != nil).To(Equal(violated))
+			},
+			Entry("semver minimum", versionchecker.SemverComparison, versionchecker.MinimumRule(version.Must(version.NewVersion("2.10.14"))), true),
+			Entry("ignore-suffix minimum", versionchecker.IgnoreSuffixComparison, versionchecker.MinimumRule(version.Must(version.NewVersion("2.10.14"))), false),
+			Entry("post-release minimum", versionchecker.PostReleaseComparison, versionchecker.MinimumRule(version.Must(version.NewVersion("2.10.14-build.4"))), true),
+			Entry("semver constraint", versionchecker.SemverComparison, versionchecker.ConstraintRule(version.MustConstraints(version.NewConstraint(">= 2.10"))), true),
+			Entry("ignore-suffix constraint", versionchecker.IgnoreSuffixComparison, versionchecker.ConstraintRule(version.MustConstraints(version.NewConstraint(">= 2.10"))), false),
+			Entry("post-release constraint", versionchecker.PostReleaseComparison, versionchecker.ConstraintRule(version.MustConstraints(version.NewConstraint("> 2.10.14, < 2.10.15"))), false),
+		)
+
+		DescribeTable(
+			"applies the comparison to the version in each constraint",
+			func(comparison versionchecker.Comparison, constraint string, violated bool) {
+				rule := versionchecker.ConstraintRule(version.MustConstraints(version.NewConstraint(constraint)))
+				found, err := versionchecker.New(comparison, rule).Violation(instance)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found != nil).To(Equal(violated))
+			},
+			Entry("semver !=", versionchecker.SemverComparison, "!= 2.10.14-build.3", true),
+			Entry("semver <", versionchecker.SemverComparison, "< 2.10.14-build.5", false),
+			Entry("semver ~>", versionchecker.SemverComparison, "~> 2.10.14", true),
+			Entry("semver ~> with suffix", versionchecker.SemverComparison, "~> 2.10.14-build.2", false),
+			Entry("semver =", versionchecker.SemverComparison, "= 2.10.14", true),
+			Entry("semver = with suffix", versionchecker.SemverComparison, "= 2.10.14-build.3", false),
+			Entry("ignore-suffix !=", versionchecker.IgnoreSuffixComparison, "!= 2.10.14-build.3", true),
+			Entry("ignore-suffix < an equal version", versionchecker.IgnoreSuffixComparison, "< 2.10.14-build.5", true),
+			Entry("ignore-suffix < a later version", versionchecker.IgnoreSuffixComparison, "< 2.10.15-build.1", false),
+			Entry("ignore-suffix ~>", versionchecker.IgnoreSuffixComparison, "~> 2.10.14", false),
+			Entry("ignore-suffix ~> with suffix", versionchecker.IgnoreSuffixComparison, "~> 2.10.14-build.2", false),
+			Entry("ignore-suffix =", versionchecker.IgnoreSuffixComparison, "= 2.10.14", false),
+			Entry("ignore-suffix = with suffix", versionchecker.IgnoreSuffixComparison, "= 2.10.14-build.5", false),
+			Entry("post-release !=", versionchecker.PostReleaseComparison, "!= 2.10.14-build.3", true),
+			Entry("post-release != build metadata", versionchecker.PostReleaseComparison, "!= 2.10.14+build.3", true),
+			Entry("post-release <", versionchecker.PostReleaseComparison, "< 2.10.14-build.5", false),
+			Entry("post-release < an earlier build", versionchecker.PostReleaseComparison, "< 2.10.14-build.2", true),
+			Entry("post-release ~>", versionchecker.PostReleaseComparison, "~> 2.10.14", false),
+			Entry("post-release ~> with suffix", versionchecker.PostReleaseComparison, "~> 2.10.14-build.2", false),
+			Entry("post-release ~> a later build", versionchecker.PostReleaseComparison, "~> 2.10.14-build.4", true),
+			Entry("post-release =", versionchecker.PostReleaseComparison, "= 2.10.14", true),
+			Entry("post-release = with suffix", versionchecker.PostReleaseComparison, "= 2.10.14-build.3", false),
+		)
+
+		It("fails for a post-release constraint whose suffix has no build number", func() {
+			rule := versionchecker.ConstraintRule(version.MustConstraints(version.NewConstraint("!= 2.10.14-rc")))
+			_, err := versionchecker.New(versionchecker.PostReleaseComparison, rule).Violation(instance)
+			Expect(err).To(MatchError(`invalid constraint for rule "* != 2.10.14-rc": invalid version in constraint "!= 2.10.14-rc": suffix "rc" of version "2.10.14-rc" does not end with a build number`))
+		})
+
+		It("reports an unknown version for a post-release suffix without a build number", func() {
+			checker := versionchecker.New(versionchecker.PostReleaseComparison, versionchecker.MinimumRule(version.Must(version.NewVersion("1.0.0"))))
+			_, err := checker.Violation(ccapi.ServiceInstance{MaintenanceInfoVersion: "2.10.14-rc"})
+			Expect(err).To(MatchError(versionchecker.ErrUnknownVersion))
+		})
+	})
+})
@@ -0,0 +1,13 @@
+package versionchecker_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestVersionChecker(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Version Checker Suite")
+}
@@ -3,13 +3,19 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"text/template"
 	"time"
 
 	"upgrade-all-services-cli-plugin/internal/ccapi"
 	"upgrade-all-services-cli-plugin/internal/config"
+	"upgrade-all-services-cli-plugin/internal/hooks"
 	"upgrade-all-services-cli-plugin/internal/logger"
+	"upgrade-all-services-cli-plugin/internal/notifier"
+	"upgrade-all-services-cli-plugin/internal/report"
 	"upgrade-all-services-cli-plugin/internal/requester"
+	"upgrade-all-services-cli-plugin/internal/templates"
 	"upgrade-all-services-cli-plugin/internal/upgrader"
 
 	"code.cloudfoundry.org/cli/v8/plugin"
@@ -33,23 +39,112 @@ func upgradeAllServices(cliConnection plugin.CliConnection, args []string) int {
 		return exitError
 	}
 
-	logr := logger.New(time.Minute)
+	reportOutput, err := openReportOutput(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "upgrade-all-services plugin failed: %s", err)
+		return exitError
+	}
+	if reportOutput != nil && reportOutput != os.Stdout {
+		defer reportOutput.Close()
+	}
+
+	junitOutput, err := openJUnitReport(cfg.JUnitReportFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "upgrade-all-services plugin failed: %s", err)
+		return exitError
+	}
+	if junitOutput != nil {
+		defer junitOutput.Close()
+	}
+
+	tmpl, err := parseTemplate(cfg.TemplateFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "upgrade-all-services plugin failed: %s", err)
+		return exitError
+	}
+
+	logr := newLogger(cfg, reportOutput == os.Stdout)
 	reqr := requester.NewRequester(cfg.APIEndpoint, cfg.APIToken, cfg.SkipSSLValidation)
 	if cfg.HTTPLogging {
 		reqr.Logger = logr
 	}
 
-	err = upgrader.Upgrade(ccapi.NewCCAPI(reqr, cfg.InstancePollingInterval), logr, upgrader.UpgradeConfig{
-		BrokerName:       cfg.BrokerName,
-		ParallelUpgrades: cfg.ParallelUpgrades,
-		Action:           cfg.Action,
-		MinVersion:       cfg.MinVersion,
-		JSONOutput:       cfg.JSONOutput,
-		Limit:            cfg.Limit,
-		Attempts:         cfg.Attempts,
-		RetryInterval:    cfg.RetryInterval,
+	var log upgrader.Logger = logr
+	var notify *notifier.Notifier
+	if cfg.NotifyURL != "" {
+		notify = notifier.New(cfg.NotifyURL, cfg.NotifyTimeout, logr, cfg.BrokerName, cfg.RunID)
+		log = notify
+	}
+
+	var recorder *report.Recorder
+	upgradeJUnit := junitOutput != nil && cfg.Action == config.UpgradeAction
+	upgradeSummary := cfg.Summary && cfg.Action == config.UpgradeAction
+	if reportOutput != nil || upgradeJUnit || upgradeSummary {
+		recorder = report.New(log, cfg.BrokerName, cfg.RunID)
+		recorder.IncludeSummary = cfg.Summary
+		log = recorder
+	}
+
+	err = upgrader.Upgrade(ccapi.NewCCAPI(reqr, cfg.InstancePollingInterval), log, upgrader.UpgradeConfig{
+		BrokerName:           cfg.BrokerName,
+		ParallelUpgrades:     cfg.ParallelUpgrades,
+		Action:               cfg.Action,
+		Checks:               cfg.Checks,
+		MinVersion:           cfg.MinVersion,
+		MinVersionPolicy:     cfg.MinVersionPolicy,
+		VersionConstraint:    cfg.VersionConstraint,
+		FailOnUnknownVersion: cfg.FailOnUnknownVersion,
+		VersionComparison:    cfg.VersionComparison,
+		Thresholds:           cfg.Thresholds,
+		MaxPendingAge:        cfg.MaxPendingAge,
+		PendingSinceFile:     cfg.PendingSinceFile,
+		PlanMappings:         cfg.PlanMappings,
+		ReplacementPlans:     cfg.ReplacementPlans,
+		JSONOutput:           cfg.JSONOutput,
+		Output:               cfg.Output,
+		Columns:              cfg.Columns,
+		Template:             tmpl,
+		Summary:              cfg.Summary,
+		BlastRadius:          cfg.BlastRadius,
+		Limit:                cfg.Limit,
+		Attempts:             cfg.Attempts,
+		RetryInterval:        cfg.RetryInterval,
+		Annotate:             cfg.Annotate,
+		Username:             cfg.Username,
+		RunID:                cfg.RunID,
+		PreHook:              newHook(cfg.PreHook, cfg.HookTimeout),
+		PostHook:             newHook(cfg.PostHook, cfg.HookTimeout),
+		HealthCheckWindow:    cfg.HealthCheckWindow,
+		HealthCheckInterval:  cfg.InstancePollingInterval,
+		StopOnDegraded:       cfg.StopOnDegraded,
+		JUnitReport:          checkJUnitOutput(junitOutput, cfg.Action),
 	})
 
+	if notify != nil {
+		notify.Completed(err)
+	}
+
+	if upgradeSummary {
+		if summaryErr := recorder.WriteSummary(logOutput(cfg, reportOutput == os.Stdout)); summaryErr != nil {
+			fmt.Fprintf(os.Stderr, "upgrade-all-services plugin error: writing summary: %s", summaryErr)
+			return exitError
+		}
+	}
+
+	if reportOutput != nil {
+		if reportErr := recorder.Write(reportOutput, err); reportErr != nil {
+			fmt.Fprintf(os.Stderr, "upgrade-all-services plugin error: writing report: %s", reportErr)
+			return exitError
+		}
+	}
+
+	if upgradeJUnit {
+		if reportErr := recorder.WriteJUnit(junitOutput); reportErr != nil {
+			fmt.Fprintf(os.Stderr, "upgrade-all-services plugin error: writing JUnit report: %s", reportErr)
+			return exitError
+		}
+	}
+
 	isInstanceError := errors.As(err, &upgrader.InstanceError{})
 
 	switch {
@@ -66,3 +161,88 @@ func upgradeAllServices(cliConnection plugin.CliConnection, args []string) int {
 		return exitError
 	}
 }
+
+// newHook returns a runner for the hook command, or nil when no command was specified
+func newHook(command string, timeout time.Duration) upgrader.HookRunner {
+	if command == "" {
+		return nil
+	}
+	return hooks.New(command, timeout)
+}
+
+// logOutput returns where the text log is written, which is stderr when stdout is being used for JSON output
+func logOutput(cfg config.Config, reportOnStdout bool) io.Writer {
+	if cfg.Output == config.JSONLinesOutput || reportOnStdout {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// newLogger creates a logger that writes text to stdout, unless stdout is being used for JSON output
+func newLogger(cfg config.Config, reportOnStdout bool) *logger.Logger {
+	l := newLoggerForOutput(cfg, reportOnStdout)
+	if cfg.Action == config.MigratePlansAction {
+		l.SetOperation(logger.OperationPlanMigration)
+	}
+	return l
+}
+
+func newLoggerForOutput(cfg config.Config, reportOnStdout bool) *logger.Logger {
+	switch {
+	case cfg.Output == config.JSONLinesOutput:
+		return logger.NewWithSinks(time.Minute, logger.NewTextSink(os.Stderr), logger.NewJSONLinesSink(os.Stdout, cfg.RunID))
+	case reportOnStdout:
+		return logger.NewWithOutput(time.Minute, os.Stderr)
+	default:
+		return logger.New(time.Minute)
+	}
+}
+
+// parseTemplate reads the template file before any work starts, or returns nil when no template was specified
+func parseTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return templates.ParseFile(path)
+}
+
+// openJUnitReport creates the JUnit report file before any work starts, or returns nil when no report was requested
+func openJUnitReport(path string) (*os.File, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating JUnit report file: %w", err)
+	}
+	return f, nil
+}
+
+// checkJUnitOutput returns where the checks should write their JUnit report. The report for an upgrade
+// is written from the recorded events instead.
+func checkJUnitOutput(junitOutput *os.File, action config.Action) io.Writer {
+	if junitOutput == nil || action == config.UpgradeAction {
+		return nil
+	}
+	return junitOutput
+}
+
+// openReportOutput returns where the JSON report of an upgrade should be written, or nil when no report
+// was requested. The report file is created before the upgrade starts so that a bad path is found early.
+func openReportOutput(cfg config.Config) (*os.File, error) {
+	switch {
+	case cfg.Action != config.UpgradeAction:
+		return nil, nil
+	case cfg.ReportFile != "":
+		f, err := os.Create(cfg.ReportFile)
+		if err != nil {
+			return nil, fmt.Errorf("error creating report file: %w", err)
+		}
+		return f, nil
+	case cfg.JSONOutput:
+		return os.Stdout, nil
+	default:
+		return nil, nil
+	}
+}